@@ -17,7 +17,10 @@ limitations under the License.
 package v1beta1
 
 import (
+	acmev1 "github.com/jetstack/cert-manager/pkg/apis/acme/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/json"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,6 +30,69 @@ import (
 type GitLabSpec struct {
 	// The specification of GitLab Chart that is used to deploy the instance.
 	Chart GitLabChartSpec `json:"chart,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=minimal;"1k";"3k";"10k"
+	// Profile sizes the components after a GitLab reference architecture. It
+	// sets the replicas, the resources, the Puma workers, the Sidekiq
+	// concurrency and the Gitaly resources. The chart values take precedence.
+	Profile string `json:"profile,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Secrets configures how the Operator manages the generated Secrets of the instance.
+	Secrets *GitLabSecretsSpec `json:"secrets,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ObjectStorage configures how the Operator manages the external object storage.
+	ObjectStorage *ObjectStorageSpec `json:"objectStorage,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Gitaly configures how the Operator manages Gitaly and the Praefect-managed
+	// Gitaly storages.
+	Gitaly *GitalySpec `json:"gitaly,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ExternalMigration moves the data of the bundled PostgreSQL and Redis to
+	// external services. Once the data is copied and verified, the instance
+	// uses the external services and the bundled ones are retired.
+	ExternalMigration *ExternalMigrationSpec `json:"externalMigration,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Geo configures the instance as a site of a GitLab Geo deployment.
+	Geo *GeoSpec `json:"geo,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Networking configures how the Operator exposes the instance.
+	Networking *NetworkingSpec `json:"networking,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NetworkPolicies configures the NetworkPolicies that the Operator
+	// generates for the components of the instance.
+	NetworkPolicies *NetworkPoliciesSpec `json:"networkPolicies,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Availability configures the PodDisruptionBudgets and the topology spread
+	// of the components that the Operator manages.
+	Availability *AvailabilitySpec `json:"availability,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CertManager configures the cert-manager issuers of the certificates of
+	// the Ingresses.
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// TLS configures how the Operator monitors the TLS certificates of the
+	// Ingresses.
+	TLS *TLSSpec `json:"tls,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Trust configures the custom certificate authorities that the components
+	// trust.
+	Trust *TrustSpec `json:"trust,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Autoscaling configures how the Operator scales the components.
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
 }
 
 // GitLabChartSpec specifies GitLab Chart version and values.
@@ -40,6 +106,474 @@ type GitLabChartSpec struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// ChartValues is the set of Helm values that is used to render the GitLab Chart.
 	Values ChartValues `json:"values,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ValuesFrom is an ordered list of ConfigMaps and Secrets in the namespace
+	// of the instance that hold Helm values. They are merged in order before
+	// the values of ChartValues, which take precedence.
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+}
+
+// ValuesReference selects the Helm values from a key of a ConfigMap or a
+// Secret.
+type ValuesReference struct {
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	// Kind is the kind of the object that holds the values.
+	Kind string `json:"kind"`
+
+	// +kubebuilder:validation:MinLength=1
+	// Name is the name of the object that holds the values.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=values.yaml
+	// ValuesKey is the key of the object that holds the values.
+	ValuesKey string `json:"valuesKey,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// TargetPath is the dot-separated path of a single value, for example
+	// `global.smtp.password`. When it is set, the content of the key is used
+	// as the value at the path instead of being parsed as YAML.
+	TargetPath string `json:"targetPath,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Optional ignores the reference when the object or the key does not
+	// exist.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// GitLabSecretsSpec configures the management of the generated Secrets.
+type GitLabSecretsSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Job;Operator
+	// +kubebuilder:default=Job
+	// Generator selects how the shared Secrets and self-signed certificates are
+	// generated. `Job` uses the Jobs of the GitLab Chart and `Operator` generates
+	// them directly from the Operator.
+	Generator string `json:"generator,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Rotation lists the generated Secrets that the Operator regenerates periodically.
+	Rotation []SecretRotationSpec `json:"rotation,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RotationOverlap is the minimum period during which the servers of a rotated
+	// Secret accept the previous value after its clients start to roll. Gitaly and
+	// Praefect accept any token during this period. Defaults to one hour.
+	RotationOverlap *metav1.Duration `json:"rotationOverlap,omitempty"`
+}
+
+// SecretRotationSpec specifies the rotation schedule of a generated Secret.
+type SecretRotationSpec struct {
+	// +kubebuilder:validation:Enum=Gitaly
+	// Secret is the generated Secret that is rotated. Only the Gitaly token can
+	// be rotated without an outage.
+	Secret string `json:"secret"`
+
+	// +kubebuilder:validation:Optional
+	// Interval is the maximum age of the Secret before it is regenerated.
+	// Defaults to 90 days.
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// ObjectStorageSpec configures the management of the external object storage.
+type ObjectStorageSpec struct {
+	// +kubebuilder:validation:Optional
+	// ProvisionBuckets enables the creation of the configured buckets that do
+	// not exist yet.
+	ProvisionBuckets bool `json:"provisionBuckets,omitempty"`
+}
+
+// GitalySpec configures the management of Gitaly.
+type GitalySpec struct {
+	// +kubebuilder:validation:Optional
+	// Rollout configures how changes to the Gitaly StatefulSets are rolled out.
+	Rollout *GitalyRolloutSpec `json:"rollout,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Migration moves the repositories of the standalone Gitaly to a Praefect
+	// virtual storage. The standalone Gitaly is retired once it is empty.
+	Migration *GitalyMigrationSpec `json:"migration,omitempty"`
+}
+
+// GitalyMigrationSpec specifies the migration of repositories from the
+// standalone Gitaly to Praefect.
+type GitalyMigrationSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=default
+	// SourceStorage is the name of the standalone Gitaly storage.
+	SourceStorage string `json:"sourceStorage,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+	// DestinationStorage is the name of the Praefect virtual storage.
+	DestinationStorage string `json:"destinationStorage"`
+}
+
+// GitalyRolloutSpec configures the rollout of the Gitaly StatefulSets.
+type GitalyRolloutSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Parallel;Staged
+	// +kubebuilder:default=Parallel
+	// Strategy selects how changes are rolled out. `Parallel` lets Kubernetes
+	// update the Pods of each StatefulSet. `Staged` updates one Gitaly Pod at a
+	// time and the Praefect virtual storages one after another.
+	Strategy string `json:"strategy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// SoakTime is the time to wait after an updated Pod becomes ready before
+	// the next Pod is updated. Defaults to five minutes.
+	SoakTime *metav1.Duration `json:"soakTime,omitempty"`
+}
+
+// ExternalMigrationSpec specifies the external services that the data of the
+// bundled services is moved to.
+type ExternalMigrationSpec struct {
+	// +kubebuilder:validation:Optional
+	// PostgreSQL is the external PostgreSQL server for the GitLab database.
+	PostgreSQL *ExternalPostgreSQLSpec `json:"postgresql,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Redis is the external Redis server.
+	Redis *ExternalRedisSpec `json:"redis,omitempty"`
+}
+
+// ExternalPostgreSQLSpec specifies the connection to an external PostgreSQL
+// server.
+type ExternalPostgreSQLSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// Host is the address of the PostgreSQL server.
+	Host string `json:"host"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=5432
+	// Port is the port of the PostgreSQL server.
+	Port int32 `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=gitlabhq_production
+	// Database is the name of the existing database for GitLab.
+	Database string `json:"database,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=gitlab
+	// Username is the user that owns the database.
+	Username string `json:"username,omitempty"`
+
+	// Password is the Secret that holds the password of the user.
+	Password SecretKeySpec `json:"password"`
+}
+
+// GeoSpec configures the role of the instance in a GitLab Geo deployment.
+type GeoSpec struct {
+	// +kubebuilder:validation:Enum=primary;secondary
+	// Role is the role of the site. It is either `primary` or `secondary`.
+	Role string `json:"role"`
+
+	// +kubebuilder:validation:Optional
+	// NodeName is the unique name of the site, as it is registered in the
+	// primary site. Defaults to the external URL of the instance.
+	NodeName string `json:"nodeName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Peer is the connection to the peer site. It is required for a secondary
+	// site, which reads from the replica of the database of the primary site.
+	Peer *GeoPeerSpec `json:"peer,omitempty"`
+}
+
+// GeoPeerSpec specifies the connection to the peer site of a Geo deployment.
+type GeoPeerSpec struct {
+	// Database is the read-only replica of the database of the primary site.
+	// Its password is read from the connection Secret of the peer.
+	Database ExternalPostgreSQLSpec `json:"database"`
+}
+
+// NetworkingSpec configures how the Operator exposes the instance.
+type NetworkingSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Ingress;Route;GatewayAPI
+	// +kubebuilder:default=Ingress
+	// Mode is the kind of objects that expose the instance. `Ingress` applies
+	// the Ingresses of the chart. `Route` converts them into OpenShift Routes.
+	// `GatewayAPI` converts them into Gateway API routes that attach to
+	// Gateway.
+	Mode string `json:"mode,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Gateway is the Gateway that the routes attach to. It is required in the
+	// `GatewayAPI` mode.
+	Gateway *GatewayReferenceSpec `json:"gateway,omitempty"`
+}
+
+// GatewayReferenceSpec references a Gateway of the Gateway API.
+type GatewayReferenceSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// Name is the name of the Gateway.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// Namespace is the namespace of the Gateway. Defaults to the namespace of
+	// the instance.
+	Namespace string `json:"namespace,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// HTTPListener is the name of the listener that the HTTPRoutes attach to.
+	// When it is not set, they attach to all the HTTP and HTTPS listeners of
+	// the Gateway.
+	HTTPListener string `json:"httpListener,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// SSHListener is the name of the TCP listener that the TCPRoute of GitLab
+	// Shell attaches to. When it is not set, it attaches to all the TCP
+	// listeners of the Gateway.
+	SSHListener string `json:"sshListener,omitempty"`
+}
+
+// NetworkPoliciesSpec configures the NetworkPolicies of the instance.
+type NetworkPoliciesSpec struct {
+	// +kubebuilder:validation:Optional
+	// Enabled generates a NetworkPolicy for each enabled component that only
+	// allows its known traffic. The NetworkPolicies are removed when it is
+	// disabled.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// AvailabilitySpec configures the PodDisruptionBudgets and the topology
+// spread of the components.
+type AvailabilitySpec struct {
+	// +kubebuilder:validation:Optional
+	// Enabled manages a PodDisruptionBudget for each workload of the
+	// components that has more than one replica and spreads its Pods across
+	// nodes and zones. When it is disabled, the components keep the behaviour
+	// of the chart.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=name
+	// Components overrides the defaults of individual components.
+	Components []ComponentAvailabilitySpec `json:"components,omitempty"`
+}
+
+// ComponentAvailabilitySpec overrides the PodDisruptionBudgets and the
+// topology spread of a component.
+type ComponentAvailabilitySpec struct {
+	// +kubebuilder:validation:Enum=gitaly;praefect;postgresql;redis;minio;webservice;sidekiq;gitlab-shell;registry;kas;gitlab-pages;mailroom;gitlab-exporter;nginx-ingress
+	// Name is the name of the component.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// Disabled keeps the behaviour of the chart for the component.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// MinAvailable is the minimum number of available Pods of each workload of
+	// the component. The PodDisruptionBudgets are created regardless of the
+	// number of replicas. It can not be combined with MaxUnavailable.
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// MaxUnavailable is the maximum number of unavailable Pods of each
+	// workload of the component. The PodDisruptionBudgets are created
+	// regardless of the number of replicas. Defaults to 1 for workloads with
+	// more than one replica.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// TopologySpreadConstraints replace the default constraints of the Pods of
+	// the component. A constraint without a label selector selects the Pods of
+	// the workload.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// CertManagerSpec configures the cert-manager issuers of the instance.
+type CertManagerSpec struct {
+	// +kubebuilder:validation:Optional
+	// Issuer references an existing Issuer or ClusterIssuer that issues the
+	// certificates of all the hosts. When it is set, the Operator does not
+	// create its own Issuer.
+	Issuer *IssuerReferenceSpec `json:"issuer,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DNS01 replaces the HTTP-01 solver of the ACME Issuer that the Operator
+	// creates. The credentials of the DNS provider are read from Secrets in
+	// the namespace of the instance. It is required for the wildcard
+	// certificate of GitLab Pages.
+	DNS01 *acmev1.ACMEChallengeSolverDNS01 `json:"dns01,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Hosts overrides the issuer of individual hosts.
+	Hosts *CertManagerHostsSpec `json:"hosts,omitempty"`
+}
+
+// CertManagerHostsSpec references the issuers of individual hosts.
+type CertManagerHostsSpec struct {
+	// +kubebuilder:validation:Optional
+	// GitLab is the issuer of the host of Webservice.
+	GitLab *IssuerReferenceSpec `json:"gitlab,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Registry is the issuer of the host of the Container Registry.
+	Registry *IssuerReferenceSpec `json:"registry,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Pages is the issuer of the wildcard host of GitLab Pages.
+	Pages *IssuerReferenceSpec `json:"pages,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// KAS is the issuer of the host of the GitLab agent server.
+	KAS *IssuerReferenceSpec `json:"kas,omitempty"`
+}
+
+// IssuerReferenceSpec references an existing cert-manager issuer.
+type IssuerReferenceSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// Name is the name of the issuer. An Issuer must be in the namespace of
+	// the instance.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default=Issuer
+	// Kind is the kind of the issuer.
+	Kind string `json:"kind,omitempty"`
+}
+
+// TLSSpec configures the monitoring of the TLS certificates.
+type TLSSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=30
+	// ExpiryWarningDays is the number of days before the expiry of a
+	// certificate when the Operator reports it as expiring.
+	ExpiryWarningDays int32 `json:"expiryWarningDays,omitempty"`
+}
+
+// TrustSpec configures the custom certificate authorities of the instance.
+type TrustSpec struct {
+	// +kubebuilder:validation:Optional
+	// CABundleRef references the PEM-encoded bundle of the custom certificate
+	// authorities. The bundle is mounted into all the Pods of the instance,
+	// and the Pods are restarted when it changes.
+	CABundleRef *CABundleReferenceSpec `json:"caBundleRef,omitempty"`
+}
+
+// CABundleReferenceSpec references a bundle of certificate authorities.
+type CABundleReferenceSpec struct {
+	// +kubebuilder:validation:Enum=ConfigMap;Secret;Bundle
+	// Kind is the kind of the object that contains the bundle. A trust-manager
+	// Bundle must target a ConfigMap in the namespace of the instance.
+	Kind string `json:"kind"`
+
+	// +kubebuilder:validation:MinLength=1
+	// Name is the name of the object. It must be in the namespace of the
+	// instance.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=ca.crt
+	// Key is the key of the bundle in the object. For a trust-manager Bundle,
+	// it is the key of its target ConfigMap.
+	Key string `json:"key,omitempty"`
+}
+
+// AutoscalingSpec configures the scaling of the components.
+type AutoscalingSpec struct {
+	// +kubebuilder:validation:Optional
+	// Sidekiq scales the Sidekiq Deployments on the depth and the latency of
+	// their queues instead of their CPU usage.
+	Sidekiq *SidekiqAutoscalingSpec `json:"sidekiq,omitempty"`
+}
+
+// SidekiqAutoscalingSpec configures the queue-based scaling of Sidekiq.
+type SidekiqAutoscalingSpec struct {
+	// +kubebuilder:validation:Optional
+	// Enabled replaces the HorizontalPodAutoscalers of the Sidekiq Deployments
+	// with the queue-based scaler of the Operator.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// MinReplicas is the minimum number of replicas of each Deployment. When
+	// it is 0, the Deployments without queued jobs are scaled to zero.
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=10
+	// MaxReplicas is the maximum number of replicas of each Deployment.
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	// TargetQueueDepth is the number of queued jobs that each replica is
+	// expected to handle.
+	TargetQueueDepth int32 `json:"targetQueueDepth,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="30s"
+	// TargetQueueLatency is the time that the oldest queued job can wait
+	// before the Deployment is scaled up.
+	TargetQueueLatency *metav1.Duration `json:"targetQueueLatency,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="5m"
+	// ScaleDownDelay is the time since the last scaling of a Deployment before
+	// it can be scaled down.
+	ScaleDownDelay *metav1.Duration `json:"scaleDownDelay,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=name
+	// Pods overrides the replicas of individual Sidekiq pods of the chart.
+	Pods []SidekiqPodAutoscalingSpec `json:"pods,omitempty"`
+}
+
+// SidekiqPodAutoscalingSpec overrides the replicas of a Sidekiq pod.
+type SidekiqPodAutoscalingSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// Name is the name of the pod in `gitlab.sidekiq.pods`.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// MinReplicas overrides the minimum number of replicas.
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// MaxReplicas overrides the maximum number of replicas.
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
+
+// ExternalRedisSpec specifies the connection to an external Redis server.
+type ExternalRedisSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// Host is the address of the Redis server.
+	Host string `json:"host"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=6379
+	// Port is the port of the Redis server.
+	Port int32 `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Password is the Secret that holds the password of the Redis server.
+	// The server does not require a password when it is not set.
+	Password *SecretKeySpec `json:"password,omitempty"`
+}
+
+// SecretKeySpec selects a key of a Secret in the namespace of the GitLab
+// resource.
+type SecretKeySpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// Secret is the name of the Secret.
+	Secret string `json:"secret"`
+
+	// +kubebuilder:validation:MinLength=1
+	// Key is the key of the Secret.
+	Key string `json:"key"`
 }
 
 // Unstructured values for rendering GitLab Chart.
@@ -79,6 +613,282 @@ type GitLabStatus struct {
 	Phase      string             `json:"phase,omitempty"`
 	Version    string             `json:"version,omitempty"`
 	Conditions []metav1.Condition `json:"conditions"`
+
+	// Secrets records the rotation state of the rotated Secrets.
+	Secrets []SecretRotationStatus `json:"secrets,omitempty"`
+
+	// Redis records the health of the external Redis instances.
+	Redis []RedisInstanceStatus `json:"redis,omitempty"`
+
+	// VolumeExpansions records the progress of expanding the volumes of the
+	// StatefulSets and the MinIO PersistentVolumeClaim.
+	VolumeExpansions []VolumeExpansionStatus `json:"volumeExpansions,omitempty"`
+
+	// GitalyRollouts records the progress of the staged rollouts of the Gitaly
+	// StatefulSets.
+	GitalyRollouts []GitalyRolloutStatus `json:"gitalyRollouts,omitempty"`
+
+	// GitalyMigration records the progress of the migration from the
+	// standalone Gitaly to Praefect.
+	GitalyMigration *GitalyMigrationStatus `json:"gitalyMigration,omitempty"`
+
+	// PostgreSQLUpgrade records the progress of the major version upgrade of
+	// the bundled PostgreSQL.
+	PostgreSQLUpgrade *PostgreSQLUpgradeStatus `json:"postgresqlUpgrade,omitempty"`
+
+	// ExternalMigrations records the progress of moving the data of the
+	// bundled services to external services.
+	ExternalMigrations []ExternalMigrationStatus `json:"externalMigrations,omitempty"`
+
+	// TLS records the TLS certificates of the Ingresses.
+	TLS []TLSCertificateStatus `json:"tls,omitempty"`
+
+	// SidekiqScaling records the queue-based scaling of the Sidekiq
+	// Deployments.
+	SidekiqScaling []SidekiqScalingStatus `json:"sidekiqScaling,omitempty"`
+}
+
+// SecretRotationStatus records the most recent rotation of a generated Secret.
+type SecretRotationStatus struct {
+	// Secret is the generated Secret that is rotated.
+	Secret string `json:"secret"`
+
+	// Name is the name of the rotated Kubernetes Secret.
+	Name string `json:"name"`
+
+	// LastRotationTime is the time when the Secret was last regenerated.
+	LastRotationTime metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// Phase is the phase of the rollout of the rotated Secret. It is empty when
+	// no rotation is in progress.
+	Phase string `json:"phase,omitempty"`
+}
+
+// RedisInstanceStatus records the health of an external Redis instance.
+type RedisInstanceStatus struct {
+	// Instance is the name of the Redis instance, for example `default` or
+	// `cache`.
+	Instance string `json:"instance"`
+
+	// Address is the address of the Redis server that was probed. When
+	// Sentinels are used, this is the address of the discovered master.
+	Address string `json:"address,omitempty"`
+
+	// Version is the version of the Redis server.
+	Version string `json:"version,omitempty"`
+
+	// Ready indicates that the Redis server is reachable and meets the
+	// requirements.
+	Ready bool `json:"ready"`
+
+	// Message describes why the Redis instance is not ready.
+	Message string `json:"message,omitempty"`
+
+	// LastProbeTime is the time of the probe that last changed the health of the
+	// Redis instance.
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty"`
+}
+
+// TLSCertificateStatus records the TLS certificate of an Ingress.
+type TLSCertificateStatus struct {
+	// Ingress is the name of the Ingress that uses the certificate.
+	Ingress string `json:"ingress"`
+
+	// Secret is the name of the TLS Secret of the certificate.
+	Secret string `json:"secret"`
+
+	// Hosts are the hosts of the Ingress that the certificate secures.
+	Hosts []string `json:"hosts,omitempty"`
+
+	// DNSNames are the subject alternative names of the certificate.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// Issuer is the distinguished name of the issuer of the certificate.
+	Issuer string `json:"issuer,omitempty"`
+
+	// NotAfter is the time when the certificate expires.
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// Message describes the problems of the certificate, for example the
+	// hosts that it does not cover.
+	Message string `json:"message,omitempty"`
+
+	// LastCheckTime is the time of the check that last changed the outcome for
+	// the certificate.
+	LastCheckTime metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+// SidekiqScalingStatus records the queue-based scaling of a Sidekiq
+// Deployment.
+type SidekiqScalingStatus struct {
+	// Deployment is the name of the Sidekiq Deployment.
+	Deployment string `json:"deployment"`
+
+	// Replicas is the number of replicas that the scaler set.
+	Replicas int32 `json:"replicas"`
+
+	// QueueDepth is the number of queued jobs of the queues of the
+	// Deployment.
+	QueueDepth int64 `json:"queueDepth"`
+
+	// QueueLatency is the time that the oldest queued job has waited.
+	QueueLatency metav1.Duration `json:"queueLatency,omitempty"`
+
+	// Message describes the problems of reading the queues.
+	Message string `json:"message,omitempty"`
+
+	// LastScaleTime is the time when the replicas last changed.
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+}
+
+// VolumeExpansionStatus records the progress of expanding a
+// PersistentVolumeClaim.
+type VolumeExpansionStatus struct {
+	// Workload is the name of the StatefulSet or Deployment that uses the
+	// PersistentVolumeClaim.
+	Workload string `json:"workload"`
+
+	// Claim is the name of the expanded PersistentVolumeClaim.
+	Claim string `json:"claim"`
+
+	// Size is the requested size of the PersistentVolumeClaim.
+	Size string `json:"size"`
+
+	// Phase is the phase of the expansion. It is one of `Pending`,
+	// `Resizing`, `FileSystemResizePending`, `Completed` or `Unsupported`.
+	Phase string `json:"phase"`
+
+	// Message describes the current phase of the expansion.
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is the time when the phase last changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// GitalyRolloutStatus records the progress of the staged rollout of a Gitaly
+// StatefulSet.
+type GitalyRolloutStatus struct {
+	// StatefulSet is the name of the Gitaly StatefulSet.
+	StatefulSet string `json:"statefulSet"`
+
+	// Partition is the ordinal from which the Pods are updated.
+	Partition int32 `json:"partition"`
+
+	// UpdatedReplicas is the number of Pods that run the update revision and
+	// are ready.
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+
+	// Replicas is the number of Pods of the StatefulSet.
+	Replicas int32 `json:"replicas"`
+
+	// Phase is the phase of the rollout. It is one of `Pending`, `RollingOut`,
+	// `Soaking` or `Completed`.
+	Phase string `json:"phase"`
+
+	// Message describes the current phase of the rollout.
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is the time when the phase last changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// GitalyMigrationStatus records the progress of the migration from the
+// standalone Gitaly to Praefect.
+type GitalyMigrationStatus struct {
+	// SourceStorage is the name of the standalone Gitaly storage.
+	SourceStorage string `json:"sourceStorage"`
+
+	// DestinationStorage is the name of the Praefect virtual storage.
+	DestinationStorage string `json:"destinationStorage"`
+
+	// Phase is the phase of the migration. It is one of `Pending`, `Moving`,
+	// `Completed` or `Failed`.
+	Phase string `json:"phase"`
+
+	// Attempt is the number of times the repository moves were scheduled.
+	Attempt int32 `json:"attempt,omitempty"`
+
+	// RemainingRepositories is the number of repositories that are still
+	// stored in the source storage.
+	RemainingRepositories int64 `json:"remainingRepositories,omitempty"`
+
+	// InProgressMoves is the number of repository moves that are scheduled or
+	// running.
+	InProgressMoves int64 `json:"inProgressMoves,omitempty"`
+
+	// FailedMoves is the number of repository moves that have failed.
+	FailedMoves int64 `json:"failedMoves,omitempty"`
+
+	// Message describes the current phase of the migration.
+	Message string `json:"message,omitempty"`
+
+	// StartTime is the time when the repository moves were first scheduled.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// LastCheckTime is the time when the progress was last checked.
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+
+	// CompletionTime is the time when the source storage became empty.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// PostgreSQLUpgradeStatus records the progress of the major version upgrade
+// of the bundled PostgreSQL.
+type PostgreSQLUpgradeStatus struct {
+	// FromVersion is the major version of PostgreSQL before the upgrade.
+	FromVersion int32 `json:"fromVersion"`
+
+	// ToVersion is the major version of PostgreSQL after the upgrade.
+	ToVersion int32 `json:"toVersion"`
+
+	// FromImage is the PostgreSQL image before the upgrade. It is used to
+	// back up the databases.
+	FromImage string `json:"fromImage,omitempty"`
+
+	// Phase is the phase of the upgrade. It is one of `BackingUp`,
+	// `Replacing`, `Restoring`, `Completed` or `Failed`.
+	Phase string `json:"phase"`
+
+	// Message describes the current phase of the upgrade.
+	Message string `json:"message,omitempty"`
+
+	// RetainedVolume is the PersistentVolume with the data directory of the
+	// previous major version. It is retained until it is deleted manually.
+	RetainedVolume string `json:"retainedVolume,omitempty"`
+
+	// StartTime is the time when the upgrade started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time when the upgrade completed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// ExternalMigrationStatus records the progress of moving the data of a
+// bundled service to an external service.
+type ExternalMigrationStatus struct {
+	// Service is the bundled service. It is one of `PostgreSQL` or `Redis`.
+	Service string `json:"service"`
+
+	// Phase is the phase of the migration. It is one of `Migrating`,
+	// `Completed` or `Failed`.
+	Phase string `json:"phase"`
+
+	// Message describes the current phase of the migration.
+	Message string `json:"message,omitempty"`
+
+	// SourceCount is the number of rows of all tables, or the number of keys,
+	// of the bundled service when it was copied.
+	SourceCount int64 `json:"sourceCount,omitempty"`
+
+	// DestinationCount is the number of rows of all tables, or the number of
+	// keys, of the external service after the copy.
+	DestinationCount int64 `json:"destinationCount,omitempty"`
+
+	// StartTime is the time when the migration started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time when the migration completed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
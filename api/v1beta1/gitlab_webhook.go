@@ -51,6 +51,26 @@ func (r *GitLab) ValidateCreate() (warnings admission.Warnings, err error) {
 		return
 	}
 
+	if validateErr := r.validateGeo(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
+	if validateErr := r.validateNetworking(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
+	if validateErr := r.validateAvailability(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
+	if validateErr := r.validateCertManager(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
 	return
 }
 
@@ -63,6 +83,26 @@ func (r *GitLab) ValidateUpdate(old runtime.Object) (warnings admission.Warnings
 		return
 	}
 
+	if validateErr := r.validateGeo(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
+	if validateErr := r.validateNetworking(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
+	if validateErr := r.validateAvailability(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
+	if validateErr := r.validateCertManager(); validateErr != nil {
+		err = newError(r.Name, validateErr)
+		return
+	}
+
 	return
 }
 
@@ -88,6 +128,59 @@ func (r GitLab) validateChartVersion() *field.Error {
 	return nil
 }
 
+func (r GitLab) validateGeo() *field.Error {
+	geo := r.Spec.Geo
+	if geo == nil || geo.Role != "secondary" {
+		return nil
+	}
+
+	if geo.Peer == nil {
+		return field.Required(field.NewPath("spec").Child("geo").Child("peer"),
+			"peer must be configured for a secondary site")
+	}
+
+	return nil
+}
+
+func (r GitLab) validateNetworking() *field.Error {
+	networking := r.Spec.Networking
+	if networking == nil || networking.Mode != "GatewayAPI" {
+		return nil
+	}
+
+	if networking.Gateway == nil {
+		return field.Required(field.NewPath("spec").Child("networking").Child("gateway"),
+			"gateway must be configured in the GatewayAPI mode")
+	}
+
+	return nil
+}
+
+func (r GitLab) validateAvailability() *field.Error {
+	if r.Spec.Availability == nil {
+		return nil
+	}
+
+	for i, component := range r.Spec.Availability.Components {
+		if component.MinAvailable != nil && component.MaxUnavailable != nil {
+			return field.Invalid(field.NewPath("spec").Child("availability").Child("components").Index(i),
+				component.Name, "minAvailable and maxUnavailable can not be combined")
+		}
+	}
+
+	return nil
+}
+
+func (r GitLab) validateCertManager() *field.Error {
+	certManager := r.Spec.CertManager
+	if certManager == nil || certManager.Issuer == nil || certManager.DNS01 == nil {
+		return nil
+	}
+
+	return field.Forbidden(field.NewPath("spec").Child("certManager").Child("dns01"),
+		"dns01 configures the Issuer of the Operator, which is not created when an issuer is referenced")
+}
+
 func newError(name string, err *field.Error) error {
 	return apierrors.NewInvalid(GroupKind, name, field.ErrorList{err})
 }
@@ -0,0 +1,135 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitLabRunnerSpec defines the desired state of GitLabRunner.
+type GitLabRunnerSpec struct {
+	// GitLab references the GitLab resource in the same namespace that the
+	// runner connects to.
+	GitLab corev1.LocalObjectReference `json:"gitlab"`
+
+	// +kubebuilder:validation:Optional
+	// Token is the Secret that holds the runner authentication token. When it
+	// is not set, the Operator creates an instance runner in GitLab with the
+	// Toolbox and stores its token in a Secret.
+	Token *SecretKeySpec `json:"token,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Version is the version of GitLab Runner, for example `16.11.0`. It
+	// defaults to the version that the GitLab Chart of the instance bundles,
+	// so that the runner is upgraded with the instance.
+	Version string `json:"version,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Image is the GitLab Runner image. It overrides the image of the version.
+	Image string `json:"image,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=10
+	// Concurrent is the maximum number of jobs that the runner runs at the
+	// same time.
+	Concurrent int32 `json:"concurrent,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Tags are the tags of the runner. They are only applied when the
+	// Operator creates the runner.
+	Tags []string `json:"tags,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RunUntagged allows the runner to pick jobs without tags. It is always
+	// allowed when the runner does not have tags. It is only applied when the
+	// Operator creates the runner.
+	RunUntagged bool `json:"runUntagged,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Kubernetes configures the Kubernetes executor.
+	Kubernetes *RunnerKubernetesSpec `json:"kubernetes,omitempty"`
+}
+
+// RunnerKubernetesSpec configures the Kubernetes executor of a runner.
+type RunnerKubernetesSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="alpine:latest"
+	// Image is the default image of the jobs.
+	Image string `json:"image,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Privileged runs the containers of the jobs in privileged mode.
+	Privileged bool `json:"privileged,omitempty"`
+}
+
+// GitLabRunnerStatus defines the observed state of GitLabRunner.
+type GitLabRunnerStatus struct {
+	// Phase represents the current phase of the runner. It is one of
+	// `Pending`, `Registering`, `Running` or `Failed`.
+	Phase string `json:"phase,omitempty"`
+
+	// Version is the version of GitLab Runner that is deployed.
+	Version string `json:"version,omitempty"`
+
+	// RunnerID is the ID of the runner that the Operator created in GitLab.
+	RunnerID int64 `json:"runnerID,omitempty"`
+
+	// TokenSecret is the name of the Secret that holds the runner
+	// authentication token.
+	TokenSecret string `json:"tokenSecret,omitempty"`
+
+	// Conditions represent the latest available observations of the runner.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=glr
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="GITLAB",type=string,JSONPath=`.spec.gitlab.name`
+// +kubebuilder:printcolumn:name="STATUS",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="VERSION",type=string,JSONPath=`.status.version`
+// +operator-sdk:csv:customresourcedefinitions:displayName="GitLab Runner"
+// +operator-sdk:csv:customresourcedefinitions:resources={{ConfigMap,v1,""},{Secret,v1,""},{ServiceAccount,v1,""},{Deployment,v1,""},{Job,v1,""}}
+
+// GitLabRunner is a GitLab Runner that runs the CI/CD jobs of a GitLab
+// instance with the Kubernetes executor.
+type GitLabRunner struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of the runner.
+	Spec GitLabRunnerSpec `json:"spec,omitempty"`
+
+	// Most recently observed status of the runner.
+	// It is read-only to the user.
+	Status GitLabRunnerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GitLabRunnerList contains a list of GitLabRunner.
+type GitLabRunnerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitLabRunner `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitLabRunner{}, &GitLabRunnerList{})
+}
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+// externalMigrationCheckInterval is the delay between checking the progress
+// of the migration of the bundled services to external services.
+const externalMigrationCheckInterval = 15 * time.Second
+
+// reconcileExternalMigration moves the data of the bundled PostgreSQL and
+// Redis to the external services of the GitLab resource. It returns true when
+// a migration is in progress or has just completed, in which case the other
+// components are not reconciled.
+//
+// A Job of each service stops GitLab from writing to the bundled service,
+// copies the data to the external service, and verifies the number of rows or
+// keys. Once the migration is completed, the values point to the external
+// service and the bundled service is no longer managed, so that it is
+// retired. Its PersistentVolumeClaims are kept.
+//
+// When the migration fails, GitLab keeps using the bundled service. The
+// migration is retried when its failed Job is deleted.
+func (r *GitLabReconciler) reconcileExternalMigration(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (bool, error) {
+	postgresPending, err := r.migratePostgresToExternal(ctx, adapter, template)
+	if err != nil {
+		return false, err
+	}
+
+	redisPending, err := r.migrateRedisToExternal(ctx, adapter, template)
+	if err != nil {
+		return false, err
+	}
+
+	return postgresPending || redisPending, nil
+}
+
+func (r *GitLabReconciler) migratePostgresToExternal(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (bool, error) {
+	migration := adapter.PostgreSQLMigration()
+	if migration == nil || isExternalMigrationCompleted(adapter, status.ExternalMigrationPostgreSQL) {
+		return false, nil
+	}
+
+	if err := r.validatePostgresMigration(ctx, adapter, migration); err != nil {
+		return false, r.blockExternalMigration(ctx, adapter, status.ExternalMigrationPostgreSQL, err)
+	}
+
+	statefulSet, err := internal.AsStatefulSet(gitlabctl.PostgresStatefulSet(adapter, template))
+	if err != nil {
+		return false, err
+	}
+
+	job, err := gitlabctl.PostgresMigrationJob(adapter, template, statefulSet, migration)
+	if err != nil {
+		return false, err
+	}
+
+	return r.runExternalMigration(ctx, adapter, status.ExternalMigrationPostgreSQL,
+		gitlabctl.PostgresService(adapter, template), job,
+		fmt.Sprintf("%s:%d/%s", migration.Host, migration.Port, migration.Database))
+}
+
+func (r *GitLabReconciler) migrateRedisToExternal(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (bool, error) {
+	migration := adapter.RedisMigration()
+	if migration == nil || isExternalMigrationCompleted(adapter, status.ExternalMigrationRedis) {
+		return false, nil
+	}
+
+	if err := r.validateRedisMigration(ctx, adapter, migration); err != nil {
+		return false, r.blockExternalMigration(ctx, adapter, status.ExternalMigrationRedis, err)
+	}
+
+	statefulSet, err := internal.AsStatefulSet(gitlabctl.RedisStatefulSet(adapter, template))
+	if err != nil {
+		return false, err
+	}
+
+	job, err := gitlabctl.RedisMigrationJob(adapter, template, statefulSet, migration)
+	if err != nil {
+		return false, err
+	}
+
+	return r.runExternalMigration(ctx, adapter, status.ExternalMigrationRedis,
+		gitlabctl.RedisMasterService(adapter, template), job,
+		fmt.Sprintf("%s:%d", migration.Host, migration.Port))
+}
+
+// validatePostgresMigration checks that the bundled PostgreSQL only holds the
+// GitLab database and that the password of the external server exists.
+func (r *GitLabReconciler) validatePostgresMigration(ctx context.Context, adapter gitlab.Adapter, migration *gitlab.PostgreSQLMigration) error {
+	if !adapter.WantsComponent(component.PostgreSQL) {
+		return fmt.Errorf("the bundled PostgreSQL is not installed")
+	}
+
+	if gitlabctl.ProvisionsPraefectDatabase(adapter) {
+		return fmt.Errorf("the Praefect database is provisioned in the bundled PostgreSQL and can not be migrated")
+	}
+
+	if gitlabctl.IsGeoSecondary(adapter) {
+		return fmt.Errorf("the bundled PostgreSQL of a Geo secondary site holds the tracking database and can not be migrated")
+	}
+
+	_, err := r.secretValue(ctx, adapter, migration.PasswordSecret, migration.PasswordKey)
+
+	return err
+}
+
+// validateRedisMigration checks that the password of the external server
+// exists, when it is required.
+func (r *GitLabReconciler) validateRedisMigration(ctx context.Context, adapter gitlab.Adapter, migration *gitlab.RedisMigration) error {
+	if !adapter.WantsComponent(component.Redis) {
+		return fmt.Errorf("the bundled Redis is not installed")
+	}
+
+	if migration.PasswordSecret == "" {
+		return nil
+	}
+
+	_, err := r.secretValue(ctx, adapter, migration.PasswordSecret, migration.PasswordKey)
+
+	return err
+}
+
+// blockExternalMigration records that the migration can not start. It is
+// recorded as failed and starts once the cause is resolved.
+func (r *GitLabReconciler) blockExternalMigration(ctx context.Context, adapter gitlab.Adapter, service string, cause error) error {
+	message := fmt.Sprintf("The migration of %s can not start: %v", service, cause)
+
+	if progress := adapter.ExternalMigrationProgress(service); progress != nil && progress.Message == message {
+		return nil
+	}
+
+	r.Recorder.Event(adapter.Origin(), "Warning", "ExternalMigrationBlocked", message)
+
+	adapter.RecordExternalMigration(gitlab.ExternalMigrationProgress{
+		Service: service,
+		Phase:   status.ExternalMigrationFailed,
+		Message: message,
+	})
+
+	return r.Status().Update(ctx, adapter.Origin())
+}
+
+// runExternalMigration runs the migration Job of the service once the bundled
+// service is ready and records its outcome. It returns true while the Job is
+// running and when it has just completed.
+func (r *GitLabReconciler) runExternalMigration(ctx context.Context, adapter gitlab.Adapter, service string, endpoint client.Object, job *batchv1.Job, destination string) (bool, error) {
+	progress := adapter.ExternalMigrationProgress(service)
+	if progress == nil {
+		progress = &gitlab.ExternalMigrationProgress{Service: service}
+	}
+
+	lookup, err := r.lookupJob(ctx, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	serviceReady := progress.Phase != status.ExternalMigrationMigrating &&
+		endpoint != nil && r.isEndpointReady(ctx, endpoint.GetName(), adapter)
+
+	phase := internal.NextExternalMigrationPhase(progress.Phase, internal.JobOutcomeOf(lookup), serviceReady)
+
+	switch {
+	case phase != status.ExternalMigrationMigrating && progress.Phase == phase:
+		// Keep the failed Job until it is deleted to retry the migration.
+		if lookup != nil {
+			return false, r.createOrPatch(ctx, job, adapter)
+		}
+
+		return false, nil
+	case phase == status.ExternalMigrationMigrating:
+		if progress.Phase != phase {
+			progress.Phase = phase
+			progress.Message = fmt.Sprintf("Copying the data of the bundled %s to %s. GitLab is unavailable during the migration",
+				service, destination)
+
+			r.Recorder.Event(adapter.Origin(), "Normal", "ExternalMigrationStarted", progress.Message)
+		}
+
+		if err := r.createOrPatch(ctx, job, adapter); err != nil {
+			return false, err
+		}
+	case phase == status.ExternalMigrationFailed:
+		progress.Phase = phase
+		progress.Message = fmt.Sprintf("Job %s failed to copy the data of the bundled %s. GitLab keeps using the bundled %s",
+			job.Name, service, service)
+
+		r.Recorder.Event(adapter.Origin(), "Warning", "ExternalMigrationFailed", progress.Message)
+	case phase == status.ExternalMigrationCompleted:
+		message, err := jobTerminationMessage(ctx, r, lookup)
+		if err != nil {
+			return false, err
+		}
+
+		counts, err := internal.ParseTerminationMessage[internal.ExternalMigrationCounts](message)
+		if err != nil {
+			return false, err
+		}
+
+		progress.Phase = phase
+		progress.SourceCount = counts.Source
+		progress.DestinationCount = counts.Destination
+		progress.Message = fmt.Sprintf("Copied the data of the bundled %s to %s. GitLab uses the external %s",
+			service, destination, service)
+
+		r.Recorder.Event(adapter.Origin(), "Normal", "ExternalMigrationCompleted", progress.Message)
+	}
+
+	adapter.RecordExternalMigration(*progress)
+
+	return phase != status.ExternalMigrationFailed, r.Status().Update(ctx, adapter.Origin())
+}
+
+// isExternalMigrationCompleted returns true when the data of the bundled
+// service is moved to the external service.
+func isExternalMigrationCompleted(adapter gitlab.Adapter, service string) bool {
+	progress := adapter.ExternalMigrationProgress(service)
+
+	return progress != nil && progress.Phase == status.ExternalMigrationCompleted
+}
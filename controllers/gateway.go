@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+// reconcileHTTPRoutes applies the HTTPRoutes that replace the Ingress. The
+// Ingress itself is not applied.
+func (r *GitLabReconciler) reconcileHTTPRoutes(ctx context.Context, ingress *networkingv1.Ingress, adapter gitlab.Adapter, template helm.Template) error {
+	gateway, err := networkingGateway(adapter)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range gitlabctl.IngressHTTPRoutes(ingress, template, gateway) {
+		if err := r.createOrPatch(ctx, route, adapter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileShellTCPRoute applies the TCPRoute that exposes the SSH port of
+// GitLab Shell through the Gateway.
+func (r *GitLabReconciler) reconcileShellTCPRoute(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
+	gateway, err := networkingGateway(adapter)
+	if err != nil {
+		return err
+	}
+
+	route := gitlabctl.ShellTCPRoute(template, gateway)
+	if route == nil {
+		return nil
+	}
+
+	return r.createOrPatch(ctx, route, adapter)
+}
+
+func networkingGateway(adapter gitlab.Adapter) (*gitlab.GatewayReference, error) {
+	gateway := adapter.NetworkingGateway()
+	if gateway == nil {
+		return nil, fmt.Errorf("the Gateway is not configured in the %s networking mode", gitlab.NetworkingModeGatewayAPI)
+	}
+
+	return gateway, nil
+}
+
+// newGatewayRoute returns an empty route of the Gateway API. The Operator does
+// not depend on the Gateway API, so routes are unstructured objects.
+func newGatewayRoute(apiVersion, kind string) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetAPIVersion(apiVersion)
+	route.SetKind(kind)
+
+	return route
+}
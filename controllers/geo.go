@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+const (
+	// geoCheckInterval is the delay between checking the replication of the
+	// database of the primary site.
+	geoCheckInterval = time.Minute
+
+	// geoMaximumReplicationLag is the size of the WAL that the replica of a
+	// secondary site did not replay yet, above which the replication is
+	// reported as not ready.
+	geoMaximumReplicationLag = 256 << 20
+)
+
+// generateGeoSecrets creates the Secrets of a secondary Geo site that the
+// shared secrets Job does not generate.
+func (r *GitLabReconciler) generateGeoSecrets(ctx context.Context, adapter gitlab.Adapter) error {
+	for _, s := range gitlabctl.GeoSecrets(adapter) {
+		geoSecret := s
+
+		err := r.createSecretIfMissing(ctx, adapter, geoSecret.Name, func() ([]*corev1.Secret, error) {
+			data, err := geoSecret.Generate()
+			if err != nil {
+				return nil, err
+			}
+
+			return []*corev1.Secret{gitlabctl.NewSharedSecret(adapter, geoSecret.Name, data)}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// provisionGeoTrackingDatabase creates the Geo tracking database and user of
+// a secondary site in the bundled PostgreSQL. It returns false while the
+// tracking database is not ready, in which case the migrations and the
+// components of the secondary site are not reconciled.
+func (r *GitLabReconciler) provisionGeoTrackingDatabase(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (bool, error) {
+	if !gitlabctl.ProvisionsGeoTrackingDatabase(adapter) {
+		return true, nil
+	}
+
+	if service := gitlabctl.PostgresService(adapter, template); service == nil || !r.isEndpointReady(ctx, service.GetName(), adapter) {
+		return false, r.setStatusCondition(ctx, adapter, status.ConditionGeoTrackingDatabaseReady, false,
+			"Waiting for the bundled PostgreSQL to become ready")
+	}
+
+	created, err := r.createGeoTrackingDatabase(ctx, adapter, template)
+	if err != nil {
+		r.Recorder.Event(adapter.Origin(), "Warning", "GeoTrackingDatabaseNotReady",
+			fmt.Sprintf("Failed to provision the Geo tracking database: %v", err))
+
+		return false, r.setStatusCondition(ctx, adapter, status.ConditionGeoTrackingDatabaseReady, false,
+			fmt.Sprintf("Failed to provision the Geo tracking database: %v", err))
+	}
+
+	if created {
+		r.Recorder.Event(adapter.Origin(), "Normal", "GeoTrackingDatabaseCreated",
+			"Created the Geo tracking database in the bundled PostgreSQL")
+	}
+
+	return true, r.setStatusCondition(ctx, adapter, status.ConditionGeoTrackingDatabaseReady, true,
+		"The Geo tracking database exists in the bundled PostgreSQL")
+}
+
+// createGeoTrackingDatabase connects to the bundled PostgreSQL as the
+// superuser and creates the tracking role and database when they do not
+// exist.
+func (r *GitLabReconciler) createGeoTrackingDatabase(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (bool, error) {
+	tracking := gitlabctl.GeoTrackingDatabaseConnection(adapter)
+
+	endpoint, err := r.postgresEndpoint(ctx, adapter, gitlabctl.BundledPostgresAdminConnection(adapter, template))
+	if err != nil {
+		return false, err
+	}
+
+	trackingPassword, err := r.secretValue(ctx, adapter, tracking.PasswordSecret, tracking.PasswordKey)
+	if err != nil {
+		return false, err
+	}
+
+	provisionCtx, cancel := context.WithTimeout(ctx, databaseProbeTimeout)
+	defer cancel()
+
+	return internal.ProvisionPostgreSQLDatabase(provisionCtx, endpoint, internal.PostgreSQLDatabase{
+		Database: tracking.Database,
+		Role:     tracking.Username,
+		Password: string(trackingPassword),
+	})
+}
+
+func (r *GitLabReconciler) reconcileGeoLogcursor(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
+	deployment := gitlabctl.GeoLogcursorDeployment(template)
+	if err := r.annotateSecretsChecksum(ctx, adapter, deployment); err != nil {
+		return err
+	}
+
+	return r.createOrPatch(ctx, deployment, adapter)
+}
+
+// reconcileGeoReplicationStatus reports the replication of the database of
+// the primary site in the GeoReplicationReady condition. On the primary site
+// it checks that replicas stream from the database. On a secondary site it
+// checks that the database is a replica and how far it is behind.
+//
+// The replication does not stop the reconcile loop. It is checked again
+// after geoCheckInterval.
+func (r *GitLabReconciler) reconcileGeoReplicationStatus(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
+	role := adapter.GeoRole()
+	if role == "" {
+		return nil
+	}
+
+	connection := gitlabctl.ExternalPostgresConnection(adapter)
+	if role == gitlab.GeoPrimary && adapter.WantsComponent(component.PostgreSQL) {
+		connection = gitlabctl.BundledPostgresAdminConnection(adapter, template)
+	}
+
+	err := r.probeGeoReplication(ctx, adapter, role, connection)
+	if err != nil {
+		if conditionChanges(adapter, status.ConditionGeoReplicationReady, false) {
+			r.Recorder.Event(adapter.Origin(), "Warning", "GeoReplicationNotReady",
+				fmt.Sprintf("Geo replication is not ready: %v", err))
+		}
+
+		return r.setStatusCondition(ctx, adapter, status.ConditionGeoReplicationReady, false, err.Error())
+	}
+
+	message := "Replicas stream from the database of the primary site"
+	if role == gitlab.GeoSecondary {
+		message = "The replica of the primary database is up to date"
+	}
+
+	return r.setStatusCondition(ctx, adapter, status.ConditionGeoReplicationReady, true, message)
+}
+
+func (r *GitLabReconciler) probeGeoReplication(ctx context.Context, adapter gitlab.Adapter, role string, connection gitlabctl.PostgresConnection) error {
+	endpoint, err := r.postgresEndpoint(ctx, adapter, connection)
+	if err != nil {
+		return err
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, databaseProbeTimeout)
+	defer cancel()
+
+	replication, err := internal.ProbePostgreSQLReplication(probeCtx, endpoint)
+	if err != nil {
+		return fmt.Errorf("can not connect to PostgreSQL at %s:%d: %w", endpoint.Host, endpoint.Port, err)
+	}
+
+	if role == gitlab.GeoSecondary {
+		return replication.ValidateSecondary(geoMaximumReplicationLag)
+	}
+
+	return replication.ValidatePrimary()
+}
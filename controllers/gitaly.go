@@ -3,7 +3,10 @@ package controllers
 import (
 	"context"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
 )
@@ -33,7 +36,27 @@ func (r *GitLabReconciler) reconcileGitalyConfigMap(ctx context.Context, adapter
 }
 
 func (r *GitLabReconciler) reconcileGitalyStatefulSet(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
-	if err := r.createOrPatch(ctx, gitlabctl.GitalyStatefulSet(template), adapter); err != nil {
+	gitaly := gitlabctl.GitalyStatefulSet(template)
+
+	if isGitalyRetired(adapter) {
+		if err := retireStatefulSet(gitaly); err != nil {
+			return err
+		}
+	}
+
+	if err := r.annotateSecretsChecksum(ctx, adapter, gitaly); err != nil {
+		return err
+	}
+
+	if err := r.expandStatefulSetVolumes(ctx, adapter, gitaly); err != nil {
+		return err
+	}
+
+	if err := r.stageGitalyRollouts(ctx, adapter, []client.Object{gitaly}); err != nil {
+		return err
+	}
+
+	if err := r.createOrPatch(ctx, gitaly, adapter); err != nil {
 		return err
 	}
 
@@ -47,3 +70,16 @@ func (r *GitLabReconciler) reconcileGitalyService(ctx context.Context, adapter g
 
 	return nil
 }
+
+// retireStatefulSet scales the StatefulSet down but keeps its volumes.
+func retireStatefulSet(obj client.Object) error {
+	statefulSet, err := internal.AsStatefulSet(obj)
+	if err != nil {
+		return err
+	}
+
+	replicas := int32(0)
+	statefulSet.Spec.Replicas = &replicas
+
+	return nil
+}
@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	feature "gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/features"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+const (
+	// gitalyMigrationCheckInterval is the delay between checking the progress
+	// of the migration from Gitaly to Praefect.
+	gitalyMigrationCheckInterval = time.Minute
+
+	// gitalyMigrationMaxAttempts is the number of times the repository moves
+	// are scheduled before the migration fails.
+	gitalyMigrationMaxAttempts = 3
+
+	// gitalyRetiredAnnotation records the source storage of a completed
+	// migration on the GitLab resource. Unlike the status, it survives a
+	// reset of the status and the removal of the migration from the spec.
+	gitalyRetiredAnnotation = "apps.gitlab.com/gitaly-retired"
+)
+
+// reconcileGitalyMigration moves the repositories of the standalone Gitaly to
+// a Praefect virtual storage.
+//
+// Once the Praefect-managed Gitaly of the destination storage is ready, a
+// Toolbox Job schedules the repository storage moves. Another Toolbox Job
+// periodically reports the number of repositories that remain in the source
+// storage. The moves are scheduled again when they are finished but the source
+// storage is not empty. The migration completes when the source storage is
+// empty, after which the standalone Gitaly is retired: it is scaled down and
+// the retirement is recorded in the gitalyRetiredAnnotation. The source
+// storage stays in the configuration of GitLab.
+//
+// The migration does not block the reconcile loop. Its progress is recorded
+// in the status.
+func (r *GitLabReconciler) reconcileGitalyMigration(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
+	migration := adapter.GitalyMigration()
+	progress := adapter.GitalyMigrationProgress()
+
+	if migration == nil {
+		if progress != nil {
+			adapter.RecordGitalyMigration(nil)
+		}
+
+		return nil
+	}
+
+	if progress == nil ||
+		progress.SourceStorage != migration.SourceStorage ||
+		progress.DestinationStorage != migration.DestinationStorage {
+		progress = &gitlab.GitalyMigrationProgress{
+			SourceStorage:      migration.SourceStorage,
+			DestinationStorage: migration.DestinationStorage,
+			Phase:              status.GitalyMigrationPending,
+		}
+
+		if adapter.Origin().GetAnnotations()[gitalyRetiredAnnotation] == migration.SourceStorage {
+			progress.Phase = status.GitalyMigrationCompleted
+			progress.Message = fmt.Sprintf("All repositories were moved to %s. The standalone Gitaly is retired",
+				migration.DestinationStorage)
+
+			adapter.RecordGitalyMigration(progress)
+
+			return nil
+		}
+	}
+
+	if progress.Phase == status.GitalyMigrationCompleted {
+		// The retirement of migrations that completed before it was recorded
+		// in the annotation.
+		return r.persistGitalyRetirement(ctx, adapter, migration)
+	}
+
+	if progress.Phase == status.GitalyMigrationFailed {
+		return nil
+	}
+
+	defer adapter.RecordGitalyMigration(progress)
+
+	if err := validateGitalyMigration(adapter, migration); err != nil {
+		if progress.Message != err.Error() {
+			r.Recorder.Event(adapter.Origin(), "Warning", "GitalyMigrationBlocked",
+				fmt.Sprintf("Gitaly migration can not start: %v", err))
+		}
+
+		progress.Phase = status.GitalyMigrationPending
+		progress.Message = err.Error()
+
+		return nil
+	}
+
+	if progress.Attempt == 0 {
+		if !r.isGitalyMigrationDestinationReady(ctx, adapter, template, migration) {
+			progress.Message = fmt.Sprintf("Waiting for Praefect and the Gitaly nodes of %s to become ready",
+				migration.DestinationStorage)
+
+			return nil
+		}
+
+		return r.scheduleGitalyMigration(ctx, adapter, template, migration, progress)
+	}
+
+	finished, err := r.runGitalyMigrationScheduleJob(ctx, adapter, template, migration, progress.Attempt)
+	if err != nil {
+		return err
+	}
+
+	if !finished {
+		progress.Message = "Scheduling the repository moves"
+		return nil
+	}
+
+	check, err := r.checkGitalyMigration(ctx, adapter, template, migration, progress)
+	if err != nil || check == nil {
+		return err
+	}
+
+	progress.Remaining = check.Remaining
+	progress.InProgress = check.InProgress
+	progress.Failed = check.Failed
+
+	switch internal.NextGitalyMigrationStep(*check, progress.Attempt, gitalyMigrationMaxAttempts) {
+	case internal.GitalyMigrationDone:
+		progress.Phase = status.GitalyMigrationCompleted
+		progress.Message = fmt.Sprintf("All repositories are moved to %s. The standalone Gitaly is retired",
+			migration.DestinationStorage)
+
+		r.Recorder.Event(adapter.Origin(), "Normal", "GitalyMigrationCompleted",
+			fmt.Sprintf("All repositories are moved from %s to %s", migration.SourceStorage, migration.DestinationStorage))
+
+		return r.persistGitalyRetirement(ctx, adapter, migration)
+	case internal.GitalyMigrationWait:
+		progress.Message = fmt.Sprintf("Moving %d repositories, %d remaining",
+			check.InProgress, check.Remaining)
+	case internal.GitalyMigrationRetry:
+		r.Recorder.Event(adapter.Origin(), "Warning", "GitalyMigrationRetrying",
+			fmt.Sprintf("%d repositories remain in %s and %d moves have failed, scheduling the moves again",
+				check.Remaining, migration.SourceStorage, check.Failed))
+
+		return r.scheduleGitalyMigration(ctx, adapter, template, migration, progress)
+	case internal.GitalyMigrationGiveUp:
+		progress.Phase = status.GitalyMigrationFailed
+		progress.Message = fmt.Sprintf("%d repositories remain in %s after %d attempts, %d moves have failed",
+			check.Remaining, migration.SourceStorage, progress.Attempt, check.Failed)
+
+		r.Recorder.Event(adapter.Origin(), "Warning", "GitalyMigrationFailed", progress.Message)
+	}
+
+	return nil
+}
+
+// scheduleGitalyMigration starts the next attempt of scheduling the
+// repository moves.
+func (r *GitLabReconciler) scheduleGitalyMigration(ctx context.Context, adapter gitlab.Adapter, template helm.Template, migration *gitlab.GitalyMigration, progress *gitlab.GitalyMigrationProgress) error {
+	progress.Attempt++
+	progress.Phase = status.GitalyMigrationMoving
+	progress.Message = "Scheduling the repository moves"
+
+	if _, err := r.runGitalyMigrationScheduleJob(ctx, adapter, template, migration, progress.Attempt); err != nil {
+		return err
+	}
+
+	if progress.Attempt == 1 {
+		r.Recorder.Event(adapter.Origin(), "Normal", "GitalyMigrationStarted",
+			fmt.Sprintf("Moving repositories from %s to %s", migration.SourceStorage, migration.DestinationStorage))
+	}
+
+	return nil
+}
+
+// runGitalyMigrationScheduleJob ensures that the Job of the attempt exists
+// and returns true when it is finished. A failed Job is reported as finished,
+// so that the progress check decides whether the moves are scheduled again.
+func (r *GitLabReconciler) runGitalyMigrationScheduleJob(ctx context.Context, adapter gitlab.Adapter, template helm.Template, migration *gitlab.GitalyMigration, attempt int32) (bool, error) {
+	job, err := gitlabctl.GitalyMigrationScheduleJob(adapter, template, migration, attempt)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.createOrPatch(ctx, job, adapter); err != nil {
+		return false, err
+	}
+
+	lookup, err := r.lookupJob(ctx, job)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return isJobFinished(lookup), nil
+}
+
+// checkGitalyMigration runs the Job that reports the progress of the
+// migration, at most once per check interval. It returns nil when the
+// progress is not available yet.
+func (r *GitLabReconciler) checkGitalyMigration(ctx context.Context, adapter gitlab.Adapter, template helm.Template, migration *gitlab.GitalyMigration, progress *gitlab.GitalyMigrationProgress) (*internal.GitalyMigrationCheck, error) {
+	job, err := gitlabctl.GitalyMigrationCheckJob(adapter, template, migration)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup, err := r.lookupJob(ctx, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if errors.IsNotFound(err) && time.Since(progress.LastCheckTime) < gitalyMigrationCheckInterval {
+		return nil, nil
+	}
+
+	if err := r.createOrPatch(ctx, job, adapter); err != nil {
+		return nil, err
+	}
+
+	if lookup == nil || !isJobFinished(lookup) {
+		return nil, nil
+	}
+
+	message := ""
+	if lookup.Status.Succeeded > 0 {
+		if message, err = jobTerminationMessage(ctx, r, lookup); err != nil {
+			return nil, err
+		}
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	if err := r.Delete(ctx, lookup, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	progress.LastCheckTime = time.Now()
+
+	check, err := internal.ParseTerminationMessage[internal.GitalyMigrationCheck](message)
+	if err != nil {
+		progress.Message = fmt.Sprintf("Failed to check the progress: %v", err)
+
+		r.Recorder.Event(adapter.Origin(), "Warning", "GitalyMigrationCheckFailed", progress.Message)
+
+		return nil, nil
+	}
+
+	return &check, nil
+}
+
+// jobTerminationMessage returns the termination message of the succeeded Pod
+// of the Job.
+func jobTerminationMessage(ctx context.Context, reader client.Reader, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := reader.List(ctx, pods, client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+
+		for _, container := range pod.Status.ContainerStatuses {
+			if container.State.Terminated != nil {
+				return container.State.Terminated.Message, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// isGitalyMigrationDestinationReady checks that Praefect and the Gitaly nodes
+// of the destination storage are ready.
+func (r *GitLabReconciler) isGitalyMigrationDestinationReady(ctx context.Context, adapter gitlab.Adapter, template helm.Template, migration *gitlab.GitalyMigration) bool {
+	if !r.isEndpointReady(ctx, gitlabctl.PraefectService(template).GetName(), adapter) {
+		return false
+	}
+
+	for _, service := range gitlabctl.GitalyPraefectServices(template) {
+		if service.GetLabels()["storage"] != migration.DestinationStorage {
+			continue
+		}
+
+		if !r.isEndpointReady(ctx, service.GetName(), adapter) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateGitalyMigration checks that both the standalone Gitaly and the
+// destination Praefect virtual storage are deployed.
+func validateGitalyMigration(adapter gitlab.Adapter, migration *gitlab.GitalyMigration) error {
+	if !adapter.WantsComponent(component.Gitaly) || !adapter.WantsComponent(component.Praefect) {
+		return fmt.Errorf("both Gitaly and Praefect must be enabled")
+	}
+
+	if adapter.WantsFeature(feature.ReplaceGitalyWithPraefect) {
+		return fmt.Errorf("global.praefect.replaceInternalGitaly must be disabled until the migration is completed")
+	}
+
+	if !adapter.WantsComponent(component.Toolbox) {
+		return fmt.Errorf("the Toolbox must be enabled")
+	}
+
+	if migration.SourceStorage == migration.DestinationStorage {
+		return fmt.Errorf("the source and destination storages must be different")
+	}
+
+	for _, storage := range gitlabctl.PraefectVirtualStorages(adapter) {
+		if storage == migration.DestinationStorage {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is not a Praefect virtual storage", migration.DestinationStorage)
+}
+
+// persistGitalyRetirement records the retirement of the standalone Gitaly in
+// the gitalyRetiredAnnotation of the GitLab resource.
+func (r *GitLabReconciler) persistGitalyRetirement(ctx context.Context, adapter gitlab.Adapter, migration *gitlab.GitalyMigration) error {
+	origin := adapter.Origin()
+	if origin.GetAnnotations()[gitalyRetiredAnnotation] == migration.SourceStorage {
+		return nil
+	}
+
+	live := &apiv1beta1.GitLab{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(origin), live); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(live.DeepCopy())
+
+	if live.Annotations == nil {
+		live.Annotations = map[string]string{}
+	}
+
+	live.Annotations[gitalyRetiredAnnotation] = migration.SourceStorage
+
+	if err := r.Patch(ctx, live, patch); err != nil {
+		return err
+	}
+
+	// The origin holds the status changes of this reconcile. Only take over
+	// the annotations and the resource version of the patched object.
+	origin.SetAnnotations(live.Annotations)
+	origin.SetResourceVersion(live.ResourceVersion)
+
+	return nil
+}
+
+// isGitalyRetired returns true when all repositories are moved from the
+// standalone Gitaly to Praefect. A retirement that is recorded in the
+// gitalyRetiredAnnotation is kept when the migration is removed from the spec.
+func isGitalyRetired(adapter gitlab.Adapter) bool {
+	if _, ok := adapter.Origin().GetAnnotations()[gitalyRetiredAnnotation]; ok {
+		return true
+	}
+
+	migration := adapter.GitalyMigration()
+	progress := adapter.GitalyMigrationProgress()
+
+	return migration != nil && progress != nil &&
+		progress.Phase == status.GitalyMigrationCompleted &&
+		progress.SourceStorage == migration.SourceStorage &&
+		progress.DestinationStorage == migration.DestinationStorage
+}
+
+// isGitalyMigrationInProgress returns true when the migration from Gitaly to
+// Praefect is pending or moving repositories.
+func isGitalyMigrationInProgress(adapter gitlab.Adapter) bool {
+	progress := adapter.GitalyMigrationProgress()
+
+	return adapter.GitalyMigration() != nil && progress != nil &&
+		(progress.Phase == status.GitalyMigrationPending || progress.Phase == status.GitalyMigrationMoving)
+}
+
+func isJobFinished(job *batchv1.Job) bool {
+	outcome := internal.JobOutcomeOf(job)
+
+	return outcome == internal.JobSucceeded || outcome == internal.JobFailed
+}
@@ -47,6 +47,21 @@ func (r *GitLabReconciler) reconcileGitalyPraefectServices(ctx context.Context,
 func (r *GitLabReconciler) reconcileGitalyPraefectStatefulSets(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
 	gitalyPraefectStatefulSets := gitlabctl.GitalyPraefectStatefulSets(template)
 
+	for _, gitalyPraefectStatefulSet := range gitalyPraefectStatefulSets {
+		if err := r.annotateSecretsChecksum(ctx, adapter, gitalyPraefectStatefulSet); err != nil {
+			return err
+		}
+
+		if err := r.expandStatefulSetVolumes(ctx, adapter, gitalyPraefectStatefulSet); err != nil {
+			return err
+		}
+	}
+
+	// Virtual storages are rolled out one after another.
+	if err := r.stageGitalyRollouts(ctx, adapter, gitalyPraefectStatefulSets); err != nil {
+		return err
+	}
+
 	for _, gitalyPraefectStatefulSet := range gitalyPraefectStatefulSets {
 		if err := r.createOrPatch(ctx, gitalyPraefectStatefulSet, adapter); err != nil {
 			return err
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+// gitalyRolloutRetryDelay is the delay between checking the progress of the
+// staged Gitaly rollouts.
+const gitalyRolloutRetryDelay = 15 * time.Second
+
+// stageGitalyRollouts sets the partitions of the desired Gitaly StatefulSets
+// when the staged rollout is enabled, so that changes are rolled out one Pod
+// at a time and one StatefulSet after another, in the order of their names.
+//
+// The next Pod is only updated once the updated Pods are ready for the soak
+// time. The progress is recorded in the status.
+func (r *GitLabReconciler) stageGitalyRollouts(ctx context.Context, adapter gitlab.Adapter, statefulSets []client.Object) error {
+	if !adapter.StagesGitalyRollout() {
+		return nil
+	}
+
+	desiredStatefulSets := make([]*appsv1.StatefulSet, 0, len(statefulSets))
+
+	for _, obj := range statefulSets {
+		if obj == nil {
+			continue
+		}
+
+		desired, err := internal.AsStatefulSet(obj)
+		if err != nil {
+			return err
+		}
+
+		desiredStatefulSets = append(desiredStatefulSets, desired)
+	}
+
+	sort.Slice(desiredStatefulSets, func(i, j int) bool {
+		return desiredStatefulSets[i].Name < desiredStatefulSets[j].Name
+	})
+
+	blocked := false
+
+	for _, desired := range desiredStatefulSets {
+		replicas := int32(1)
+		if desired.Spec.Replicas != nil {
+			replicas = *desired.Spec.Replicas
+		}
+
+		current := &appsv1.StatefulSet{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(desired), current); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+
+			held, err := r.holdAdoptedPods(ctx, adapter, desired, replicas)
+			if err != nil {
+				return err
+			}
+
+			blocked = blocked || held
+
+			continue
+		}
+
+		pods, err := r.statefulSetPods(ctx, current, replicas)
+		if err != nil {
+			return err
+		}
+
+		step := internal.NextRolloutStep(current, replicas, pods,
+			adapter.GitalyRolloutSoakTime(), time.Now(), blocked)
+
+		desired.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+			Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+				Partition: &step.Partition,
+			},
+		}
+
+		if step.Phase == status.GitalyRolloutRollingOut && step.Partition < replicas &&
+			(current.Spec.UpdateStrategy.RollingUpdate == nil ||
+				current.Spec.UpdateStrategy.RollingUpdate.Partition == nil ||
+				*current.Spec.UpdateStrategy.RollingUpdate.Partition != step.Partition) {
+			r.Log.Info("updating the next Gitaly Pod",
+				"gitlab", adapter.Name(), "statefulset", desired.Name, "partition", step.Partition)
+
+			r.Recorder.Event(adapter.Origin(), "Normal", "GitalyRolloutProgressing",
+				fmt.Sprintf("Updating Pod %s-%d of StatefulSet %s", desired.Name, step.Partition, desired.Name))
+		}
+
+		adapter.RecordGitalyRollout(gitlab.GitalyRollout{
+			StatefulSet:     desired.Name,
+			Partition:       step.Partition,
+			UpdatedReplicas: step.UpdatedReplicas,
+			Replicas:        replicas,
+			Phase:           step.Phase,
+			Message:         step.Message,
+		})
+
+		if step.Phase != status.GitalyRolloutCompleted {
+			blocked = true
+		}
+	}
+
+	return nil
+}
+
+// holdAdoptedPods sets the partition of a StatefulSet that is created again,
+// for example to update its volume claim templates, while its orphaned Pods
+// are still running. The recreated StatefulSet adopts them and would update
+// them all at once. Instead they are held and then rolled out one at a time.
+func (r *GitLabReconciler) holdAdoptedPods(ctx context.Context, adapter gitlab.Adapter, desired *appsv1.StatefulSet, replicas int32) (bool, error) {
+	pods, err := r.statefulSetPods(ctx, desired, replicas)
+	if err != nil {
+		return false, err
+	}
+
+	if len(pods) == 0 {
+		return false, nil
+	}
+
+	desired.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+		Type: appsv1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+			Partition: &replicas,
+		},
+	}
+
+	adapter.RecordGitalyRollout(gitlab.GitalyRollout{
+		StatefulSet:     desired.Name,
+		Partition:       replicas,
+		UpdatedReplicas: 0,
+		Replicas:        replicas,
+		Phase:           status.GitalyRolloutPending,
+		Message:         "Waiting for the StatefulSet to adopt its Pods",
+	})
+
+	return true, nil
+}
+
+// statefulSetPods returns the existing Pods of the StatefulSet by their
+// ordinals.
+func (r *GitLabReconciler) statefulSetPods(ctx context.Context, statefulSet *appsv1.StatefulSet, replicas int32) (map[int32]*corev1.Pod, error) {
+	pods := map[int32]*corev1.Pod{}
+
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		pod := &corev1.Pod{}
+		lookupKey := types.NamespacedName{
+			Name:      fmt.Sprintf("%s-%d", statefulSet.Name, ordinal),
+			Namespace: statefulSet.Namespace,
+		}
+
+		if err := r.Get(ctx, lookupKey, pod); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		pods[ordinal] = pod
+	}
+
+	return pods, nil
+}
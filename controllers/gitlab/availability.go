@@ -0,0 +1,245 @@
+package gitlab
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+)
+
+const (
+	// PodDisruptionBudgetKind is the kind of PodDisruptionBudgets.
+	PodDisruptionBudgetKind = "PodDisruptionBudget"
+
+	hostnameTopologyKey = "kubernetes.io/hostname"
+	zoneTopologyKey     = "topology.kubernetes.io/zone"
+)
+
+// AvailabilityComponents are the components whose PodDisruptionBudgets and
+// topology spread the Operator manages. They are the components of
+// component.All that run Deployments or StatefulSets, and Praefect.
+var AvailabilityComponents = gitlab.Components{
+	component.Gitaly,
+	component.Praefect,
+	component.PostgreSQL,
+	component.Redis,
+	component.MinIO,
+	component.Webservice,
+	component.Sidekiq,
+	component.GitLabShell,
+	component.Registry,
+	component.GitLabKAS,
+	component.GitLabPages,
+	component.Mailroom,
+	component.GitLabExporter,
+	component.NginxIngress,
+}
+
+// availabilityWorkload is a Deployment or a StatefulSet of a component.
+type availabilityWorkload struct {
+	component gitlab.Component
+	object    client.Object
+	selector  *metav1.LabelSelector
+	template  *corev1.PodTemplateSpec
+	replicas  int32
+}
+
+// PodDisruptionBudgets returns the PodDisruptionBudgets of the workloads of
+// the enabled components. By default, a workload with more than one replica
+// can lose one Pod at a time. The replicas of a workload are the minimum
+// replicas of its HorizontalPodAutoscaler, if it has one. The overrides of a
+// component apply to all its workloads, regardless of their replicas.
+//
+// The PodDisruptionBudgets are named after their workloads.
+func PodDisruptionBudgets(adapter gitlab.Adapter, template helm.Template) []*policyv1.PodDisruptionBudget {
+	result := []*policyv1.PodDisruptionBudget{}
+
+	for _, workload := range availabilityWorkloads(adapter, template) {
+		overrides := adapter.ComponentAvailability(workload.component)
+
+		spec := policyv1.PodDisruptionBudgetSpec{
+			Selector:       workload.selector,
+			MinAvailable:   overrides.MinAvailable,
+			MaxUnavailable: overrides.MaxUnavailable,
+		}
+
+		if spec.MinAvailable == nil && spec.MaxUnavailable == nil {
+			if workload.replicas < 2 {
+				continue
+			}
+
+			maxUnavailable := intstr.FromInt(1)
+			spec.MaxUnavailable = &maxUnavailable
+		}
+
+		result = append(result, &policyv1.PodDisruptionBudget{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: policyv1.SchemeGroupVersion.String(),
+				Kind:       PodDisruptionBudgetKind,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      workload.object.GetName(),
+				Namespace: adapter.Name().Namespace,
+				Labels:    workload.object.GetLabels(),
+			},
+			Spec: spec,
+		})
+	}
+
+	return result
+}
+
+// SpreadWorkloads sets the topology spread constraints of the workloads of
+// the enabled components in the template. By default, the Pods of a
+// workload with more than one replica are spread across nodes and zones
+// when possible. Workloads that already have constraints in the chart keep
+// them. The constraints of a component override replace them for all its
+// workloads.
+func SpreadWorkloads(adapter gitlab.Adapter, template helm.Template) {
+	for _, workload := range availabilityWorkloads(adapter, template) {
+		overrides := adapter.ComponentAvailability(workload.component)
+
+		if len(overrides.TopologySpreadConstraints) > 0 {
+			constraints := make([]corev1.TopologySpreadConstraint, 0, len(overrides.TopologySpreadConstraints))
+
+			for _, constraint := range overrides.TopologySpreadConstraints {
+				constraint := *constraint.DeepCopy()
+				if constraint.LabelSelector == nil {
+					constraint.LabelSelector = workload.selector.DeepCopy()
+				}
+
+				constraints = append(constraints, constraint)
+			}
+
+			workload.template.Spec.TopologySpreadConstraints = constraints
+
+			continue
+		}
+
+		if workload.replicas < 2 || len(workload.template.Spec.TopologySpreadConstraints) > 0 {
+			continue
+		}
+
+		workload.template.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{
+			defaultSpreadConstraint(hostnameTopologyKey, workload.selector),
+			defaultSpreadConstraint(zoneTopologyKey, workload.selector),
+		}
+	}
+}
+
+func defaultSpreadConstraint(topologyKey string, selector *metav1.LabelSelector) corev1.TopologySpreadConstraint {
+	return corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       topologyKey,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector:     selector.DeepCopy(),
+	}
+}
+
+// availabilityWorkloads returns the workloads of the enabled components that
+// are not excluded by their overrides.
+func availabilityWorkloads(adapter gitlab.Adapter, template helm.Template) []availabilityWorkload {
+	minReplicas := autoscalerMinReplicas(template)
+	result := []availabilityWorkload{}
+
+	for _, c := range AvailabilityComponents {
+		if !adapter.WantsComponent(c) || adapter.ComponentAvailability(c).Disabled {
+			continue
+		}
+
+		for _, object := range componentWorkloads(adapter, template, c) {
+			workload := availabilityWorkload{component: c, object: object, replicas: 1}
+
+			var (
+				kind     string
+				replicas *int32
+			)
+
+			switch o := object.(type) {
+			case *appsv1.Deployment:
+				kind, replicas = DeploymentKind, o.Spec.Replicas
+				workload.selector, workload.template = o.Spec.Selector, &o.Spec.Template
+			case *appsv1.StatefulSet:
+				kind, replicas = StatefulSetKind, o.Spec.Replicas
+				workload.selector, workload.template = o.Spec.Selector, &o.Spec.Template
+			default:
+				continue
+			}
+
+			if workload.selector == nil {
+				continue
+			}
+
+			if replicas != nil {
+				workload.replicas = *replicas
+			}
+
+			if autoscaled, ok := minReplicas[kind+"/"+object.GetName()]; ok {
+				workload.replicas = autoscaled
+			}
+
+			result = append(result, workload)
+		}
+	}
+
+	return result
+}
+
+// componentWorkloads returns the Deployments and the StatefulSets of the
+// component. The Pods of the components of the GitLab Chart are labeled
+// with the name of their component.
+func componentWorkloads(adapter gitlab.Adapter, template helm.Template, c gitlab.Component) []client.Object {
+	switch c {
+	case component.PostgreSQL:
+		return []client.Object{PostgresStatefulSet(adapter, template)}
+	case component.Redis:
+		if statefulSet := RedisStatefulSet(adapter, template); statefulSet != nil {
+			return []client.Object{statefulSet}
+		}
+
+		return nil
+	}
+
+	labels := map[string]string{appLabel: c.Name()}
+	result := []client.Object{}
+
+	for _, kind := range []string{DeploymentKind, StatefulSetKind} {
+		result = append(result, template.Query().ObjectsByKindAndLabels(kind, labels)...)
+	}
+
+	return result
+}
+
+// autoscalerMinReplicas returns the minimum replicas of the
+// HorizontalPodAutoscalers by the kind and the name of their targets. The
+// HorizontalPodAutoscalers of the template can have different API versions.
+func autoscalerMinReplicas(template helm.Template) map[string]int32 {
+	result := map[string]int32{}
+
+	for _, hpa := range template.Query().ObjectsByKind(HorizontalPodAutoscalerKind) {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(hpa)
+		if err != nil {
+			continue
+		}
+
+		kind, _, _ := unstructured.NestedString(content, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(content, "spec", "scaleTargetRef", "name")
+
+		replicas, found, _ := unstructured.NestedInt64(content, "spec", "minReplicas")
+		if !found {
+			replicas = 1
+		}
+
+		result[kind+"/"+name] = int32(replicas)
+	}
+
+	return result
+}
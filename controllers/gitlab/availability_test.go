@@ -0,0 +1,116 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("PodDisruptionBudgets and topology spread", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	findBudget := func(budgets []*policyv1.PodDisruptionBudget, name string) *policyv1.PodDisruptionBudget {
+		for _, budget := range budgets {
+			if budget.Name == name {
+				return budget
+			}
+		}
+
+		return nil
+	}
+
+	When("the Operator manages the availability", func() {
+		minAvailable := intstr.FromInt(1)
+
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue("global.praefect.enabled", true)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.Availability = &gitlabv1beta1.AvailabilitySpec{
+			Enabled: true,
+			Components: []gitlabv1beta1.ComponentAvailabilitySpec{
+				{Name: "postgresql", MinAvailable: &minAvailable},
+				{Name: "webservice", Disabled: true},
+			},
+		}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+			Expect(adapter.ManagesAvailability()).To(BeTrue())
+		})
+
+		It("Should protect Praefect against concurrent disruptions", func() {
+			statefulSet, ok := PraefectStatefulSet(template).(*appsv1.StatefulSet)
+			Expect(ok).To(BeTrue())
+			Expect(*statefulSet.Spec.Replicas).To(BeNumerically(">", 1))
+
+			budget := findBudget(PodDisruptionBudgets(adapter, template), statefulSet.Name)
+			Expect(budget).NotTo(BeNil())
+			Expect(budget.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 1}))
+			Expect(budget.Spec.Selector).To(Equal(statefulSet.Spec.Selector))
+		})
+
+		It("Should spread the Pods of Praefect across nodes and zones", func() {
+			statefulSet, ok := PraefectStatefulSet(template).(*appsv1.StatefulSet)
+			Expect(ok).To(BeTrue())
+
+			constraints := statefulSet.Spec.Template.Spec.TopologySpreadConstraints
+			Expect(constraints).To(ConsistOf(
+				HaveField("TopologyKey", hostnameTopologyKey),
+				HaveField("TopologyKey", zoneTopologyKey),
+			))
+			Expect(constraints[0].WhenUnsatisfiable).To(Equal(corev1.ScheduleAnyway))
+			Expect(constraints[0].LabelSelector).To(Equal(statefulSet.Spec.Selector))
+		})
+
+		It("Should apply the overrides of a component regardless of its replicas", func() {
+			statefulSet := PostgresStatefulSet(adapter, template)
+
+			budget := findBudget(PodDisruptionBudgets(adapter, template), statefulSet.GetName())
+			Expect(budget).NotTo(BeNil())
+			Expect(budget.Spec.MinAvailable).To(Equal(&minAvailable))
+			Expect(budget.Spec.MaxUnavailable).To(BeNil())
+		})
+
+		It("Should keep the behaviour of the chart for disabled components", func() {
+			deployment, ok := WebserviceDeployments(template)[0].(*appsv1.Deployment)
+			Expect(ok).To(BeTrue())
+
+			Expect(findBudget(PodDisruptionBudgets(adapter, template), deployment.Name)).To(BeNil())
+			Expect(deployment.Spec.Template.Spec.TopologySpreadConstraints).To(BeEmpty())
+		})
+	})
+
+	When("the Operator does not manage the availability", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue("global.praefect.enabled", true)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should keep the behaviour of the chart", func() {
+			Expect(err).To(BeNil())
+			Expect(adapter.ManagesAvailability()).To(BeFalse())
+
+			statefulSet, ok := PraefectStatefulSet(template).(*appsv1.StatefulSet)
+			Expect(ok).To(BeTrue())
+			Expect(statefulSet.Spec.Template.Spec.TopologySpreadConstraints).To(BeEmpty())
+		})
+	})
+})
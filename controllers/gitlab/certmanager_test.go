@@ -0,0 +1,131 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	acmev1 "github.com/jetstack/cert-manager/pkg/apis/acme/v1"
+	certmetav1 "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("cert-manager issuers", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	annotations := func(object interface{}) map[string]string {
+		ingress, ok := object.(*networkingv1.Ingress)
+		Expect(ok).To(BeTrue())
+
+		return ingress.Annotations
+	}
+
+	When("an existing issuer is referenced", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue(globalPagesEnabled, true)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.CertManager = &gitlabv1beta1.CertManagerSpec{
+			Issuer: &gitlabv1beta1.IssuerReferenceSpec{Name: "letsencrypt", Kind: "ClusterIssuer"},
+			Hosts: &gitlabv1beta1.CertManagerHostsSpec{
+				Pages: &gitlabv1beta1.IssuerReferenceSpec{Name: "pages-dns01"},
+			},
+		}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should use the issuer for all the hosts", func() {
+			for _, ingress := range []interface{}{WebserviceIngresses(template)[0], RegistryIngress(template), KasIngress(template)} {
+				Expect(annotations(ingress)).To(And(
+					HaveKeyWithValue("cert-manager.io/issuer", "letsencrypt"),
+					HaveKeyWithValue("cert-manager.io/issuer-kind", "ClusterIssuer"),
+					HaveKeyWithValue("cert-manager.io/issuer-group", "cert-manager.io"),
+				))
+			}
+		})
+
+		It("Should use the issuer of the host when it is overridden", func() {
+			Expect(annotations(PagesIngress(template))).To(And(
+				HaveKeyWithValue("cert-manager.io/issuer", "pages-dns01"),
+				HaveKeyWithValue("cert-manager.io/issuer-kind", "Issuer"),
+			))
+		})
+
+		It("Should not require the Issuer of the Operator", func() {
+			Expect(internal.RequiresCertificateIssuer(adapter)).To(BeFalse())
+		})
+	})
+
+	When("only some hosts reference existing issuers", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.CertManager = &gitlabv1beta1.CertManagerSpec{
+			Hosts: &gitlabv1beta1.CertManagerHostsSpec{
+				Registry: &gitlabv1beta1.IssuerReferenceSpec{Name: "registry", Kind: "Issuer"},
+			},
+		}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should use the Issuer of the Operator for the other hosts", func() {
+			Expect(err).To(BeNil())
+			Expect(internal.RequiresCertificateIssuer(adapter)).To(BeTrue())
+
+			Expect(annotations(WebserviceIngresses(template)[0])).To(
+				HaveKeyWithValue("cert-manager.io/issuer", releaseName+"-issuer"))
+			Expect(annotations(RegistryIngress(template))).To(
+				HaveKeyWithValue("cert-manager.io/issuer", "registry"))
+		})
+	})
+
+	When("the Issuer of the Operator uses DNS-01", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+
+		dns01 := &acmev1.ACMEChallengeSolverDNS01{
+			Cloudflare: &acmev1.ACMEIssuerDNS01ProviderCloudflare{
+				APIToken: &certmetav1.SecretKeySelector{
+					LocalObjectReference: certmetav1.LocalObjectReference{Name: "cloudflare"},
+					Key:                  "token",
+				},
+			},
+		}
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.CertManager = &gitlabv1beta1.CertManagerSpec{DNS01: dns01}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should solve the challenges with DNS-01", func() {
+			Expect(err).To(BeNil())
+
+			issuer := internal.CertificateIssuer(adapter)
+			Expect(issuer.Spec.ACME).NotTo(BeNil())
+			Expect(issuer.Spec.ACME.Solvers).To(ConsistOf(And(
+				HaveField("DNS01", Equal(dns01)),
+				HaveField("HTTP01", BeNil()),
+			)))
+		})
+
+		It("Should not edit the Ingresses in place", func() {
+			Expect(annotations(WebserviceIngresses(template)[0])).NotTo(
+				HaveKey("acme.cert-manager.io/http01-edit-in-place"))
+		})
+	})
+})
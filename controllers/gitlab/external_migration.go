@@ -0,0 +1,287 @@
+package gitlab
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	externalMigrationBackoffLimit = 2
+
+	// redisMigrationUser is the Redis user that the migration Job creates to
+	// copy the keys while the default user can not write.
+	redisMigrationUser = "gitlab-operator-migration"
+)
+
+// postgresMigrationScript stops the GitLab database user of the bundled
+// PostgreSQL from logging in, so that GitLab does not write to the database,
+// and copies the database to the external server. It compares the number of
+// rows of all tables and the schemas, including the values of the sequences,
+// and reports the total counts.
+//
+// The login is enabled again when the migration fails. Otherwise the bundled
+// PostgreSQL is retired. The copy fails on any error, except for the
+// extensions and the public schema that the external user does not own, for
+// example on a managed PostgreSQL service.
+const postgresMigrationScript = postgresFunctions + `
+trap '[ "${completed:-}" = true ] || set_role_login "${SOURCE_ROLE}" LOGIN' EXIT
+
+destination() {
+  PGHOST="${DESTINATION_HOST}" PGPORT="${DESTINATION_PORT}" PGUSER="${DESTINATION_USER}" PGPASSWORD="${DESTINATION_PASSWORD}" "$@"
+}
+
+total_rows() {
+  awk -F'|' '{ total += $2 } END { print total + 0 }'
+}
+
+set_role_login "${SOURCE_ROLE}" NOLOGIN
+
+restore_log="$(pg_dump --clean --if-exists --no-owner --no-privileges -d "${SOURCE_DATABASE}" \
+  | destination psql -X -q -d "${DESTINATION_DATABASE}" 2>&1)"
+
+if echo "${restore_log}" | grep 'ERROR:' \
+  | grep -v -e 'ERROR:  must be owner of extension' -e 'ERROR:  must be owner of schema public' >&2; then
+  echo "The copy of the database failed" >&2
+  exit 1
+fi
+
+source_rows="$(count_table_rows -d "${SOURCE_DATABASE}" | sort)"
+destination_rows="$(destination count_table_rows -d "${DESTINATION_DATABASE}" | sort)"
+
+if ! diff <(echo "${source_rows}") <(echo "${destination_rows}") >&2; then
+  echo "The number of rows differs after the copy" >&2
+  exit 1
+fi
+
+if ! diff <(describe_schema -d "${SOURCE_DATABASE}") <(destination describe_schema -d "${DESTINATION_DATABASE}") >&2; then
+  echo "The schema differs after the copy" >&2
+  exit 1
+fi
+
+printf '{"source":%d,"destination":%d}' \
+  "$(echo "${source_rows}" | total_rows)" "$(echo "${destination_rows}" | total_rows)" > /dev/termination-log
+
+completed=true
+`
+
+// redisMigrationScript stops the default user of the bundled Redis from
+// writing, so that GitLab does not write to Redis, and copies all keys to the
+// external server with a dedicated user. It compares the number of keys that
+// exist in both servers and reports them.
+//
+// The default user can write again when the migration fails. Otherwise the
+// bundled Redis is retired.
+const redisMigrationScript = `
+set -euo pipefail
+cd /tmp
+
+admin_cli() {
+  REDISCLI_AUTH="${SOURCE_PASSWORD}" redis-cli --no-auth-warning -h "${SOURCE_HOST}" -p "${SOURCE_PORT}" "$@"
+}
+
+source_cli() {
+  REDISCLI_AUTH="${MIGRATION_PASSWORD}" redis-cli --no-auth-warning --user "${MIGRATION_USER}" -h "${SOURCE_HOST}" -p "${SOURCE_PORT}" "$@"
+}
+
+destination_cli() {
+  if [ -n "${DESTINATION_PASSWORD:-}" ]; then
+    REDISCLI_AUTH="${DESTINATION_PASSWORD}" redis-cli --no-auth-warning -h "${DESTINATION_HOST}" -p "${DESTINATION_PORT}" "$@"
+  else
+    redis-cli -h "${DESTINATION_HOST}" -p "${DESTINATION_PORT}" "$@"
+  fi
+}
+
+migrate_keys() {
+  local auth=()
+
+  if [ -n "${DESTINATION_PASSWORD:-}" ]; then
+    auth=(AUTH "${DESTINATION_PASSWORD}")
+  fi
+
+  source_cli MIGRATE "${DESTINATION_HOST}" "${DESTINATION_PORT}" "" 0 60000 COPY REPLACE ${auth[@]+"${auth[@]}"} KEYS "$@"
+}
+
+count_keys() {
+  xargs -r -d '\n' -n 100 bash -c '"$0" EXISTS "$@"' "$1" < keys.txt | awk '{ total += $1 } END { print total + 0 }'
+}
+
+expect_ok() {
+  local reply
+
+  reply="$("$@")"
+  if [ "${reply}" != "OK" ]; then
+    echo "${reply}" >&2
+    return 1
+  fi
+}
+
+export -f source_cli destination_cli migrate_keys
+
+trap '[ "${completed:-}" = true ] || admin_cli ACL SETUSER default +@write' EXIT
+
+expect_ok admin_cli ACL SETUSER "${MIGRATION_USER}" on ">${MIGRATION_PASSWORD}" '~*' +@all
+expect_ok admin_cli ACL SETUSER default -@write
+
+source_cli --scan > keys.txt
+xargs -r -d '\n' -n 100 bash -c 'migrate_keys "$@"' _ < keys.txt > migrate.log
+
+if grep -v -x -e OK -e NOKEY migrate.log >&2; then
+  echo "Failed to copy the keys" >&2
+  exit 1
+fi
+
+source_count="$(count_keys source_cli)"
+destination_count="$(count_keys destination_cli)"
+
+if [ "${destination_count}" -lt "${source_count}" ]; then
+  echo "Only ${destination_count} of ${source_count} keys exist after the copy" >&2
+  exit 1
+fi
+
+printf '{"source":%d,"destination":%d}' "${source_count}" "${destination_count}" > /dev/termination-log
+
+completed=true
+`
+
+// RedisMigrationSecret returns the name and the key of the Secret that holds
+// the password of the Redis user that copies the keys of the bundled Redis.
+func RedisMigrationSecret(adapter gitlab.Adapter) (string, string) {
+	return fmt.Sprintf("%s-redis-migration-secret", adapter.ReleaseName()), "password"
+}
+
+// PostgresMigrationJob returns the Job that copies the GitLab database of
+// the bundled PostgreSQL to the external server. It uses the image of the
+// bundled PostgreSQL.
+func PostgresMigrationJob(adapter gitlab.Adapter, template helm.Template, statefulSet *appsv1.StatefulSet, migration *gitlab.PostgreSQLMigration) (*batchv1.Job, error) {
+	admin := BundledPostgresAdminConnection(adapter, template)
+	if admin.Host == "" {
+		return nil, fmt.Errorf("the PostgreSQL Service is required to migrate PostgreSQL")
+	}
+
+	values := adapter.Values()
+
+	env := []corev1.EnvVar{
+		{Name: "PGHOST", Value: admin.Host},
+		{Name: "PGPORT", Value: fmt.Sprintf("%d", admin.Port)},
+		{Name: "PGUSER", Value: admin.Username},
+		secretEnvVar("PGPASSWORD", admin.PasswordSecret, admin.PasswordKey),
+		{Name: "SOURCE_DATABASE", Value: values.GetString("global.psql.database", "gitlabhq_production")},
+		{Name: "SOURCE_ROLE", Value: values.GetString("global.psql.username", "gitlab")},
+		{Name: "DESTINATION_HOST", Value: migration.Host},
+		{Name: "DESTINATION_PORT", Value: fmt.Sprintf("%d", migration.Port)},
+		{Name: "DESTINATION_DATABASE", Value: migration.Database},
+		{Name: "DESTINATION_USER", Value: migration.Username},
+		secretEnvVar("DESTINATION_PASSWORD", migration.PasswordSecret, migration.PasswordKey),
+	}
+
+	return externalMigrationJob(adapter, statefulSet, postgresContainer(statefulSet),
+		fmt.Sprintf("%s-postgresql-migration", adapter.ReleaseName()), postgresMigrationScript, env), nil
+}
+
+// RedisMigrationJob returns the Job that copies the keys of the bundled Redis
+// to the external server. It uses the image of the bundled Redis.
+func RedisMigrationJob(adapter gitlab.Adapter, template helm.Template, statefulSet *appsv1.StatefulSet, migration *gitlab.RedisMigration) (*batchv1.Job, error) {
+	source := BundledRedisConnection(adapter, template)
+	if source.Host == "" {
+		return nil, fmt.Errorf("the Redis master Service is required to migrate Redis")
+	}
+
+	migrationSecret, migrationKey := RedisMigrationSecret(adapter)
+
+	env := []corev1.EnvVar{
+		{Name: "SOURCE_HOST", Value: source.Host},
+		{Name: "SOURCE_PORT", Value: fmt.Sprintf("%d", source.Port)},
+		secretEnvVar("SOURCE_PASSWORD", source.PasswordSecret, source.PasswordKey),
+		{Name: "MIGRATION_USER", Value: redisMigrationUser},
+		secretEnvVar("MIGRATION_PASSWORD", migrationSecret, migrationKey),
+		{Name: "DESTINATION_HOST", Value: migration.Host},
+		{Name: "DESTINATION_PORT", Value: fmt.Sprintf("%d", migration.Port)},
+	}
+
+	if migration.PasswordSecret != "" {
+		env = append(env, secretEnvVar("DESTINATION_PASSWORD", migration.PasswordSecret, migration.PasswordKey))
+	}
+
+	return externalMigrationJob(adapter, statefulSet, namedContainer(statefulSet, DefaultRedisComponentName),
+		fmt.Sprintf("%s-redis-migration", adapter.ReleaseName()), redisMigrationScript, env), nil
+}
+
+// externalMigrationJob returns a Job that runs the script with the image, the
+// security context and the resources of the container of the bundled
+// service. The Pod does not use the labels of the StatefulSet, so that the
+// Services of the bundled service do not select it. Its own labels are
+// selected by the NetworkPolicies.
+func externalMigrationJob(adapter gitlab.Adapter, statefulSet *appsv1.StatefulSet, source *corev1.Container, name, script string, env []corev1.EnvVar) *batchv1.Job {
+	podSpec := statefulSet.Spec.Template.Spec
+
+	container := corev1.Container{
+		Name:    "migration",
+		Command: []string{"/bin/bash", "-c", script},
+		Env:     env,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "tmp", MountPath: "/tmp"},
+		},
+	}
+
+	if source != nil {
+		container.Image = source.Image
+		container.SecurityContext = source.SecurityContext
+		container.Resources = source.Resources
+	}
+
+	backoffLimit := int32(externalMigrationBackoffLimit)
+
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       JobKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: adapter.Name().Namespace,
+			Labels:    statefulSet.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: MaintenanceJobPodLabels(adapter, ExternalMigrationComponentName),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					SecurityContext:    podSpec.SecurityContext,
+					ImagePullSecrets:   podSpec.ImagePullSecrets,
+					ServiceAccountName: podSpec.ServiceAccountName,
+					Containers:         []corev1.Container{container},
+					Volumes: []corev1.Volume{
+						{
+							Name: "tmp",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func secretEnvVar(name, secret, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
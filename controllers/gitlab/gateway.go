@@ -0,0 +1,189 @@
+package gitlab
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	// GatewayAPIGroup is the API group of the Gateway API.
+	GatewayAPIGroup = "gateway.networking.k8s.io"
+
+	// HTTPRouteAPIVersion is the API version of HTTPRoutes.
+	HTTPRouteAPIVersion = GatewayAPIGroup + "/v1"
+
+	// TCPRouteAPIVersion is the API version of TCPRoutes. TCPRoutes are in
+	// the experimental channel of the Gateway API.
+	TCPRouteAPIVersion = GatewayAPIGroup + "/v1alpha2"
+
+	gatewayKind = "Gateway"
+
+	// shellSSHPortName is the name of the SSH port of the GitLab Shell
+	// Service.
+	shellSSHPortName = "ssh"
+)
+
+// IngressHTTPRoutes converts the Ingress into HTTPRoutes that attach to the
+// Gateway, one for each of its rules. The HTTPRoutes keep the labels and
+// annotations of the Ingress. The paths of the Ingress are prefix matches.
+//
+// TLS is terminated by the Gateway, so the TLS settings of the Ingress are
+// ignored.
+func IngressHTTPRoutes(ingress *networkingv1.Ingress, template helm.Template, gateway *gitlab.GatewayReference) []*unstructured.Unstructured {
+	routes := []*unstructured.Unstructured{}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		rules := []interface{}{}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+
+			rules = append(rules, httpRouteRule(path, template))
+		}
+
+		if len(rules) == 0 {
+			continue
+		}
+
+		spec := map[string]interface{}{
+			"parentRefs": []interface{}{gatewayParentRef(gateway, gateway.HTTPListener)},
+			"rules":      rules,
+		}
+
+		if rule.Host != "" {
+			spec["hostnames"] = []interface{}{rule.Host}
+		}
+
+		name := ingress.Name
+		if len(routes) > 0 {
+			name = fmt.Sprintf("%s-%d", ingress.Name, len(routes))
+		}
+
+		routes = append(routes, newGatewayRoute(HTTPRouteAPIVersion, HTTPRouteKind,
+			name, ingress.Namespace, ingress.GetLabels(), ingressAnnotations(ingress), spec))
+	}
+
+	return routes
+}
+
+// ShellTCPRoute returns the TCPRoute that attaches the SSH port of the GitLab
+// Shell Service to the Gateway. It returns nil when the Service does not
+// exist.
+func ShellTCPRoute(template helm.Template, gateway *gitlab.GatewayReference) *unstructured.Unstructured {
+	service, ok := ShellService(template).(*corev1.Service)
+	if !ok || len(service.Spec.Ports) == 0 {
+		return nil
+	}
+
+	port := service.Spec.Ports[0].Port
+
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Name == shellSSHPortName {
+			port = servicePort.Port
+		}
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{gatewayParentRef(gateway, gateway.SSHListener)},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"name": service.Name,
+						"port": int64(port),
+					},
+				},
+			},
+		},
+	}
+
+	return newGatewayRoute(TCPRouteAPIVersion, TCPRouteKind,
+		service.Name, service.Namespace, service.GetLabels(), nil, spec)
+}
+
+func httpRouteRule(path networkingv1.HTTPIngressPath, template helm.Template) map[string]interface{} {
+	value := path.Path
+	if value == "" {
+		value = "/"
+	}
+
+	return map[string]interface{}{
+		"matches": []interface{}{
+			map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":  "PathPrefix",
+					"value": value,
+				},
+			},
+		},
+		"backendRefs": []interface{}{
+			map[string]interface{}{
+				"name": path.Backend.Service.Name,
+				"port": backendServicePort(template, path.Backend.Service),
+			},
+		},
+	}
+}
+
+// backendServicePort returns the port number of the backend Service. The
+// routes of the Gateway API only reference Service ports by number.
+func backendServicePort(template helm.Template, backend *networkingv1.IngressServiceBackend) int64 {
+	if backend.Port.Name == "" {
+		return int64(backend.Port.Number)
+	}
+
+	service, ok := template.Query().ObjectByKindAndName(ServiceKind, backend.Name).(*corev1.Service)
+	if ok {
+		for _, servicePort := range service.Spec.Ports {
+			if servicePort.Name == backend.Port.Name {
+				return int64(servicePort.Port)
+			}
+		}
+	}
+
+	return int64(backend.Port.Number)
+}
+
+func gatewayParentRef(gateway *gitlab.GatewayReference, listener string) map[string]interface{} {
+	parentRef := map[string]interface{}{
+		"group":     GatewayAPIGroup,
+		"kind":      gatewayKind,
+		"name":      gateway.Name,
+		"namespace": gateway.Namespace,
+	}
+
+	if listener != "" {
+		parentRef["sectionName"] = listener
+	}
+
+	return parentRef
+}
+
+func newGatewayRoute(apiVersion, kind, name, namespace string, labels, annotations map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+
+	route.SetAPIVersion(apiVersion)
+	route.SetKind(kind)
+	route.SetName(name)
+	route.SetNamespace(namespace)
+	route.SetLabels(labels)
+	route.SetAnnotations(annotations)
+
+	return route
+}
@@ -0,0 +1,80 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("Gateway API routes", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	When("the networking mode is GatewayAPI", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue("nginx-ingress.enabled", true)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.Networking = &gitlabv1beta1.NetworkingSpec{
+			Mode: "GatewayAPI",
+			Gateway: &gitlabv1beta1.GatewayReferenceSpec{
+				Name:         "shared",
+				Namespace:    "gateways",
+				HTTPListener: "https",
+				SSHListener:  "ssh",
+			},
+		}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+		gateway := adapter.NetworkingGateway()
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should not deploy the NGINX Ingress Controller", func() {
+			Expect(adapter.WantsComponent(component.NginxIngress)).To(BeFalse())
+		})
+
+		It("Should convert the Webservice Ingress into an HTTPRoute", func() {
+			ingress, ok := WebserviceIngresses(template)[0].(*networkingv1.Ingress)
+			Expect(ok).To(BeTrue())
+
+			routes := IngressHTTPRoutes(ingress, template, gateway)
+			Expect(routes).To(HaveLen(1))
+
+			route := routes[0]
+			Expect(route.GetKind()).To(Equal(HTTPRouteKind))
+			Expect(route.GetName()).To(Equal(ingress.GetName()))
+
+			hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+			Expect(hostnames).To(ConsistOf("gitlab.example.com"))
+
+			parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			Expect(parentRefs).To(ConsistOf(HaveKeyWithValue("sectionName", "https")))
+			Expect(parentRefs).To(ConsistOf(HaveKeyWithValue("namespace", "gateways")))
+		})
+
+		It("Should attach the SSH port of GitLab Shell to the Gateway", func() {
+			route := ShellTCPRoute(template, gateway)
+			Expect(route).NotTo(BeNil())
+			Expect(route.GetKind()).To(Equal(TCPRouteKind))
+
+			parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			Expect(parentRefs).To(ConsistOf(HaveKeyWithValue("sectionName", "ssh")))
+
+			rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+			Expect(rules).To(HaveLen(1))
+		})
+	})
+})
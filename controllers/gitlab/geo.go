@@ -0,0 +1,117 @@
+package gitlab
+
+import (
+	"fmt"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+)
+
+const (
+	// GeoLogcursorComponentName is the common name of the Geo Log Cursor.
+	GeoLogcursorComponentName = "geo-logcursor"
+
+	// geoMigrationsDeadline is the maximum time that the migrations Job of a
+	// secondary site waits for the primary site, in seconds.
+	geoMigrationsDeadline = 3600
+)
+
+// geoMigrationsScript runs the migrations of a secondary site. The schema of
+// the main database is replicated from the primary site, so the script waits
+// until the primary site has migrated it to the version of the secondary site
+// and only migrates the tracking database. The wait is bounded by the active
+// deadline of the Job.
+const geoMigrationsScript = `
+until gitlab-rake db:abort_if_pending_migrations:main > /dev/null 2>&1; do
+  echo "Waiting for the primary site to migrate the main database"
+  sleep 30
+done
+
+exec gitlab-rake db:migrate:geo
+`
+
+// GeoLogcursorDeployment returns the Deployment of the Geo Log Cursor.
+func GeoLogcursorDeployment(template helm.Template) client.Object {
+	return template.Query().ObjectByKindAndComponent(DeploymentKind, GeoLogcursorComponentName)
+}
+
+// IsGeoSecondary returns true when the instance is a secondary Geo site.
+func IsGeoSecondary(adapter gitlab.Adapter) bool {
+	return adapter.GeoRole() == gitlab.GeoSecondary
+}
+
+// GeoTrackingDatabaseConnection returns the connection settings of the Geo
+// tracking database of a secondary site.
+func GeoTrackingDatabaseConnection(adapter gitlab.Adapter) PostgresConnection {
+	values := adapter.Values()
+
+	port, err := strconv.Atoi(values.GetString("global.geo.psql.port", "5432"))
+	if err != nil {
+		port = 5432
+	}
+
+	return PostgresConnection{
+		Host:           values.GetString("global.geo.psql.host"),
+		Port:           port,
+		Database:       values.GetString("global.geo.psql.database", "gitlabhq_geo_production"),
+		Username:       values.GetString("global.geo.psql.username", "gitlab_geo"),
+		PasswordSecret: values.GetString("global.geo.psql.password.secret", fmt.Sprintf("%s-geo-postgresql-password", adapter.ReleaseName())),
+		PasswordKey:    values.GetString("global.geo.psql.password.key", "postgresql-password"),
+	}
+}
+
+// ProvisionsGeoTrackingDatabase returns true when the Operator creates the
+// Geo tracking database in the bundled PostgreSQL. This is the case for a
+// secondary site with the bundled PostgreSQL, unless the tracking database is
+// on another PostgreSQL server.
+func ProvisionsGeoTrackingDatabase(adapter gitlab.Adapter) bool {
+	return IsGeoSecondary(adapter) &&
+		adapter.WantsComponent(component.PostgreSQL) &&
+		GeoTrackingDatabaseConnection(adapter).Host == bundledPostgresHost(adapter)
+}
+
+// BundledPostgresPasswordSecret returns the name and the key of the Secret
+// that holds the passwords of the bundled PostgreSQL. On a secondary Geo site
+// GitLab uses the password of the replica of the primary database instead, so
+// the bundled PostgreSQL keeps its own Secret.
+func BundledPostgresPasswordSecret(adapter gitlab.Adapter) (string, string) {
+	defaultSecret := fmt.Sprintf("%s-postgresql-password", adapter.ReleaseName())
+
+	if IsGeoSecondary(adapter) {
+		return defaultSecret, "postgresql-password"
+	}
+
+	values := adapter.Values()
+
+	return values.GetString("global.psql.password.secret", defaultSecret),
+		values.GetString("global.psql.password.key", "postgresql-password")
+}
+
+// bundledPostgresHost returns the address of the bundled PostgreSQL that the
+// GitLab Chart uses by default.
+func bundledPostgresHost(adapter gitlab.Adapter) string {
+	return fmt.Sprintf("%s-postgresql.%s.svc", adapter.ReleaseName(), adapter.Name().Namespace)
+}
+
+// applyGeoMigrations replaces the migrations of the main database with the
+// migrations of the tracking database on a secondary site. The Job fails when
+// the primary site does not migrate the main database within the deadline.
+func applyGeoMigrations(adapter gitlab.Adapter, job *batchv1.Job) {
+	if !IsGeoSecondary(adapter) {
+		return
+	}
+
+	job.SetName(fmt.Sprintf("%s-geo", job.GetName()))
+	job.Spec.ActiveDeadlineSeconds = pointer.Int64(geoMigrationsDeadline)
+
+	for i := range job.Spec.Template.Spec.Containers {
+		job.Spec.Template.Spec.Containers[i].Command = []string{"/bin/bash", "-c", geoMigrationsScript}
+		job.Spec.Template.Spec.Containers[i].Args = nil
+	}
+}
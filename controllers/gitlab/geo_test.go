@@ -0,0 +1,109 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("Geo resources", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	When("the instance is a primary site", func() {
+		mockGitLab := CreateMockGitLab(releaseName, namespace, support.Values{})
+		mockGitLab.Spec.Geo = &gitlabv1beta1.GeoSpec{Role: "primary"}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should enable Geo without the Log Cursor", func() {
+			Expect(adapter.Values().GetBool("global.geo.enabled")).To(BeTrue())
+			Expect(adapter.Values().GetString("global.geo.role")).To(Equal("primary"))
+			Expect(adapter.WantsComponent(component.GeoLogcursor)).To(BeFalse())
+			Expect(GeoLogcursorDeployment(template)).To(BeNil())
+		})
+
+		It("Should not provision the tracking database", func() {
+			Expect(ProvisionsGeoTrackingDatabase(adapter)).To(BeFalse())
+			Expect(GeoSecrets(adapter)).To(BeEmpty())
+		})
+
+		It("Should run the migrations of the main database", func() {
+			job, err := MigrationsJob(adapter, template)
+			Expect(err).To(BeNil())
+			Expect(job.GetName()).NotTo(HaveSuffix("-geo"))
+		})
+	})
+
+	When("the instance is a secondary site", func() {
+		mockGitLab := CreateMockGitLab(releaseName, namespace, support.Values{})
+		mockGitLab.Spec.Geo = &gitlabv1beta1.GeoSpec{
+			Role:     "secondary",
+			NodeName: "secondary.example.com",
+			Peer: &gitlabv1beta1.GeoPeerSpec{
+				Database: gitlabv1beta1.ExternalPostgreSQLSpec{
+					Host: "replica.example.com",
+					Password: gitlabv1beta1.SecretKeySpec{
+						Secret: "primary-postgresql-password",
+						Key:    "password",
+					},
+				},
+			},
+		}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should read from the replica of the primary database", func() {
+			values := adapter.Values()
+			Expect(values.GetString("global.geo.nodeName")).To(Equal("secondary.example.com"))
+			Expect(values.GetString("global.psql.host")).To(Equal("replica.example.com"))
+			Expect(values.GetString("global.psql.password.secret")).To(Equal("primary-postgresql-password"))
+		})
+
+		It("Should keep the password Secret of the bundled PostgreSQL", func() {
+			secret, key := BundledPostgresPasswordSecret(adapter)
+			Expect(secret).To(Equal(releaseName + "-postgresql-password"))
+			Expect(key).To(Equal("postgresql-password"))
+		})
+
+		It("Should provision the tracking database in the bundled PostgreSQL", func() {
+			connection := GeoTrackingDatabaseConnection(adapter)
+			Expect(ProvisionsGeoTrackingDatabase(adapter)).To(BeTrue())
+			Expect(connection.Database).To(Equal("gitlabhq_geo_production"))
+			Expect(connection.Username).To(Equal("gitlab_geo"))
+			Expect(GeoSecrets(adapter)).To(HaveLen(2))
+		})
+
+		It("Should contain the Geo Log Cursor", func() {
+			Expect(adapter.WantsComponent(component.GeoLogcursor)).To(BeTrue())
+			Expect(GeoLogcursorDeployment(template)).NotTo(BeNil())
+		})
+
+		It("Should only migrate the tracking database", func() {
+			job, err := MigrationsJob(adapter, template)
+			Expect(err).To(BeNil())
+			Expect(job.GetName()).To(HaveSuffix("-geo"))
+			Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElement(geoMigrationsScript))
+
+			preMigrations, err := PreMigrationsJob(adapter, template)
+			Expect(err).To(BeNil())
+			Expect(preMigrations.GetName()).To(HaveSuffix("-geo-pre"))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package gitlab
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	gitalyMigrationSourceEnv      = "SOURCE_STORAGE"
+	gitalyMigrationDestinationEnv = "DESTINATION_STORAGE"
+
+	gitalyMigrationBackoffLimit = 2
+)
+
+// gitalyMigrationScheduleScript stops placing new repositories in the source
+// storage and schedules the moves of all the repositories of the source
+// storage, the same as the repository storage moves API.
+const gitalyMigrationScheduleScript = `
+source = ENV.fetch('SOURCE_STORAGE')
+destination = ENV.fetch('DESTINATION_STORAGE')
+
+weights = Gitlab::CurrentSettings.repository_storages_weighted.stringify_keys
+ApplicationSetting.current_without_cache.update!(
+  repository_storages_weighted: weights.merge(source => 0, destination => 100))
+
+Projects::ScheduleBulkRepositoryShardMovesService.new.execute(source, destination)
+Snippets::ScheduleBulkRepositoryShardMovesService.new.execute(source, destination)
+
+if defined?(Groups::ScheduleBulkRepositoryShardMovesService)
+  Groups::ScheduleBulkRepositoryShardMovesService.new.execute(source, destination)
+end
+`
+
+// gitalyMigrationCheckScript counts the repositories that remain in the
+// source storage and the scheduled and failed moves, and reports them in the
+// termination message of the container.
+const gitalyMigrationCheckScript = `
+source = ENV.fetch('SOURCE_STORAGE')
+
+repositories = [ProjectRepository, SnippetRepository]
+repositories << GroupWikiRepository if defined?(GroupWikiRepository)
+
+moves = [Projects::RepositoryStorageMove, Snippets::RepositoryStorageMove]
+moves << Groups::RepositoryStorageMove if defined?(Groups::RepositoryStorageMove)
+
+progress = {
+  remaining: repositories.sum { |r| r.for_repository_storage(source).count },
+  inProgress: moves.sum { |m| m.where(source_storage_name: source).with_state(:scheduled, :started, :replicated).count },
+  failed: moves.sum { |m| m.where(source_storage_name: source).with_state(:failed).count }
+}
+
+File.write('/dev/termination-log', progress.to_json)
+`
+
+// PraefectVirtualStorages returns the names of the Praefect virtual storages.
+func PraefectVirtualStorages(adapter gitlab.Adapter) []string {
+	value, err := adapter.Values().GetValue("global.praefect.virtualStorages")
+	if err != nil {
+		return []string{"default"}
+	}
+
+	storages, ok := value.([]interface{})
+	if !ok {
+		return []string{"default"}
+	}
+
+	result := []string{}
+
+	for _, storage := range storages {
+		settings, ok := storage.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if name, _ := settings["name"].(string); name != "" {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// GitalyMigrationScheduleJob returns the Job that schedules the moves of the
+// repositories of the source storage to the destination storage. Each
+// attempt uses a new Job.
+func GitalyMigrationScheduleJob(adapter gitlab.Adapter, template helm.Template, migration *gitlab.GitalyMigration, attempt int32) (*batchv1.Job, error) {
+	return gitalyMigrationJob(adapter, template, migration,
+		fmt.Sprintf("%s-gitaly-migration-%d", adapter.ReleaseName(), attempt),
+		gitalyMigrationScheduleScript)
+}
+
+// GitalyMigrationCheckJob returns the Job that reports the progress of the
+// migration in its termination message.
+func GitalyMigrationCheckJob(adapter gitlab.Adapter, template helm.Template, migration *gitlab.GitalyMigration) (*batchv1.Job, error) {
+	return gitalyMigrationJob(adapter, template, migration,
+		fmt.Sprintf("%s-gitaly-migration-check", adapter.ReleaseName()),
+		gitalyMigrationCheckScript)
+}
+
+// gitalyMigrationJob returns a Job that runs the script with the Rails runner
+// of the Toolbox.
+func gitalyMigrationJob(adapter gitlab.Adapter, template helm.Template, migration *gitlab.GitalyMigration, name, script string) (*batchv1.Job, error) {
+	return railsRunnerJob(adapter, template, name, script, gitalyMigrationBackoffLimit,
+		corev1.EnvVar{Name: gitalyMigrationSourceEnv, Value: migration.SourceStorage},
+		corev1.EnvVar{Name: gitalyMigrationDestinationEnv, Value: migration.DestinationStorage})
+}
@@ -19,7 +19,8 @@ func MigrationsConfigMap(adapter gitlab.Adapter, template helm.Template) client.
 		fmt.Sprintf("%s-%s", adapter.ReleaseName(), MigrationsComponentName))
 }
 
-// MigrationsJob returns the Job for Migrations component.
+// MigrationsJob returns the Job for Migrations component. On a secondary Geo
+// site, it only migrates the tracking database.
 func MigrationsJob(adapter gitlab.Adapter, template helm.Template) (*batchv1.Job, error) {
 	migrations := template.Query().ObjectByKindAndComponent(JobKind, MigrationsComponentName)
 	job, ok := migrations.DeepCopyObject().(*batchv1.Job)
@@ -34,6 +35,7 @@ func MigrationsJob(adapter gitlab.Adapter, template helm.Template) (*batchv1.Job
 	}
 
 	job.SetName(nameWithSuffix)
+	applyGeoMigrations(adapter, job)
 
 	return job, nil
 }
@@ -0,0 +1,665 @@
+package gitlab
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/settings"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+)
+
+const (
+	// NetworkPolicyKind is the kind of NetworkPolicies.
+	NetworkPolicyKind = "NetworkPolicy"
+
+	// PostgresUpgradeComponentName is the common name of the Jobs that
+	// upgrade the bundled PostgreSQL.
+	PostgresUpgradeComponentName = "postgresql-upgrade"
+
+	// ExternalMigrationComponentName is the common name of the Jobs that
+	// migrate the bundled services to external services.
+	ExternalMigrationComponentName = "external-migration"
+
+	// CertManagerSolverComponentName is the name of the NetworkPolicy of the
+	// Pods that solve the HTTP-01 challenges of cert-manager.
+	CertManagerSolverComponentName = "cm-acme-http-solver"
+
+	// operatorPodLabel selects the Pods of the Operator, which connect to
+	// the bundled PostgreSQL to provision databases and to the bundled Redis
+	// to check it and to measure the Sidekiq queues.
+	operatorPodLabel      = "control-plane"
+	operatorPodLabelValue = "controller-manager"
+
+	// certManagerSolverPodLabel selects the Pods of the HTTP-01 solvers that
+	// cert-manager creates in the namespace.
+	certManagerSolverPodLabel = "acme.cert-manager.io/http01-solver"
+
+	// namespaceNameLabel is the label of the name of a namespace, which
+	// Kubernetes sets on all namespaces.
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+
+	releaseLabel = "release"
+)
+
+// Default ports of the components. The NetworkPolicies do not follow ports
+// that are changed in the values.
+const (
+	gitalyPort            = 8075
+	webservicePumaPort    = 8080
+	workhorsePort         = 8181
+	registryPort          = 5000
+	kasExternalPort       = 8150
+	kasInternalPort       = 8153
+	kasProxyPort          = 8154
+	pagesPort             = 8090
+	shellPort             = 2222
+	minioPort             = 9000
+	redisPort             = 6379
+	redisSentinelPort     = 26379
+	postgresPort          = 5432
+	spamcheckPort         = 8001
+	zoektPort             = 8080
+	smtpPort              = 2525
+	imapPort              = 993
+	httpPort              = 80
+	httpsPort             = 443
+	kubernetesAPIPort     = 6443
+	dnsPort               = 53
+	openShiftDNSPort      = 5353
+	objectStorageTLSPort  = httpsPort
+	certManagerSolverPort = 8089
+)
+
+// networkPolicyPath is a known traffic path between the components of an
+// instance. The clients connect to the ports of the server.
+type networkPolicyPath struct {
+	server  string
+	ports   []int32
+	clients []string
+}
+
+// networkPolicyPaths are the traffic paths between the components. Paths
+// with a component that is not enabled are ignored.
+var networkPolicyPaths = []networkPolicyPath{
+	{
+		server: GitalyComponentName,
+		ports:  []int32{gitalyPort},
+		clients: []string{WebserviceComponentName, SidekiqComponentName, GitLabShellComponentName,
+			ToolboxComponentName, KasComponentName, MigrationsComponentName, PraefectComponentName,
+			GitalyComponentName},
+	},
+	{
+		server: PraefectComponentName,
+		ports:  []int32{gitalyPort},
+		clients: []string{WebserviceComponentName, SidekiqComponentName, GitLabShellComponentName,
+			ToolboxComponentName, KasComponentName, MigrationsComponentName},
+	},
+	{
+		server: WebserviceComponentName,
+		ports:  []int32{webservicePumaPort, workhorsePort},
+		clients: []string{NGINXComponentName, GitLabShellComponentName, KasComponentName,
+			PagesComponentName, GitalyComponentName, RegistryComponentName, MailroomComponentName,
+			ZoektComponentName},
+	},
+	{
+		server:  RegistryComponentName,
+		ports:   []int32{registryPort},
+		clients: []string{NGINXComponentName, WebserviceComponentName, SidekiqComponentName},
+	},
+	{
+		server:  KasComponentName,
+		ports:   []int32{kasExternalPort, kasInternalPort, kasProxyPort},
+		clients: []string{NGINXComponentName, WebserviceComponentName, SidekiqComponentName},
+	},
+	{
+		server:  PagesComponentName,
+		ports:   []int32{pagesPort},
+		clients: []string{NGINXComponentName},
+	},
+	{
+		server:  GitLabShellComponentName,
+		ports:   []int32{shellPort},
+		clients: []string{NGINXComponentName},
+	},
+	{
+		server: MinioComponentName,
+		ports:  []int32{minioPort},
+		clients: []string{NGINXComponentName, WebserviceComponentName, SidekiqComponentName,
+			ToolboxComponentName, RegistryComponentName, PagesComponentName, MigrationsComponentName},
+	},
+	{
+		server: DefaultRedisComponentName,
+		ports:  []int32{redisPort},
+		clients: []string{WebserviceComponentName, SidekiqComponentName, ToolboxComponentName,
+			MigrationsComponentName, KasComponentName, MailroomComponentName, GitLabExporterComponentName,
+			RegistryComponentName, GeoLogcursorComponentName, ExternalMigrationComponentName},
+	},
+	{
+		server: DefaultPostgresComponentName,
+		ports:  []int32{postgresPort},
+		clients: []string{WebserviceComponentName, SidekiqComponentName, ToolboxComponentName,
+			MigrationsComponentName, GitLabExporterComponentName, PraefectComponentName,
+			RegistryComponentName, GeoLogcursorComponentName, PostgresUpgradeComponentName,
+			ExternalMigrationComponentName},
+	},
+	{
+		server:  SpamcheckComponentName,
+		ports:   []int32{spamcheckPort},
+		clients: []string{WebserviceComponentName, SidekiqComponentName},
+	},
+	{
+		server:  ZoektComponentName,
+		ports:   []int32{zoektPort},
+		clients: []string{WebserviceComponentName, SidekiqComponentName},
+	},
+	{
+		server:  CertManagerSolverComponentName,
+		ports:   []int32{certManagerSolverPort},
+		clients: []string{NGINXComponentName},
+	},
+}
+
+// networkPolicyOperatorServers are the components that the Operator connects
+// to.
+var networkPolicyOperatorServers = []string{DefaultPostgresComponentName, DefaultRedisComponentName}
+
+// networkPolicyPublicPorts are the ports that accept connections from any
+// source, because they are exposed outside of the namespace by the
+// Ingresses, the Routes, the Gateway or a LoadBalancer Service.
+var networkPolicyPublicPorts = map[string][]int32{
+	WebserviceComponentName:  {workhorsePort},
+	RegistryComponentName:    {registryPort},
+	KasComponentName:         {kasExternalPort, kasProxyPort},
+	PagesComponentName:       {pagesPort},
+	GitLabShellComponentName: {shellPort},
+	MinioComponentName:       {minioPort},
+}
+
+var (
+	// networkPolicyInternetClients connect to arbitrary HTTP endpoints, for
+	// example webhooks, integrations and the external object storage.
+	networkPolicyInternetClients = []string{WebserviceComponentName, SidekiqComponentName}
+
+	// networkPolicyObjectStorageClients connect to the object storage.
+	networkPolicyObjectStorageClients = []string{WebserviceComponentName, SidekiqComponentName,
+		ToolboxComponentName, RegistryComponentName, PagesComponentName, MigrationsComponentName}
+
+	// networkPolicyKubernetesAPIClients connect to the Kubernetes API.
+	networkPolicyKubernetesAPIClients = []string{NGINXComponentName, SharedSecretsComponentName,
+		PrometheusComponentName}
+
+	// networkPolicyMailClients send emails with SMTP.
+	networkPolicyMailClients = []string{WebserviceComponentName, SidekiqComponentName}
+)
+
+// NetworkPolicies returns a NetworkPolicy for each enabled component of the
+// instance. Each NetworkPolicy selects the Pods of the component and only
+// allows their known traffic: the connections from and to the other
+// components on their default ports, the public ports of the component,
+// DNS and the external services that are configured in the values.
+//
+// The NetworkPolicies are sorted by name.
+func NetworkPolicies(adapter gitlab.Adapter, template helm.Template) []*networkingv1.NetworkPolicy {
+	selectors := networkPolicySelectors(adapter, template)
+	external := networkPolicyExternalEndpoints(adapter)
+	prometheus, monitored := selectors[PrometheusComponentName]
+
+	names := make([]string, 0, len(selectors))
+	for name := range selectors {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	result := make([]*networkingv1.NetworkPolicy, 0, len(names))
+
+	for _, name := range names {
+		ingress := networkPolicyIngressRules(name, selectors)
+		egress := networkPolicyEgressRules(name, selectors, external)
+
+		if monitored && name != PrometheusComponentName {
+			ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+				From: []networkingv1.NetworkPolicyPeer{podPeer(prometheus)},
+			})
+		}
+
+		labels := map[string]string{}
+		updateCommonLabels(adapter.ReleaseName(), name, labels)
+
+		result = append(result, &networkingv1.NetworkPolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: networkingv1.SchemeGroupVersion.String(),
+				Kind:       NetworkPolicyKind,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s", adapter.ReleaseName(), name),
+				Namespace: adapter.Name().Namespace,
+				Labels:    labels,
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: selectors[name]},
+				PolicyTypes: []networkingv1.PolicyType{
+					networkingv1.PolicyTypeIngress,
+					networkingv1.PolicyTypeEgress,
+				},
+				Ingress: ingress,
+				Egress:  egress,
+			},
+		})
+	}
+
+	return result
+}
+
+// MaintenanceJobPodLabels returns the Pod labels of the Jobs that the
+// Operator runs to maintain the component. The NetworkPolicies select these
+// Pods by their labels. The labels must not match the selectors of the
+// Services of the component.
+func MaintenanceJobPodLabels(adapter gitlab.Adapter, componentName string) map[string]string {
+	return map[string]string{
+		appLabel:     componentName,
+		releaseLabel: adapter.ReleaseName(),
+	}
+}
+
+// networkPolicySelectors returns the Pod selectors of the enabled components.
+func networkPolicySelectors(adapter gitlab.Adapter, template helm.Template) map[string]map[string]string {
+	chartComponents := map[string]gitlab.Component{
+		GitalyComponentName:         component.Gitaly,
+		GeoLogcursorComponentName:   component.GeoLogcursor,
+		GitLabExporterComponentName: component.GitLabExporter,
+		PagesComponentName:          component.GitLabPages,
+		GitLabShellComponentName:    component.GitLabShell,
+		KasComponentName:            component.GitLabKAS,
+		MailroomComponentName:       component.Mailroom,
+		MigrationsComponentName:     component.Migrations,
+		MinioComponentName:          component.MinIO,
+		NGINXComponentName:          component.NginxIngress,
+		PraefectComponentName:       component.Praefect,
+		RegistryComponentName:       component.Registry,
+		SidekiqComponentName:        component.Sidekiq,
+		SpamcheckComponentName:      component.Spamcheck,
+		ToolboxComponentName:        component.Toolbox,
+		WebserviceComponentName:     component.Webservice,
+	}
+
+	selectors := map[string]map[string]string{
+		SharedSecretsComponentName: releaseSelector(adapter, SharedSecretsComponentName),
+	}
+
+	for name, chartComponent := range chartComponents {
+		if adapter.WantsComponent(chartComponent) {
+			selectors[name] = releaseSelector(adapter, name)
+		}
+	}
+
+	if adapter.WantsComponent(component.PostgreSQL) {
+		if selector := workloadSelector(PostgresStatefulSet(adapter, template)); selector != nil {
+			selectors[DefaultPostgresComponentName] = selector
+			selectors[PostgresUpgradeComponentName] = MaintenanceJobPodLabels(adapter, PostgresUpgradeComponentName)
+		}
+	}
+
+	if adapter.WantsComponent(component.Redis) {
+		if selector := workloadSelector(RedisStatefulSet(adapter, template)); selector != nil {
+			selectors[DefaultRedisComponentName] = selector
+		}
+	}
+
+	if adapter.WantsComponent(component.PostgreSQL) || adapter.WantsComponent(component.Redis) {
+		selectors[ExternalMigrationComponentName] = MaintenanceJobPodLabels(adapter, ExternalMigrationComponentName)
+	}
+
+	if adapter.WantsComponent(component.Prometheus) {
+		// The query results are cached, so they are not appended to.
+		workloads := []client.Object{}
+		workloads = append(workloads, PrometheusDeployments(template)...)
+		workloads = append(workloads, PrometheusStatefulSets(template)...)
+
+		for _, workload := range workloads {
+			if selector := workloadSelector(workload); selector != nil {
+				selectors[PrometheusComponentName] = selector
+				break
+			}
+		}
+	}
+
+	if adapter.WantsComponent(component.Zoekt) {
+		if selector := workloadSelector(ZoektStatefulSet(template, adapter)); selector != nil {
+			selectors[ZoektComponentName] = selector
+		}
+	}
+
+	if adapter.WantsComponent(component.NginxIngress) && usesHTTP01Solver(adapter) {
+		selectors[CertManagerSolverComponentName] = map[string]string{certManagerSolverPodLabel: "true"}
+	}
+
+	return selectors
+}
+
+// usesHTTP01Solver returns true when cert-manager can solve HTTP-01
+// challenges for the Ingresses of the instance, either with the Issuer that
+// the chart configures or with an existing issuer.
+func usesHTTP01Solver(adapter gitlab.Adapter) bool {
+	for _, host := range gitlab.CertManagerHosts {
+		if adapter.CertManagerIssuer(host) != nil {
+			return true
+		}
+	}
+
+	return adapter.Values().GetBool("global.ingress.configureCertmanager", true) &&
+		adapter.CertManagerDNS01() == nil
+}
+
+func networkPolicyIngressRules(name string, selectors map[string]map[string]string) []networkingv1.NetworkPolicyIngressRule {
+	rules := []networkingv1.NetworkPolicyIngressRule{}
+
+	// The NGINX Ingress Controller is the entrypoint of the instance.
+	if name == NGINXComponentName {
+		return append(rules, networkingv1.NetworkPolicyIngressRule{})
+	}
+
+	if ports, ok := networkPolicyPublicPorts[name]; ok {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			Ports: networkPolicyPorts(corev1.ProtocolTCP, ports...),
+		})
+	}
+
+	for _, path := range networkPolicyPaths {
+		if path.server != name {
+			continue
+		}
+
+		peers := []networkingv1.NetworkPolicyPeer{}
+
+		for _, client := range path.clients {
+			if selector, ok := selectors[client]; ok {
+				peers = append(peers, podPeer(selector))
+			}
+		}
+
+		if slices.Contains(networkPolicyOperatorServers, name) {
+			peers = append(peers, operatorPeer())
+		}
+
+		if len(peers) == 0 {
+			continue
+		}
+
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  peers,
+			Ports: networkPolicyPorts(corev1.ProtocolTCP, path.ports...),
+		})
+	}
+
+	return rules
+}
+
+func networkPolicyEgressRules(name string, selectors map[string]map[string]string, external map[string][]networkPolicyEndpoint) []networkingv1.NetworkPolicyEgressRule {
+	rules := []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: append(networkPolicyPorts(corev1.ProtocolUDP, dnsPort, openShiftDNSPort),
+				networkPolicyPorts(corev1.ProtocolTCP, dnsPort, openShiftDNSPort)...),
+		},
+	}
+
+	// Prometheus scrapes all the Pods of the namespace.
+	if name == PrometheusComponentName {
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{
+			To: []networkingv1.NetworkPolicyPeer{podPeer(map[string]string{})},
+		})
+	}
+
+	for _, path := range networkPolicyPaths {
+		selector, ok := selectors[path.server]
+		if !ok || !slices.Contains(path.clients, name) {
+			continue
+		}
+
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{
+			To:    []networkingv1.NetworkPolicyPeer{podPeer(selector)},
+			Ports: networkPolicyPorts(corev1.ProtocolTCP, path.ports...),
+		})
+	}
+
+	for _, endpoint := range external[name] {
+		rules = append(rules, endpoint.egressRule())
+	}
+
+	return rules
+}
+
+// networkPolicyEndpoint is a service outside of the namespace.
+type networkPolicyEndpoint struct {
+	// host is empty when the address of the service is not known.
+	host string
+	port int32
+}
+
+// egressRule allows the connections to the endpoint. When the host is an IP
+// address, the rule is limited to it. Otherwise, the rule allows the port
+// on all destinations, because NetworkPolicies do not select host names.
+func (e networkPolicyEndpoint) egressRule() networkingv1.NetworkPolicyEgressRule {
+	rule := networkingv1.NetworkPolicyEgressRule{
+		Ports: networkPolicyPorts(corev1.ProtocolTCP, e.port),
+	}
+
+	if ip := net.ParseIP(e.host); ip != nil {
+		prefix := 32
+		if ip.To4() == nil {
+			prefix = 128
+		}
+
+		rule.To = []networkingv1.NetworkPolicyPeer{{
+			IPBlock: &networkingv1.IPBlock{CIDR: fmt.Sprintf("%s/%d", ip.String(), prefix)},
+		}}
+	}
+
+	return rule
+}
+
+// networkPolicyExternalEndpoints returns the external services that each
+// component connects to, as they are configured in the values.
+func networkPolicyExternalEndpoints(adapter gitlab.Adapter) map[string][]networkPolicyEndpoint {
+	values := adapter.Values()
+	namespace := adapter.Name().Namespace
+	result := map[string][]networkPolicyEndpoint{}
+
+	add := func(clients []string, endpoints ...networkPolicyEndpoint) {
+		for _, client := range clients {
+			for _, endpoint := range endpoints {
+				if endpoint.host != "" && isNamespaceHost(endpoint.host, namespace) {
+					continue
+				}
+
+				if !containsEndpoint(result[client], endpoint) {
+					result[client] = append(result[client], endpoint)
+				}
+			}
+		}
+	}
+
+	postgresClients := networkPolicyClients(DefaultPostgresComponentName)
+	for _, prefix := range []string{"global.psql", "global.psql.main", "global.psql.ci", "global.geo.psql"} {
+		if host := values.GetString(prefix + ".host"); host != "" {
+			add(postgresClients, networkPolicyEndpoint{
+				host: host,
+				port: valuePort(values.GetString(prefix+".port"), postgresPort),
+			})
+		}
+	}
+
+	// Only Praefect connects to its own database.
+	if host := values.GetString("global.praefect.psql.host"); host != "" {
+		add([]string{PraefectComponentName}, networkPolicyEndpoint{
+			host: host,
+			port: valuePort(values.GetString("global.praefect.psql.port"), postgresPort),
+		})
+	}
+
+	redisClients := networkPolicyClients(DefaultRedisComponentName)
+	for _, connection := range ExternalRedisConnections(adapter) {
+		if len(connection.Sentinels) == 0 {
+			add(redisClients, networkPolicyEndpoint{host: connection.Host, port: int32(connection.Port)})
+			continue
+		}
+
+		for _, sentinel := range connection.Sentinels {
+			host, port, err := net.SplitHostPort(sentinel)
+			if err != nil {
+				host, port = sentinel, ""
+			}
+
+			add(redisClients, networkPolicyEndpoint{host: host, port: valuePort(port, redisSentinelPort)})
+		}
+
+		// The Sentinels return the addresses of the Redis servers, which are
+		// not known in advance.
+		add(redisClients, networkPolicyEndpoint{port: redisPort})
+	}
+
+	if !adapter.WantsComponent(component.MinIO) {
+		add(networkPolicyObjectStorageClients, networkPolicyEndpoint{port: objectStorageTLSPort})
+	}
+
+	add(networkPolicyInternetClients,
+		networkPolicyEndpoint{port: httpPort}, networkPolicyEndpoint{port: httpsPort})
+
+	add(networkPolicyKubernetesAPIClients,
+		networkPolicyEndpoint{port: httpsPort}, networkPolicyEndpoint{port: kubernetesAPIPort})
+
+	if values.GetBool("global.smtp.enabled") {
+		add(networkPolicyMailClients, networkPolicyEndpoint{
+			host: values.GetString("global.smtp.address"),
+			port: valuePort(values.GetString("global.smtp.port"), smtpPort),
+		})
+	}
+
+	for _, prefix := range []string{"global.appConfig.incomingEmail", "global.appConfig.serviceDeskEmail"} {
+		if values.GetBool(prefix + ".enabled") {
+			add([]string{MailroomComponentName}, networkPolicyEndpoint{
+				host: values.GetString(prefix + ".host"),
+				port: valuePort(values.GetString(prefix+".port"), imapPort),
+			})
+		}
+	}
+
+	return result
+}
+
+// networkPolicyClients returns the clients of the server.
+func networkPolicyClients(server string) []string {
+	for _, path := range networkPolicyPaths {
+		if path.server == server {
+			return path.clients
+		}
+	}
+
+	return nil
+}
+
+// releaseSelector returns the Pod selector of a component of the GitLab
+// Chart.
+func releaseSelector(adapter gitlab.Adapter, name string) map[string]string {
+	return map[string]string{
+		appLabel:     name,
+		releaseLabel: adapter.ReleaseName(),
+	}
+}
+
+// workloadSelector returns the Pod selector of a Deployment or a
+// StatefulSet, or nil when the object is not a workload.
+func workloadSelector(object client.Object) map[string]string {
+	var selector *metav1.LabelSelector
+
+	switch workload := object.(type) {
+	case *appsv1.Deployment:
+		selector = workload.Spec.Selector
+	case *appsv1.StatefulSet:
+		selector = workload.Spec.Selector
+	}
+
+	if selector == nil || len(selector.MatchLabels) == 0 {
+		return nil
+	}
+
+	return selector.MatchLabels
+}
+
+// operatorPeer selects the Pods of the Operator in its namespace.
+func operatorPeer() networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: settings.OperatorNamespace},
+		},
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{operatorPodLabel: operatorPodLabelValue},
+		},
+	}
+}
+
+func podPeer(selector map[string]string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: selector},
+	}
+}
+
+func networkPolicyPorts(protocol corev1.Protocol, ports ...int32) []networkingv1.NetworkPolicyPort {
+	result := make([]networkingv1.NetworkPolicyPort, 0, len(ports))
+
+	for _, port := range ports {
+		protocol := protocol
+		port := intstr.FromInt(int(port))
+
+		result = append(result, networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &port,
+		})
+	}
+
+	return result
+}
+
+// isNamespaceHost returns true when the host is the name of a Service in the
+// namespace, which is covered by the NetworkPolicies of its component.
+func isNamespaceHost(host, namespace string) bool {
+	if net.ParseIP(host) != nil {
+		return false
+	}
+
+	return !strings.Contains(host, ".") ||
+		strings.HasSuffix(host, fmt.Sprintf(".%s.svc", namespace)) ||
+		strings.HasSuffix(host, fmt.Sprintf(".%s.svc.cluster.local", namespace))
+}
+
+func valuePort(value string, defaultPort int32) int32 {
+	port, err := strconv.Atoi(value)
+	if err != nil || port <= 0 {
+		return defaultPort
+	}
+
+	return int32(port)
+}
+
+func containsEndpoint(endpoints []networkPolicyEndpoint, endpoint networkPolicyEndpoint) bool {
+	for _, e := range endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,196 @@
+package gitlab
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/settings"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("NetworkPolicies", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	findPolicy := func(policies []*networkingv1.NetworkPolicy, name string) *networkingv1.NetworkPolicy {
+		for _, policy := range policies {
+			if policy.Name == fmt.Sprintf("%s-%s", releaseName, name) {
+				return policy
+			}
+		}
+
+		return nil
+	}
+
+	selects := func(peer networkingv1.NetworkPolicyPeer, labels map[string]string) bool {
+		if peer.PodSelector == nil || peer.NamespaceSelector != nil {
+			return false
+		}
+
+		for key, value := range labels {
+			if peer.PodSelector.MatchLabels[key] != value {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	allowsIngress := func(policy *networkingv1.NetworkPolicy, from map[string]string, port int) bool {
+		for _, rule := range policy.Spec.Ingress {
+			for _, peer := range rule.From {
+				if !selects(peer, from) {
+					continue
+				}
+
+				for _, rulePort := range rule.Ports {
+					if *rulePort.Port == intstr.FromInt(port) {
+						return true
+					}
+				}
+			}
+		}
+
+		return false
+	}
+
+	When("NetworkPolicies are enabled", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.NetworkPolicies = &gitlabv1beta1.NetworkPoliciesSpec{Enabled: true}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+			Expect(adapter.WantsNetworkPolicies()).To(BeTrue())
+		})
+
+		var policies []*networkingv1.NetworkPolicy
+
+		BeforeEach(func() {
+			policies = NetworkPolicies(adapter, template)
+		})
+
+		It("Should select the Pods of each enabled component", func() {
+			for _, name := range []string{WebserviceComponentName, SidekiqComponentName, GitalyComponentName,
+				GitLabShellComponentName, DefaultPostgresComponentName, DefaultRedisComponentName} {
+				policy := findPolicy(policies, name)
+				Expect(policy).NotTo(BeNil(), name)
+				Expect(policy.Spec.PodSelector.MatchLabels).NotTo(BeEmpty(), name)
+				Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress))
+			}
+		})
+
+		It("Should allow Webservice to reach Gitaly", func() {
+			gitaly := findPolicy(policies, GitalyComponentName)
+			webservice := findPolicy(policies, WebserviceComponentName)
+
+			Expect(allowsIngress(gitaly, webservice.Spec.PodSelector.MatchLabels, gitalyPort)).To(BeTrue())
+		})
+
+		It("Should allow Sidekiq to reach PostgreSQL and Redis", func() {
+			sidekiq := findPolicy(policies, SidekiqComponentName).Spec.PodSelector.MatchLabels
+
+			Expect(allowsIngress(findPolicy(policies, DefaultPostgresComponentName), sidekiq, postgresPort)).To(BeTrue())
+			Expect(allowsIngress(findPolicy(policies, DefaultRedisComponentName), sidekiq, redisPort)).To(BeTrue())
+			Expect(allowsIngress(findPolicy(policies, DefaultRedisComponentName), sidekiq, postgresPort)).To(BeFalse())
+		})
+
+		It("Should allow the Operator to reach PostgreSQL and Redis from its namespace", func() {
+			for _, name := range []string{DefaultPostgresComponentName, DefaultRedisComponentName} {
+				policy := findPolicy(policies, name)
+				Expect(policy.Spec.Ingress).To(ContainElement(HaveField("From", ContainElement(And(
+					HaveField("PodSelector.MatchLabels", HaveKeyWithValue(operatorPodLabel, operatorPodLabelValue)),
+					HaveField("NamespaceSelector.MatchLabels", HaveKeyWithValue(namespaceNameLabel, settings.OperatorNamespace)),
+				)))), name)
+			}
+		})
+
+		It("Should allow NGINX to reach the HTTP-01 solvers of cert-manager", func() {
+			solver := findPolicy(policies, CertManagerSolverComponentName)
+			Expect(solver).NotTo(BeNil())
+			Expect(solver.Spec.PodSelector.MatchLabels).To(HaveKeyWithValue(certManagerSolverPodLabel, "true"))
+
+			nginx := findPolicy(policies, NGINXComponentName)
+			Expect(allowsIngress(solver, nginx.Spec.PodSelector.MatchLabels, certManagerSolverPort)).To(BeTrue())
+			Expect(nginx.Spec.Egress).To(ContainElement(HaveField("To", ContainElement(
+				HaveField("PodSelector.MatchLabels", HaveKeyWithValue(certManagerSolverPodLabel, "true"))))))
+		})
+
+		It("Should not allow Gitaly to be reached from any source", func() {
+			policy := findPolicy(policies, GitalyComponentName)
+			for _, rule := range policy.Spec.Ingress {
+				Expect(rule.From).NotTo(BeEmpty())
+			}
+		})
+	})
+
+	When("PostgreSQL is external", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue("postgresql.install", false)
+		_ = chartValues.SetValue("global.psql.host", "10.0.0.5")
+		_ = chartValues.SetValue("global.psql.port", 6432)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.NetworkPolicies = &gitlabv1beta1.NetworkPoliciesSpec{Enabled: true}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should allow the clients of PostgreSQL to reach the external server", func() {
+			Expect(err).To(BeNil())
+
+			policies := NetworkPolicies(adapter, template)
+			Expect(findPolicy(policies, DefaultPostgresComponentName)).To(BeNil())
+
+			port := intstr.FromInt(6432)
+			policy := findPolicy(policies, WebserviceComponentName)
+			Expect(policy.Spec.Egress).To(ContainElement(And(
+				HaveField("To", ConsistOf(HaveField("IPBlock.CIDR", "10.0.0.5/32"))),
+				HaveField("Ports", ConsistOf(HaveField("Port", &port))),
+			)))
+		})
+	})
+
+	When("Praefect uses an external PostgreSQL server", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue(globalPraefectEnabled, true)
+		_ = chartValues.SetValue("global.praefect.psql.host", "10.0.0.6")
+		_ = chartValues.SetValue("global.praefect.psql.port", 5433)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.NetworkPolicies = &gitlabv1beta1.NetworkPoliciesSpec{Enabled: true}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should allow Praefect to reach its database", func() {
+			Expect(err).To(BeNil())
+
+			policies := NetworkPolicies(adapter, template)
+
+			port := intstr.FromInt(5433)
+			rule := And(
+				HaveField("To", ConsistOf(HaveField("IPBlock.CIDR", "10.0.0.6/32"))),
+				HaveField("Ports", ConsistOf(HaveField("Port", &port))),
+			)
+
+			Expect(findPolicy(policies, PraefectComponentName).Spec.Egress).To(ContainElement(rule))
+			Expect(findPolicy(policies, WebserviceComponentName).Spec.Egress).NotTo(ContainElement(rule))
+		})
+	})
+})
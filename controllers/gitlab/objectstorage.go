@@ -0,0 +1,107 @@
+package gitlab
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	consolidatedObjectStorage = "object_store"
+	defaultConnectionKey      = "connection"
+)
+
+// objectStorageType holds the Chart defaults of an object type.
+type objectStorageType struct {
+	bucket  string
+	enabled bool
+}
+
+// objectStorageTypes are the object types of GitLab that can be stored in
+// object storage with their default bucket names.
+var objectStorageTypes = map[string]objectStorageType{
+	"artifacts":       {bucket: "gitlab-artifacts", enabled: true},
+	"ciSecureFiles":   {bucket: "gitlab-ci-secure-files"},
+	"dependencyProxy": {bucket: "gitlab-dependency-proxy"},
+	"externalDiffs":   {bucket: "gitlab-mr-diffs"},
+	"lfs":             {bucket: "git-lfs", enabled: true},
+	"packages":        {bucket: "gitlab-packages", enabled: true},
+	"terraformState":  {bucket: "gitlab-terraform-state"},
+	"uploads":         {bucket: "gitlab-uploads", enabled: true},
+}
+
+// ObjectStorageBucket describes a bucket that GitLab uses and the Secret that
+// holds the connection settings of its object storage.
+type ObjectStorageBucket struct {
+	// Type is the object type that is stored in the bucket, for example
+	// `artifacts`.
+	Type   string
+	Bucket string
+
+	// ConnectionSecret and ConnectionKey address the fog connection settings
+	// in YAML format.
+	ConnectionSecret string
+	ConnectionKey    string
+}
+
+// ObjectStorageBuckets returns the buckets of all enabled object types that
+// have a connection Secret, either their own or the consolidated one of
+// `global.appConfig.object_store`. The buckets are sorted by object type.
+func ObjectStorageBuckets(adapter gitlab.Adapter) []ObjectStorageBucket {
+	values := adapter.Values()
+	consolidated := values.GetBool("global.appConfig.object_store.enabled")
+
+	types := map[string]objectStorageType{}
+	for name, defaults := range objectStorageTypes {
+		types[name] = defaults
+	}
+
+	// Object types that are unknown to the Operator are included as long as
+	// they have their own connection.
+	if appConfig, err := values.GetValue("global.appConfig"); err == nil {
+		if appConfigMap, ok := appConfig.(map[string]interface{}); ok {
+			for name := range appConfigMap {
+				if _, known := types[name]; !known && name != consolidatedObjectStorage &&
+					values.GetString(fmt.Sprintf("global.appConfig.%s.connection.secret", name)) != "" {
+					types[name] = objectStorageType{enabled: true}
+				}
+			}
+		}
+	}
+
+	result := []ObjectStorageBucket{}
+
+	for name, defaults := range types {
+		prefix := fmt.Sprintf("global.appConfig.%s", name)
+
+		if !values.GetBool(prefix+".enabled", defaults.enabled) {
+			continue
+		}
+
+		bucket := ObjectStorageBucket{
+			Type:   name,
+			Bucket: values.GetString(prefix+".bucket", defaults.bucket),
+		}
+
+		if consolidated {
+			bucket.ConnectionSecret = values.GetString("global.appConfig.object_store.connection.secret")
+			bucket.ConnectionKey = values.GetString("global.appConfig.object_store.connection.key", defaultConnectionKey)
+		} else {
+			bucket.ConnectionSecret = values.GetString(prefix + ".connection.secret")
+			bucket.ConnectionKey = values.GetString(prefix+".connection.key", defaultConnectionKey)
+		}
+
+		if bucket.ConnectionSecret == "" || bucket.Bucket == "" {
+			continue
+		}
+
+		result = append(result, bucket)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+
+	return result
+}
@@ -0,0 +1,74 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("Object storage buckets", func() {
+	When("Using the consolidated object storage configuration", func() {
+		chartValues := support.Values{}
+		_ = chartValues.AddFromYAML(`
+global:
+  minio:
+    enabled: false
+  appConfig:
+    object_store:
+      enabled: true
+      connection:
+        secret: gitlab-object-storage
+    lfs:
+      bucket: custom-lfs
+    externalDiffs:
+      enabled: true
+`)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+
+		It("Should return the buckets of the enabled object types", func() {
+			Expect(ObjectStorageBuckets(adapter)).To(Equal([]ObjectStorageBucket{
+				{Type: "artifacts", Bucket: "gitlab-artifacts", ConnectionSecret: "gitlab-object-storage", ConnectionKey: "connection"},
+				{Type: "externalDiffs", Bucket: "gitlab-mr-diffs", ConnectionSecret: "gitlab-object-storage", ConnectionKey: "connection"},
+				{Type: "lfs", Bucket: "custom-lfs", ConnectionSecret: "gitlab-object-storage", ConnectionKey: "connection"},
+				{Type: "packages", Bucket: "gitlab-packages", ConnectionSecret: "gitlab-object-storage", ConnectionKey: "connection"},
+				{Type: "uploads", Bucket: "gitlab-uploads", ConnectionSecret: "gitlab-object-storage", ConnectionKey: "connection"},
+			}))
+		})
+	})
+
+	When("Using a connection for each object type", func() {
+		chartValues := support.Values{}
+		_ = chartValues.AddFromYAML(`
+global:
+  minio:
+    enabled: false
+  appConfig:
+    artifacts:
+      bucket: artifacts
+      connection:
+        secret: artifacts-storage
+        key: s3
+    uploads:
+      enabled: false
+      connection:
+        secret: uploads-storage
+    pages:
+      bucket: gitlab-pages
+      connection:
+        secret: pages-storage
+`)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+
+		It("Should return the buckets that have a connection", func() {
+			Expect(ObjectStorageBuckets(adapter)).To(Equal([]ObjectStorageBucket{
+				{Type: "artifacts", Bucket: "artifacts", ConnectionSecret: "artifacts-storage", ConnectionKey: "s3"},
+				{Type: "pages", Bucket: "gitlab-pages", ConnectionSecret: "pages-storage", ConnectionKey: "connection"},
+			}))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package gitlab
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -92,3 +93,86 @@ func PostgresService(adapter gitlab.Adapter, template helm.Template) client.Obje
 
 	return nil
 }
+
+// PostgresConnection describes the connection settings of the PostgreSQL
+// server that GitLab uses, as they are specified in the values.
+type PostgresConnection struct {
+	Host     string
+	Port     int
+	Database string
+	Username string
+
+	// PasswordSecret and PasswordKey address the password of the user.
+	PasswordSecret string
+	PasswordKey    string
+
+	// SSLSecret holds the TLS certificates when SSL is configured. The keys
+	// address the PEM encoded certificates in the Secret.
+	SSLSecret            string
+	ServerCAKey          string
+	ClientCertificateKey string
+	ClientKeyKey         string
+}
+
+// BundledPostgresAdminConnection returns the connection settings of the
+// superuser of the bundled PostgreSQL.
+func BundledPostgresAdminConnection(adapter gitlab.Adapter, template helm.Template) PostgresConnection {
+	values := adapter.Values()
+	passwordSecret, _ := BundledPostgresPasswordSecret(adapter)
+
+	connection := PostgresConnection{
+		Port:           5432,
+		Database:       "postgres",
+		Username:       "postgres",
+		PasswordSecret: passwordSecret,
+		PasswordKey:    values.GetString("postgresql.auth.secretKeys.adminPasswordKey", "postgresql-postgres-password"),
+	}
+
+	if service := PostgresService(adapter, template); service != nil {
+		connection.Host = fmt.Sprintf("%s.%s.svc", service.GetName(), adapter.Name().Namespace)
+	}
+
+	return connection
+}
+
+// ExternalPostgresConnection returns the connection settings of the external
+// PostgreSQL server.
+func ExternalPostgresConnection(adapter gitlab.Adapter) PostgresConnection {
+	values := adapter.Values()
+
+	port, err := strconv.Atoi(values.GetString("global.psql.port", "5432"))
+	if err != nil {
+		port = 5432
+	}
+
+	connection := PostgresConnection{
+		Host:           values.GetString("global.psql.host"),
+		Port:           port,
+		Database:       values.GetString("global.psql.database", "gitlabhq_production"),
+		Username:       values.GetString("global.psql.username", "gitlab"),
+		PasswordSecret: values.GetString("global.psql.password.secret"),
+		PasswordKey:    values.GetString("global.psql.password.key", "postgresql-password"),
+	}
+
+	if sslSecret := values.GetString("global.psql.ssl.secret"); sslSecret != "" {
+		connection.SSLSecret = sslSecret
+		connection.ServerCAKey = values.GetString("global.psql.ssl.serverCA")
+		connection.ClientCertificateKey = values.GetString("global.psql.ssl.clientCertificate")
+		connection.ClientKeyKey = values.GetString("global.psql.ssl.clientKey")
+	}
+
+	return connection
+}
+
+// PostgresMinimumVersion returns the minimum major version of PostgreSQL that
+// the GitLab version of the Chart requires.
+func PostgresMinimumVersion(adapter gitlab.Adapter) int {
+	switch {
+	case IsChartVersionOlderThan(adapter.DesiredVersion(), ChartVersion7):
+		return 12
+	case IsChartVersionOlderThan(adapter.DesiredVersion(), ChartVersion8):
+		return 13
+	default:
+		return 14
+	}
+}
@@ -0,0 +1,321 @@
+package gitlab
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	postgresUpgradeBackupPath   = "/backup"
+	postgresUpgradeBackoffLimit = 2
+)
+
+// postgresFunctions are the shell functions that the scripts of the Jobs
+// use to work with the bundled PostgreSQL.
+//
+// count_table_rows prints the number of rows of every table of a database,
+// so that databases can be compared after they are copied. describe_schema
+// prints the columns, indexes and constraints of the tables and the values of
+// the sequences of a database for the same purpose. set_role_login
+// enables or disables the login of a database user and, when it is disabled,
+// disconnects the user.
+const postgresFunctions = `
+set -euo pipefail
+
+psql_admin() {
+  psql -X -At -v ON_ERROR_STOP=1 -d postgres "$@"
+}
+
+count_table_rows() {
+  psql -X -At -v ON_ERROR_STOP=1 "$@" <<'SQL'
+SELECT format('SELECT %L, count(*) FROM %I.%I', n.nspname || '.' || c.relname, n.nspname, c.relname)
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'r' AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%'
+ORDER BY 1 \gexec
+SQL
+}
+
+describe_schema() {
+  psql -X -At -v ON_ERROR_STOP=1 "$@" <<'SQL'
+SELECT format('column|%s.%s.%s|%s', n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod))
+FROM pg_attribute a JOIN pg_class c ON c.oid = a.attrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+  AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%'
+UNION ALL
+SELECT format('index|%s.%s', schemaname, indexname)
+FROM pg_indexes WHERE schemaname NOT IN ('pg_catalog', 'information_schema') AND schemaname NOT LIKE 'pg_toast%'
+UNION ALL
+SELECT format('constraint|%s.%s.%s', n.nspname, c.conrelid::regclass, c.conname)
+FROM pg_constraint c JOIN pg_namespace n ON n.oid = c.connamespace
+WHERE c.contype IN ('c', 'f', 'p', 'u', 'x') AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+UNION ALL
+SELECT format('sequence|%s.%s|%s', schemaname, sequencename, coalesce(last_value, 0))
+FROM pg_sequences WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+ORDER BY 1
+SQL
+}
+
+set_role_login() {
+  psql_admin -v role="$1" -v mode="$2" > /dev/null <<'SQL'
+SELECT format('ALTER ROLE %I %s', rolname, :'mode') FROM pg_roles WHERE rolname = :'role' \gexec
+SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE usename = :'role' AND :'mode' = 'NOLOGIN';
+SQL
+}
+`
+
+// postgresUpgradeFunctions are the shell functions that both the backup and
+// the restore scripts use.
+//
+// count_rows prints the number of rows of every table of every database and
+// describe_schemas prints the schema of every database, so that the databases
+// can be compared before and after the upgrade. set_login enables or disables
+// the logins of the database users that GitLab and Praefect use.
+const postgresUpgradeFunctions = postgresFunctions + `
+cd /backup
+
+databases() {
+  psql_admin -c "SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate AND datname <> 'postgres' ORDER BY 1"
+}
+
+count_rows() {
+  for db in $(databases); do
+    count_table_rows -d "${db}" | sed "s/^/${db}|/"
+  done | sort
+}
+
+describe_schemas() {
+  for db in $(databases); do
+    describe_schema -d "${db}" | sed "s/^/${db}|/"
+  done | sort
+}
+
+set_login() {
+  while read -r role; do
+    set_role_login "${role}" "$1"
+  done < login-roles.txt
+}
+`
+
+// postgresUpgradeBackupScript stops the database users from logging in, so
+// that GitLab does not write to the databases, and dumps all databases.
+// Logins are enabled again when the backup fails.
+const postgresUpgradeBackupScript = postgresUpgradeFunctions + `
+if [ ! -f login-roles.txt ]; then
+  psql_admin -c "SELECT rolname FROM pg_roles WHERE rolcanlogin AND NOT rolsuper ORDER BY 1" > login-roles.tmp
+  mv login-roles.tmp login-roles.txt
+fi
+
+trap 'set_login LOGIN' ERR
+
+set_login NOLOGIN
+pg_dumpall -X --clean --if-exists -f dumpall.tmp
+mv dumpall.tmp dumpall.sql
+count_rows > rows-before.txt
+describe_schemas > schema-before.txt
+`
+
+// postgresUpgradeRestoreScript restores the dump into the new major version,
+// compares the number of rows of all tables and the schemas, and enables the
+// logins of the database users again. The restore fails on any error, except
+// for the superuser that the dump drops and creates again, because it is the
+// user that runs the restore.
+const postgresUpgradeRestoreScript = postgresUpgradeFunctions + `
+set_login NOLOGIN
+psql -X -d postgres -f dumpall.sql > restore.log 2>&1
+
+if grep 'ERROR:' restore.log | grep -v -e 'ERROR:  role ".*" already exists' -e 'ERROR:  current user cannot be dropped' >&2; then
+  echo "The restore of the dump failed" >&2
+  exit 1
+fi
+
+count_rows > rows-after.txt
+describe_schemas > schema-after.txt
+
+if ! diff rows-before.txt rows-after.txt; then
+  echo "The number of rows differs after the restore" >&2
+  exit 1
+fi
+
+if ! diff schema-before.txt schema-after.txt; then
+  echo "The schema differs after the restore" >&2
+  exit 1
+fi
+
+set_login LOGIN
+`
+
+// PostgresUpgradeBackupClaim returns the PersistentVolumeClaim that holds the
+// dump of the databases during the major version upgrade. It uses the size
+// and the storage class of the data volume of the StatefulSet.
+func PostgresUpgradeBackupClaim(adapter gitlab.Adapter, statefulSet *appsv1.StatefulSet) *corev1.PersistentVolumeClaim {
+	claim := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgresUpgradeName(adapter, "backup"),
+			Namespace: adapter.Name().Namespace,
+			Labels:    statefulSet.Labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("8Gi"),
+				},
+			},
+		},
+	}
+
+	if len(statefulSet.Spec.VolumeClaimTemplates) > 0 {
+		data := statefulSet.Spec.VolumeClaimTemplates[0].Spec
+		claim.Spec.StorageClassName = data.StorageClassName
+
+		if size, ok := data.Resources.Requests[corev1.ResourceStorage]; ok {
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = size
+		}
+	}
+
+	return claim
+}
+
+// PostgresUpgradeBackupJob returns the Job that dumps the databases of the
+// previous major version, using the image of the previous major version.
+func PostgresUpgradeBackupJob(adapter gitlab.Adapter, template helm.Template, statefulSet *appsv1.StatefulSet, image string) (*batchv1.Job, error) {
+	return postgresUpgradeJob(adapter, template, statefulSet, image,
+		postgresUpgradeName(adapter, "backup"), postgresUpgradeBackupScript)
+}
+
+// PostgresUpgradeRestoreJob returns the Job that restores the dump into the
+// new major version, using the image of the new major version.
+func PostgresUpgradeRestoreJob(adapter gitlab.Adapter, template helm.Template, statefulSet *appsv1.StatefulSet, image string) (*batchv1.Job, error) {
+	return postgresUpgradeJob(adapter, template, statefulSet, image,
+		postgresUpgradeName(adapter, "restore"), postgresUpgradeRestoreScript)
+}
+
+// PostgresContainerImage returns the image of the PostgreSQL container of
+// the StatefulSet.
+func PostgresContainerImage(statefulSet *appsv1.StatefulSet) string {
+	if container := postgresContainer(statefulSet); container != nil {
+		return container.Image
+	}
+
+	return ""
+}
+
+func postgresContainer(statefulSet *appsv1.StatefulSet) *corev1.Container {
+	return namedContainer(statefulSet, DefaultPostgresComponentName)
+}
+
+// namedContainer returns the container of the StatefulSet with the name, or
+// the first container when none has the name.
+func namedContainer(statefulSet *appsv1.StatefulSet, name string) *corev1.Container {
+	containers := statefulSet.Spec.Template.Spec.Containers
+
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+
+	if len(containers) > 0 {
+		return &containers[0]
+	}
+
+	return nil
+}
+
+// postgresUpgradeJob returns a Job that runs the script against the bundled
+// PostgreSQL as the superuser, with the backup volume mounted. It uses the
+// security context of the PostgreSQL Pods so that the backup volume is
+// writable.
+func postgresUpgradeJob(adapter gitlab.Adapter, template helm.Template, statefulSet *appsv1.StatefulSet, image, name, script string) (*batchv1.Job, error) {
+	admin := BundledPostgresAdminConnection(adapter, template)
+	if admin.Host == "" {
+		return nil, fmt.Errorf("the PostgreSQL Service is required to upgrade PostgreSQL")
+	}
+
+	podSpec := statefulSet.Spec.Template.Spec
+
+	container := corev1.Container{
+		Name:    "postgresql-upgrade",
+		Image:   image,
+		Command: []string{"/bin/bash", "-c", script},
+		Env: []corev1.EnvVar{
+			{Name: "PGHOST", Value: admin.Host},
+			{Name: "PGPORT", Value: fmt.Sprintf("%d", admin.Port)},
+			{Name: "PGUSER", Value: admin.Username},
+			{
+				Name: "PGPASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: admin.PasswordSecret},
+						Key:                  admin.PasswordKey,
+					},
+				},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "backup", MountPath: postgresUpgradeBackupPath},
+		},
+	}
+
+	if postgres := postgresContainer(statefulSet); postgres != nil {
+		container.SecurityContext = postgres.SecurityContext
+		container.Resources = postgres.Resources
+	}
+
+	backoffLimit := int32(postgresUpgradeBackoffLimit)
+
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       JobKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: adapter.Name().Namespace,
+			Labels:    statefulSet.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: MaintenanceJobPodLabels(adapter, PostgresUpgradeComponentName),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					SecurityContext:    podSpec.SecurityContext,
+					ImagePullSecrets:   podSpec.ImagePullSecrets,
+					ServiceAccountName: podSpec.ServiceAccountName,
+					Containers:         []corev1.Container{container},
+					Volumes: []corev1.Volume{
+						{
+							Name: "backup",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: postgresUpgradeName(adapter, "backup"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return job, nil
+}
+
+func postgresUpgradeName(adapter gitlab.Adapter, suffix string) string {
+	return fmt.Sprintf("%s-postgresql-upgrade-%s", adapter.ReleaseName(), suffix)
+}
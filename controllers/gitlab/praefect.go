@@ -1,9 +1,14 @@
 package gitlab
 
 import (
+	"fmt"
+	"strconv"
+
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
 )
 
 // PraefectStatefulSet returns the StatefulSet of Praefect component.
@@ -25,3 +30,44 @@ func PraefectService(template helm.Template) client.Object {
 func PraefectServiceMonitor(template helm.Template) client.Object {
 	return template.Query().ObjectByKindAndComponent(ServiceMonitorKind, PraefectComponentName)
 }
+
+// PraefectDatabaseSecret returns the name and the key of the Secret that holds
+// the password of the Praefect database user.
+func PraefectDatabaseSecret(adapter gitlab.Adapter) (string, string) {
+	values := adapter.Values()
+
+	return values.GetString("global.praefect.dbSecret.secret", fmt.Sprintf("%s-praefect-dbsecret", adapter.ReleaseName())),
+		values.GetString("global.praefect.dbSecret.key", "secret")
+}
+
+// PraefectDatabaseConnection returns the connection settings of the Praefect
+// database. The host is empty when Praefect uses the bundled PostgreSQL.
+func PraefectDatabaseConnection(adapter gitlab.Adapter) PostgresConnection {
+	values := adapter.Values()
+
+	port, err := strconv.Atoi(values.GetString("global.praefect.psql.port", "5432"))
+	if err != nil {
+		port = 5432
+	}
+
+	passwordSecret, passwordKey := PraefectDatabaseSecret(adapter)
+
+	return PostgresConnection{
+		Host:           values.GetString("global.praefect.psql.host"),
+		Port:           port,
+		Database:       values.GetString("global.praefect.psql.dbName", "praefect"),
+		Username:       values.GetString("global.praefect.psql.user", "praefect"),
+		PasswordSecret: passwordSecret,
+		PasswordKey:    passwordKey,
+	}
+}
+
+// ProvisionsPraefectDatabase returns true when the Operator creates the
+// Praefect database in the bundled PostgreSQL. This is the case when both
+// Praefect and the bundled PostgreSQL are enabled and Praefect does not use
+// another PostgreSQL server.
+func ProvisionsPraefectDatabase(adapter gitlab.Adapter) bool {
+	return adapter.WantsComponent(component.Praefect) &&
+		adapter.WantsComponent(component.PostgreSQL) &&
+		PraefectDatabaseConnection(adapter).Host == ""
+}
@@ -70,4 +70,36 @@ var _ = Describe("Praefect resources", func() {
 			})
 		})
 	})
+
+	Context("Praefect database", func() {
+		When("Praefect uses the bundled PostgreSQL", func() {
+			chartValues := support.Values{}
+			_ = chartValues.SetValue(globalPraefectEnabled, true)
+
+			mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+			adapter := CreateMockAdapter(mockGitLab)
+			connection := PraefectDatabaseConnection(adapter)
+
+			It("Should provision the Praefect database", func() {
+				Expect(ProvisionsPraefectDatabase(adapter)).To(BeTrue())
+				Expect(connection.Database).To(Equal("praefect"))
+				Expect(connection.Username).To(Equal("praefect"))
+				Expect(connection.PasswordSecret).To(Equal(releaseName + "-praefect-dbsecret"))
+				Expect(connection.PasswordKey).To(Equal("secret"))
+			})
+		})
+
+		When("Praefect uses another PostgreSQL server", func() {
+			chartValues := support.Values{}
+			_ = chartValues.SetValue(globalPraefectEnabled, true)
+			_ = chartValues.SetValue("global.praefect.psql.host", "praefect-db.example.com")
+
+			mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+			adapter := CreateMockAdapter(mockGitLab)
+
+			It("Should not provision the Praefect database", func() {
+				Expect(ProvisionsPraefectDatabase(adapter)).To(BeFalse())
+			})
+		})
+	})
 })
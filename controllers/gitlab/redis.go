@@ -1,12 +1,17 @@
 package gitlab
 
 import (
+	"fmt"
+	"net"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
 )
 
 // RedisConfigMaps returns the ConfigMaps of the Redis component.
@@ -103,3 +108,172 @@ func RedisStatefulSet(adapter gitlab.Adapter, template helm.Template) client.Obj
 
 	return results[0]
 }
+
+// RedisConnection describes the connection settings of a Redis instance that
+// GitLab uses, as they are specified in the values.
+type RedisConnection struct {
+	// Instance is `default` for the global Redis settings, or the name of
+	// the Redis subqueue.
+	Instance string
+
+	// Host is the address of the Redis server or, when Sentinels are used,
+	// the name of the master group.
+	Host string
+	Port int
+	TLS  bool
+
+	Username string
+
+	// PasswordSecret and PasswordKey address the password of the instance.
+	// They are empty when authentication is disabled.
+	PasswordSecret string
+	PasswordKey    string
+
+	// Sentinels are the `host:port` addresses of the Sentinels.
+	Sentinels []string
+
+	// SentinelPasswordSecret and SentinelPasswordKey address the password of
+	// the Sentinels. They are empty when Sentinel authentication is disabled.
+	SentinelPasswordSecret string
+	SentinelPasswordKey    string
+}
+
+// ExternalRedisConnections returns the connection settings of all external
+// Redis instances, including the configured subqueues. The global Redis
+// instance is only included when the bundled Redis is disabled.
+func ExternalRedisConnections(adapter gitlab.Adapter) []RedisConnection {
+	values := adapter.Values()
+
+	defaultSecret := values.GetString("global.redis.auth.secret",
+		values.GetString("global.redis.password.secret",
+			fmt.Sprintf("%s-%s-secret", adapter.ReleaseName(), RedisComponentName(adapter))))
+	defaultKey := values.GetString("global.redis.auth.key",
+		values.GetString("global.redis.password.key", "secret"))
+	defaultAuth := values.GetBool("global.redis.auth.enabled",
+		values.GetBool("global.redis.password.enabled", true))
+
+	result := []RedisConnection{}
+
+	if !adapter.WantsComponent(component.Redis) {
+		connection := redisConnection(adapter, "default", "global.redis")
+
+		if defaultAuth {
+			connection.PasswordSecret = defaultSecret
+			connection.PasswordKey = defaultKey
+		}
+
+		result = append(result, connection)
+	}
+
+	for _, subqueue := range RedisSubqueues() {
+		prefix := fmt.Sprintf("global.redis.%s", subqueue)
+
+		if values.GetString(prefix+".host") == "" {
+			continue
+		}
+
+		connection := redisConnection(adapter, subqueue, prefix)
+
+		if values.GetBool(prefix+".password.enabled", true) {
+			connection.PasswordSecret = values.GetString(prefix+".password.secret", defaultSecret)
+			connection.PasswordKey = values.GetString(prefix+".password.key", defaultKey)
+		}
+
+		result = append(result, connection)
+	}
+
+	return result
+}
+
+// BundledRedisConnection returns the connection settings of the bundled
+// Redis.
+func BundledRedisConnection(adapter gitlab.Adapter, template helm.Template) RedisConnection {
+	values := adapter.Values()
+
+	connection := RedisConnection{
+		Instance:       "default",
+		Port:           6379,
+		PasswordSecret: values.GetString("global.redis.auth.secret", fmt.Sprintf("%s-%s-secret", adapter.ReleaseName(), RedisComponentName(adapter))),
+		PasswordKey:    values.GetString("global.redis.auth.key", "secret"),
+	}
+
+	if service := RedisMasterService(adapter, template); service != nil {
+		connection.Host = fmt.Sprintf("%s.%s.svc", service.GetName(), adapter.Name().Namespace)
+	}
+
+	return connection
+}
+
+// RedisMinimumVersion returns the minimum version of Redis that the GitLab
+// version of the Chart requires.
+func RedisMinimumVersion(adapter gitlab.Adapter) string {
+	switch {
+	case IsChartVersionOlderThan(adapter.DesiredVersion(), ChartVersion7):
+		return "5.0.0"
+	case IsChartVersionOlderThan(adapter.DesiredVersion(), ChartVersion8):
+		return "6.0.0"
+	default:
+		return "6.2.0"
+	}
+}
+
+func redisConnection(adapter gitlab.Adapter, instance, prefix string) RedisConnection {
+	values := adapter.Values()
+
+	port, err := strconv.Atoi(values.GetString(prefix+".port", "6379"))
+	if err != nil {
+		port = 6379
+	}
+
+	connection := RedisConnection{
+		Instance:  instance,
+		Host:      values.GetString(prefix + ".host"),
+		Port:      port,
+		TLS:       values.GetString(prefix+".scheme", "redis") == "rediss",
+		Username:  values.GetString(prefix + ".user"),
+		Sentinels: redisSentinels(values, prefix+".sentinels"),
+	}
+
+	if len(connection.Sentinels) > 0 && values.GetBool("global.redis.sentinelAuth.enabled") {
+		connection.SentinelPasswordSecret = values.GetString("global.redis.sentinelAuth.secret",
+			fmt.Sprintf("%s-redis-sentinel-secret", adapter.ReleaseName()))
+		connection.SentinelPasswordKey = values.GetString("global.redis.sentinelAuth.key", "password")
+	}
+
+	return connection
+}
+
+func redisSentinels(values support.Values, key string) []string {
+	value, err := values.GetValue(key)
+	if err != nil {
+		return nil
+	}
+
+	sentinels, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := []string{}
+
+	for _, sentinel := range sentinels {
+		settings, ok := sentinel.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		host, _ := settings["host"].(string)
+		if host == "" {
+			continue
+		}
+
+		port := "26379"
+		if p, ok := settings["port"]; ok && p != nil {
+			port = fmt.Sprint(p)
+		}
+
+		result = append(result, net.JoinHostPort(host, port))
+	}
+
+	return result
+}
@@ -0,0 +1,280 @@
+package gitlab
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/strings/slices"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+)
+
+const (
+	// RouteAPIVersion is the API version of OpenShift Routes.
+	RouteAPIVersion = "route.openshift.io/v1"
+
+	// RouteTerminationAnnotation selects the TLS termination of the Routes of
+	// an Ingress. It is the annotation that OpenShift uses when it translates
+	// Ingresses into Routes.
+	RouteTerminationAnnotation = "route.openshift.io/termination"
+
+	// RouteDestinationCAAnnotation names the Secret that holds the certificate
+	// authority of the backend of re-encrypted Routes.
+	RouteDestinationCAAnnotation = "route.openshift.io/destination-ca-certificate-secret"
+
+	routeTimeoutAnnotation         = "haproxy.router.openshift.io/timeout"
+	lastAppliedAnnotation          = "kubectl.kubernetes.io/last-applied-configuration"
+	nginxBackendProtocolAnnotation = "nginx.ingress.kubernetes.io/backend-protocol"
+
+	routeTerminationEdge      = "edge"
+	routeTerminationReencrypt = "reencrypt"
+
+	// wildcardRouteHostPrefix replaces the wildcard of a host. OpenShift
+	// Routes with the Subdomain wildcard policy match the subdomains of the
+	// parent domain of their host.
+	wildcardRouteHostPrefix = "wildcard"
+)
+
+// nginxTimeoutAnnotations are the timeouts of the NGINX Ingress Controller
+// that map to the timeout of the OpenShift Router.
+var nginxTimeoutAnnotations = []string{
+	"nginx.ingress.kubernetes.io/proxy-read-timeout",
+	"nginx.ingress.kubernetes.io/proxy-send-timeout",
+}
+
+// RouteCertificate is the content of a TLS Secret that is embedded in Routes.
+type RouteCertificate struct {
+	Certificate   string
+	Key           string
+	CACertificate string
+}
+
+// NewRouteCertificate reads the certificate, the key and the optional
+// certificate authority of a TLS Secret.
+func NewRouteCertificate(secret *corev1.Secret) RouteCertificate {
+	return RouteCertificate{
+		Certificate:   string(secret.Data[corev1.TLSCertKey]),
+		Key:           string(secret.Data[corev1.TLSPrivateKeyKey]),
+		CACertificate: string(secret.Data["ca.crt"]),
+	}
+}
+
+// IngressRouteSecrets returns the names of the Secrets that the Routes of the
+// Ingress embed.
+func IngressRouteSecrets(ingress *networkingv1.Ingress) []string {
+	secrets := []string{}
+
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName != "" {
+			secrets = append(secrets, tls.SecretName)
+		}
+	}
+
+	if secret := ingress.GetAnnotations()[RouteDestinationCAAnnotation]; secret != "" {
+		secrets = append(secrets, secret)
+	}
+
+	return secrets
+}
+
+// IngressRoutes converts the Ingress into OpenShift Routes, one for each path
+// of its rules. The Routes keep the labels and annotations of the Ingress.
+//
+// The TLS termination is `edge`, unless the backend expects HTTPS, in which
+// case it is `reencrypt`. The route.openshift.io/termination annotation
+// overrides it. The certificates of the TLS Secrets are embedded in the
+// Routes. When a Secret is missing from certificates, the Route uses the
+// default certificate of the OpenShift Router. The read and send timeouts of
+// NGINX are mapped to the timeout of the Router.
+func IngressRoutes(ingress *networkingv1.Ingress, template helm.Template, certificates map[string]RouteCertificate) []*unstructured.Unstructured {
+	routes := []*unstructured.Unstructured{}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+
+			name := ingress.Name
+			if len(routes) > 0 {
+				name = fmt.Sprintf("%s-%d", ingress.Name, len(routes))
+			}
+
+			routes = append(routes, newRoute(ingress, name, rule.Host, path, template, certificates))
+		}
+	}
+
+	return routes
+}
+
+func newRoute(ingress *networkingv1.Ingress, name, host string, path networkingv1.HTTPIngressPath, template helm.Template, certificates map[string]RouteCertificate) *unstructured.Unstructured {
+	backend := path.Backend.Service
+
+	spec := map[string]interface{}{
+		"host": host,
+		"to": map[string]interface{}{
+			"kind":   ServiceKind,
+			"name":   backend.Name,
+			"weight": int64(100),
+		},
+		"port": map[string]interface{}{
+			"targetPort": routeTargetPort(template, backend),
+		},
+		"wildcardPolicy": "None",
+	}
+
+	if strings.HasPrefix(host, "*.") {
+		spec["host"] = wildcardRouteHostPrefix + strings.TrimPrefix(host, "*")
+		spec["wildcardPolicy"] = "Subdomain"
+	}
+
+	if path.Path != "" && path.Path != "/" {
+		spec["path"] = path.Path
+	}
+
+	if tls := routeTLS(ingress, host, certificates); tls != nil {
+		spec["tls"] = tls
+	}
+
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+
+	route.SetAPIVersion(RouteAPIVersion)
+	route.SetKind(RouteKind)
+	route.SetName(name)
+	route.SetNamespace(ingress.Namespace)
+	route.SetLabels(ingress.GetLabels())
+	route.SetAnnotations(routeAnnotations(ingress))
+
+	return route
+}
+
+// routeTargetPort returns the name of the port of the backend Service, which
+// Routes resolve to the port of the endpoints. The port number of an Ingress
+// backend is the port of the Service, which can differ from the port of the
+// endpoints.
+func routeTargetPort(template helm.Template, backend *networkingv1.IngressServiceBackend) interface{} {
+	if backend.Port.Name != "" {
+		return backend.Port.Name
+	}
+
+	port := int64(backend.Port.Number)
+
+	service, ok := template.Query().ObjectByKindAndName(ServiceKind, backend.Name).(*corev1.Service)
+	if !ok {
+		return port
+	}
+
+	for _, servicePort := range service.Spec.Ports {
+		if int64(servicePort.Port) != port {
+			continue
+		}
+
+		switch {
+		case servicePort.Name != "":
+			return servicePort.Name
+		case servicePort.TargetPort.IntValue() != 0:
+			return int64(servicePort.TargetPort.IntValue())
+		}
+	}
+
+	return port
+}
+
+func routeTLS(ingress *networkingv1.Ingress, host string, certificates map[string]RouteCertificate) map[string]interface{} {
+	for _, tls := range ingress.Spec.TLS {
+		if !slices.Contains(tls.Hosts, host) {
+			continue
+		}
+
+		termination := routeTermination(ingress)
+
+		spec := map[string]interface{}{
+			"termination":                   termination,
+			"insecureEdgeTerminationPolicy": "Redirect",
+		}
+
+		if certificate, ok := certificates[tls.SecretName]; ok && certificate.Certificate != "" {
+			spec["certificate"] = certificate.Certificate
+			spec["key"] = certificate.Key
+
+			if certificate.CACertificate != "" {
+				spec["caCertificate"] = certificate.CACertificate
+			}
+		}
+
+		destinationCA := ingress.GetAnnotations()[RouteDestinationCAAnnotation]
+		if certificate, ok := certificates[destinationCA]; ok && termination == routeTerminationReencrypt {
+			spec["destinationCACertificate"] = certificate.Certificate
+		}
+
+		return spec
+	}
+
+	return nil
+}
+
+func routeTermination(ingress *networkingv1.Ingress) string {
+	annotations := ingress.GetAnnotations()
+
+	if termination := annotations[RouteTerminationAnnotation]; termination != "" {
+		return termination
+	}
+
+	switch strings.ToUpper(annotations[nginxBackendProtocolAnnotation]) {
+	case "HTTPS", "GRPCS":
+		return routeTerminationReencrypt
+	default:
+		return routeTerminationEdge
+	}
+}
+
+func routeAnnotations(ingress *networkingv1.Ingress) map[string]string {
+	annotations := ingressAnnotations(ingress)
+
+	if _, ok := annotations[routeTimeoutAnnotation]; !ok {
+		if timeout := nginxTimeout(annotations); timeout > 0 {
+			annotations[routeTimeoutAnnotation] = fmt.Sprintf("%ds", timeout)
+		}
+	}
+
+	return annotations
+}
+
+// ingressAnnotations returns the annotations of the Ingress, except the last
+// applied configuration.
+func ingressAnnotations(ingress *networkingv1.Ingress) map[string]string {
+	annotations := map[string]string{}
+
+	for key, value := range ingress.GetAnnotations() {
+		if key != lastAppliedAnnotation {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
+
+// nginxTimeout returns the longest timeout of NGINX in seconds.
+func nginxTimeout(annotations map[string]string) int {
+	timeout := 0
+
+	for _, key := range nginxTimeoutAnnotations {
+		if seconds, err := strconv.Atoi(annotations[key]); err == nil && seconds > timeout {
+			timeout = seconds
+		}
+	}
+
+	return timeout
+}
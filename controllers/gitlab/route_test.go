@@ -0,0 +1,136 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("OpenShift Routes", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	When("the networking mode is Route", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue("global.ingress.tls.secretName", "gitlab-tls")
+		_ = chartValues.SetValue("global.pages.enabled", true)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.Networking = &gitlabv1beta1.NetworkingSpec{Mode: "Route"}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should not deploy the NGINX Ingress Controller", func() {
+			Expect(adapter.WantsComponent(component.NginxIngress)).To(BeFalse())
+		})
+
+		It("Should convert the Webservice Ingress into an edge Route", func() {
+			ingress, ok := WebserviceIngresses(template)[0].(*networkingv1.Ingress)
+			Expect(ok).To(BeTrue())
+
+			routes := IngressRoutes(ingress, template, map[string]RouteCertificate{
+				"gitlab-tls": {Certificate: "certificate", Key: "key"},
+			})
+			Expect(routes).To(HaveLen(1))
+
+			route := routes[0]
+			Expect(route.GetKind()).To(Equal(RouteKind))
+			Expect(route.GetName()).To(Equal(ingress.GetName()))
+			Expect(route.GetLabels()).To(Equal(ingress.GetLabels()))
+
+			host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+			Expect(host).To(Equal("gitlab.example.com"))
+
+			service, _, _ := unstructured.NestedString(route.Object, "spec", "to", "name")
+			Expect(service).To(Equal(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name))
+
+			tls, _, _ := unstructured.NestedStringMap(route.Object, "spec", "tls")
+			Expect(tls).To(HaveKeyWithValue("termination", "edge"))
+			Expect(tls).To(HaveKeyWithValue("certificate", "certificate"))
+			Expect(tls).To(HaveKeyWithValue("key", "key"))
+		})
+
+		It("Should convert the Pages Ingress into a wildcard Route", func() {
+			ingress, ok := PagesIngress(template).(*networkingv1.Ingress)
+			Expect(ok).To(BeTrue())
+
+			routes := IngressRoutes(ingress, template, nil)
+			Expect(routes).To(HaveLen(1))
+
+			host, _, _ := unstructured.NestedString(routes[0].Object, "spec", "host")
+			policy, _, _ := unstructured.NestedString(routes[0].Object, "spec", "wildcardPolicy")
+			Expect(host).To(Equal("wildcard.pages.example.com"))
+			Expect(policy).To(Equal("Subdomain"))
+		})
+	})
+
+	When("the Ingress annotations configure the backend", func() {
+		ingress := &networkingv1.Ingress{
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{{Hosts: []string{"kas.example.com"}, SecretName: "kas-tls"}},
+				Rules: []networkingv1.IngressRule{{
+					Host: "kas.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path: "/k8s-proxy",
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "kas",
+										Port: networkingv1.ServiceBackendPort{Name: "tcp-external-api"},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		ingress.SetName("kas")
+		ingress.SetAnnotations(map[string]string{
+			nginxBackendProtocolAnnotation:                   "HTTPS",
+			"nginx.ingress.kubernetes.io/proxy-read-timeout": "3600",
+			RouteDestinationCAAnnotation:                     "kas-ca",
+			lastAppliedAnnotation:                            "{}",
+		})
+
+		routes := IngressRoutes(ingress, nil, map[string]RouteCertificate{
+			"kas-ca": {Certificate: "ca"},
+		})
+
+		It("Should re-encrypt the traffic to the backend", func() {
+			Expect(routes).To(HaveLen(1))
+
+			tls, _, _ := unstructured.NestedStringMap(routes[0].Object, "spec", "tls")
+			Expect(tls).To(HaveKeyWithValue("termination", "reencrypt"))
+			Expect(tls).To(HaveKeyWithValue("destinationCACertificate", "ca"))
+			Expect(tls).NotTo(HaveKey("certificate"))
+		})
+
+		It("Should keep the path, the port and the annotations", func() {
+			path, _, _ := unstructured.NestedString(routes[0].Object, "spec", "path")
+			port, _, _ := unstructured.NestedString(routes[0].Object, "spec", "port", "targetPort")
+			Expect(path).To(Equal("/k8s-proxy"))
+			Expect(port).To(Equal("tcp-external-api"))
+
+			annotations := routes[0].GetAnnotations()
+			Expect(annotations).To(HaveKeyWithValue(routeTimeoutAnnotation, "3600s"))
+			Expect(annotations).To(HaveKeyWithValue(nginxBackendProtocolAnnotation, "HTTPS"))
+			Expect(annotations).NotTo(HaveKey(lastAppliedAnnotation))
+		})
+	})
+})
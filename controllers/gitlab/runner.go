@@ -0,0 +1,487 @@
+package gitlab
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+const (
+	// RunnerComponentName is the common name of GitLab Runner.
+	RunnerComponentName = "gitlab-runner"
+
+	// RunnerTokenKey is the key of the runner authentication token in the
+	// token Secret, the same as in the GitLab Runner Chart.
+	RunnerTokenKey = "runner-token"
+
+	runnerImageRepository   = "registry.gitlab.com/gitlab-org/gitlab-runner"
+	runnerTokenPrefix       = "glrt-"
+	runnerTokenPlaceholder  = "@RUNNER_TOKEN@"
+	runnerConfigPath        = "/etc/gitlab-runner"
+	runnerCertificatesPath  = "/etc/gitlab-runner/certs"
+	runnerCAFile            = "/etc/gitlab-runner/ca.crt"
+	runnerRegistrationLimit = 2
+	runnerUser              = 100
+	runnerGroup             = 65533
+)
+
+// runnerRegistrationScript creates an instance runner with the generated
+// authentication token, or updates the runner that already uses it, and
+// reports the ID of the runner in the termination message of the container.
+const runnerRegistrationScript = `
+token = ENV.fetch('RUNNER_TOKEN')
+tags = ENV.fetch('RUNNER_TAGS', '').split(',')
+
+runner = Ci::Runner.find_by_token(token) || Ci::Runner.new(
+  runner_type: :instance_type,
+  registration_type: :authenticated_user,
+  token: token)
+
+runner.description = ENV.fetch('RUNNER_DESCRIPTION')
+runner.tag_list = tags
+runner.run_untagged = tags.empty? || ENV['RUNNER_RUN_UNTAGGED'] == 'true'
+runner.save!
+
+File.write('/dev/termination-log', { id: runner.id }.to_json)
+`
+
+// runnerUnregistrationScript deletes the runner that uses the authentication
+// token from GitLab. It succeeds when the runner does not exist anymore.
+const runnerUnregistrationScript = `
+runner = Ci::Runner.find_by_token(ENV.fetch('RUNNER_TOKEN'))
+runner&.destroy!
+
+File.write('/dev/termination-log', { id: runner&.id }.to_json)
+`
+
+// runnerConfigTemplate is the configuration of GitLab Runner. The runner
+// authentication token is substituted when the runner starts, so that it is
+// not stored in the ConfigMap.
+const runnerConfigTemplate = `concurrent = %d
+check_interval = 3
+
+[[runners]]
+  name = %q
+  url = %q
+  token = %q
+  executor = "kubernetes"
+%s
+  [runners.kubernetes]
+    namespace = %q
+    image = %q
+    privileged = %t
+    service_account = %q
+`
+
+// Runner describes a GitLab Runner of a GitLab instance.
+type Runner struct {
+	Name      string
+	Namespace string
+
+	// Version is the version of GitLab Runner and Image is its image.
+	Version string
+	Image   string
+
+	Concurrent  int
+	Tags        []string
+	RunUntagged bool
+
+	// BuildImage is the default image of the jobs, and Privileged runs the
+	// containers of the jobs in privileged mode.
+	BuildImage string
+	Privileged bool
+
+	// TokenSecret and TokenKey address the runner authentication token.
+	TokenSecret string
+	TokenKey    string
+
+	// Labels are added to all resources of the runner.
+	Labels map[string]string
+}
+
+// RunnerVersion returns the version of GitLab Runner that the GitLab Chart of
+// the instance bundles. It falls back to the first patch release of the GitLab
+// version when the Chart does not bundle GitLab Runner.
+func RunnerVersion(adapter gitlab.Adapter) string {
+	charts, err := adapter.Charts()
+	if err != nil || charts.Empty() {
+		return ""
+	}
+
+	chart := charts.First()
+
+	for _, dependency := range chart.Dependencies() {
+		if dependency.Name() == RunnerComponentName && dependency.Metadata.AppVersion != "" {
+			return strings.TrimPrefix(dependency.Metadata.AppVersion, "v")
+		}
+	}
+
+	version, err := semver.NewVersion(chart.Metadata.AppVersion)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d.%d.0", version.Major(), version.Minor())
+}
+
+// RunnerImage returns the Alpine image of the GitLab Runner version.
+func RunnerImage(version string) string {
+	return fmt.Sprintf("%s:alpine-v%s", runnerImageRepository, strings.TrimPrefix(version, "v"))
+}
+
+// InstanceURL returns the external URL of the GitLab instance.
+func InstanceURL(adapter gitlab.Adapter) string {
+	values := adapter.Values()
+
+	scheme := "https"
+	if !values.GetBool("global.hosts.gitlab.https", values.GetBool("global.hosts.https", true)) {
+		scheme = "http"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, Hostname(adapter, "gitlab"))
+}
+
+// RunnerCertificateAuthorities returns the sources of the certificate
+// authorities that the runner trusts in addition to the system certificate
+// authorities: the certificate authority of the self-signed certificates of
+// the instance and the custom certificate authorities of the instance,
+// including the bundle of the GitLab resource. It returns nil when there are
+// none.
+func RunnerCertificateAuthorities(adapter gitlab.Adapter, template helm.Template) []corev1.VolumeProjection {
+	result := []corev1.VolumeProjection{}
+
+	if job, err := SelfSignedCertsJob(adapter, template); err == nil && job != nil {
+		result = append(result, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: SelfSignedCertificateSecretName(adapter) + "-ca"},
+				Items:                []corev1.KeyToPath{{Key: "cfssl_ca", Path: "self-signed-ca.crt"}},
+			},
+		})
+	}
+
+	for _, source := range CustomCertificateAuthorities(adapter) {
+		items := []corev1.KeyToPath{}
+		for _, key := range source.Keys {
+			items = append(items, corev1.KeyToPath{Key: key, Path: fmt.Sprintf("%s-%s", source.Name, key)})
+		}
+
+		reference := corev1.LocalObjectReference{Name: source.Name}
+
+		if source.Kind == SecretKind {
+			result = append(result, corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{LocalObjectReference: reference, Items: items},
+			})
+		} else {
+			result = append(result, corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: reference, Items: items},
+			})
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+// RunnerName returns the name of the resources of the runner.
+func RunnerName(runner Runner) string {
+	return fmt.Sprintf("%s-%s", runner.Name, RunnerComponentName)
+}
+
+// RunnerTokenSecretName returns the name of the Secret that holds the
+// authentication token of a runner that the Operator creates.
+func RunnerTokenSecretName(runner Runner) string {
+	return RunnerName(runner) + "-token"
+}
+
+// RunnerTokenSecret returns a Secret with a new runner authentication token.
+func RunnerTokenSecret(runner Runner) (*corev1.Secret, error) {
+	token, err := internal.RandomString(internal.AlphanumericCharset, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       SecretKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunnerTokenSecretName(runner),
+			Namespace: runner.Namespace,
+			Labels:    runner.Labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			RunnerTokenKey: []byte(runnerTokenPrefix + token),
+		},
+	}, nil
+}
+
+// RunnerRegistrationJob returns the Job that creates the runner in GitLab
+// with the Rails runner of the Toolbox. A new Job is used when the tags of the
+// runner change.
+func RunnerRegistrationJob(adapter gitlab.Adapter, template helm.Template, runner Runner) (*batchv1.Job, error) {
+	tags := strings.Join(runner.Tags, ",")
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s/%t", tags, runner.RunUntagged))))
+
+	name, err := support.NameWithHashSuffix(RunnerName(runner)+"-registration", hash, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := railsRunnerJob(adapter, template, name, runnerRegistrationScript, runnerRegistrationLimit,
+		secretEnvVar("RUNNER_TOKEN", runner.TokenSecret, runner.TokenKey),
+		corev1.EnvVar{Name: "RUNNER_DESCRIPTION", Value: RunnerName(runner)},
+		corev1.EnvVar{Name: "RUNNER_TAGS", Value: tags},
+		corev1.EnvVar{Name: "RUNNER_RUN_UNTAGGED", Value: fmt.Sprintf("%t", runner.RunUntagged)})
+	if err != nil {
+		return nil, err
+	}
+
+	job.Labels = runner.Labels
+
+	return job, nil
+}
+
+// RunnerUnregistrationJob returns the Job that deletes the runner with the ID
+// from GitLab with the Rails runner of the Toolbox. It uses the authentication
+// token that the Operator created.
+func RunnerUnregistrationJob(adapter gitlab.Adapter, template helm.Template, runner Runner, id int64) (*batchv1.Job, error) {
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%d", id))))
+
+	name, err := support.NameWithHashSuffix(RunnerName(runner)+"-unregistration", hash, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := railsRunnerJob(adapter, template, name, runnerUnregistrationScript, runnerRegistrationLimit,
+		secretEnvVar("RUNNER_TOKEN", RunnerTokenSecretName(runner), RunnerTokenKey))
+	if err != nil {
+		return nil, err
+	}
+
+	job.Labels = runner.Labels
+
+	return job, nil
+}
+
+// RunnerConfigMap returns the ConfigMap with the configuration of the runner.
+func RunnerConfigMap(adapter gitlab.Adapter, template helm.Template, runner Runner) *corev1.ConfigMap {
+	tls := ""
+	if len(RunnerCertificateAuthorities(adapter, template)) > 0 {
+		tls = fmt.Sprintf("  tls-ca-file = %q\n", runnerCAFile)
+	}
+
+	config := fmt.Sprintf(runnerConfigTemplate, runner.Concurrent, RunnerName(runner), InstanceURL(adapter),
+		runnerTokenPlaceholder, tls, runner.Namespace, runner.BuildImage, runner.Privileged, RunnerName(runner)+"-jobs")
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       ConfigMapKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunnerName(runner),
+			Namespace: runner.Namespace,
+			Labels:    runner.Labels,
+		},
+		Data: map[string]string{
+			"config.toml": config,
+		},
+	}
+}
+
+// RunnerDeployment returns the Deployment of the runner. The runner
+// authentication token is substituted into the configuration when the
+// runner starts, and the trusted certificate authorities are combined into
+// one file.
+func RunnerDeployment(adapter gitlab.Adapter, template helm.Template, runner Runner, configChecksum string) *appsv1.Deployment {
+	authorities := RunnerCertificateAuthorities(adapter, template)
+
+	script := fmt.Sprintf("sed \"s|%s|${RUNNER_TOKEN}|\" /configmap/config.toml > %s/config.toml && exec gitlab-runner run --config %s/config.toml",
+		runnerTokenPlaceholder, runnerConfigPath, runnerConfigPath)
+
+	if len(authorities) > 0 {
+		script = fmt.Sprintf("awk 1 %s/* > %s && %s", runnerCertificatesPath, runnerCAFile, script)
+	}
+
+	container := corev1.Container{
+		Name:    RunnerComponentName,
+		Image:   runner.Image,
+		Command: []string{"/bin/sh", "-c", script},
+		Env: []corev1.EnvVar{
+			secretEnvVar("RUNNER_TOKEN", runner.TokenSecret, runner.TokenKey),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: runnerConfigPath},
+			{Name: "configmap", MountPath: "/configmap", ReadOnly: true},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: pointer.Bool(false),
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name:         "config",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+		{
+			Name: "configmap",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: RunnerName(runner)},
+				},
+			},
+		},
+	}
+
+	if len(authorities) > 0 {
+		container.VolumeMounts = append(container.VolumeMounts,
+			corev1.VolumeMount{Name: "certificates", MountPath: runnerCertificatesPath, ReadOnly: true})
+
+		volumes = append(volumes, corev1.Volume{
+			Name: "certificates",
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{Sources: authorities},
+			},
+		})
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       DeploymentKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunnerName(runner),
+			Namespace: runner.Namespace,
+			Labels:    runner.Labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(1),
+			Selector: &metav1.LabelSelector{MatchLabels: runner.Labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: runner.Labels,
+					Annotations: map[string]string{
+						"checksum/config": configChecksum,
+					},
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: RunnerName(runner),
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: pointer.Bool(true),
+						RunAsUser:    pointer.Int64(runnerUser),
+						FSGroup:      pointer.Int64(runnerGroup),
+					},
+					Containers: []corev1.Container{container},
+					Volumes:    volumes,
+				},
+			},
+		},
+	}
+}
+
+// RunnerServiceAccounts returns the ServiceAccount of the runner, which
+// manages the Pods of the jobs, and the ServiceAccount of the Pods of the
+// jobs, which does not have any permissions.
+func RunnerServiceAccounts(runner Runner) []*corev1.ServiceAccount {
+	result := []*corev1.ServiceAccount{}
+
+	for _, name := range []string{RunnerName(runner), RunnerName(runner) + "-jobs"} {
+		result = append(result, &corev1.ServiceAccount{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ServiceAccount",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: runner.Namespace,
+				Labels:    runner.Labels,
+			},
+		})
+	}
+
+	return result
+}
+
+// RunnerRole returns the Role that allows the Kubernetes executor to run the
+// jobs in the namespace of the runner.
+func RunnerRole(runner Runner) *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "Role",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunnerName(runner),
+			Namespace: runner.Namespace,
+			Labels:    runner.Labels,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods", "secrets", "configmaps", "services"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods/exec", "pods/attach"},
+				Verbs:     []string{"get", "create", "patch", "delete"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods/log"},
+				Verbs:     []string{"get", "list"},
+			},
+		},
+	}
+}
+
+// RunnerRoleBinding returns the RoleBinding of the Role of the runner to its
+// ServiceAccount.
+func RunnerRoleBinding(runner Runner) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "RoleBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunnerName(runner),
+			Namespace: runner.Namespace,
+			Labels:    runner.Labels,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     RunnerName(runner),
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      RunnerName(runner),
+				Namespace: runner.Namespace,
+			},
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("GitLab Runner resources", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	runner := Runner{
+		Name:        "runner",
+		Namespace:   namespace,
+		Version:     "16.11.0",
+		Image:       RunnerImage("16.11.0"),
+		Concurrent:  4,
+		Tags:        []string{"kubernetes", "docker"},
+		BuildImage:  "alpine:latest",
+		TokenSecret: "runner-gitlab-runner-token",
+		TokenKey:    RunnerTokenKey,
+		Labels:      map[string]string{"app.kubernetes.io/instance": "runner-gitlab-runner"},
+	}
+
+	When("The runner is created by the Operator", func() {
+		chartValues := support.Values{}
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should follow the version of the instance", func() {
+			Expect(RunnerVersion(adapter)).NotTo(BeEmpty())
+			Expect(RunnerImage("v16.11.0")).To(Equal("registry.gitlab.com/gitlab-org/gitlab-runner:alpine-v16.11.0"))
+		})
+
+		It("Should create the runner with the Toolbox", func() {
+			job, err := RunnerRegistrationJob(adapter, template, runner)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Name).To(HavePrefix("runner-gitlab-runner-registration-"))
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(
+				HaveField("Name", "RUNNER_TAGS")))
+
+			retagged := runner
+			retagged.Tags = []string{"kubernetes"}
+
+			other, err := RunnerRegistrationJob(adapter, template, retagged)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.Name).NotTo(Equal(job.Name))
+		})
+
+		It("Should delete the runner with the Toolbox", func() {
+			job, err := RunnerUnregistrationJob(adapter, template, runner, 42)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Name).To(HavePrefix("runner-gitlab-runner-unregistration-"))
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(
+				HaveField("ValueFrom.SecretKeyRef.Name", "runner-gitlab-runner-token")))
+		})
+
+		It("Should generate the runner authentication token", func() {
+			secret, err := RunnerTokenSecret(runner)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Name).To(Equal("runner-gitlab-runner-token"))
+			Expect(string(secret.Data[RunnerTokenKey])).To(HavePrefix("glrt-"))
+		})
+
+		It("Should configure the Kubernetes executor", func() {
+			configMap := RunnerConfigMap(adapter, template, runner)
+			config := configMap.Data["config.toml"]
+
+			Expect(config).To(ContainSubstring("concurrent = 4"))
+			Expect(config).To(ContainSubstring(`executor = "kubernetes"`))
+			Expect(config).To(ContainSubstring(`token = "@RUNNER_TOKEN@"`))
+			Expect(config).To(ContainSubstring(`url = "https://gitlab.`))
+			Expect(config).To(ContainSubstring(`namespace = "` + namespace + `"`))
+		})
+
+		It("Should deploy the runner", func() {
+			deployment := RunnerDeployment(adapter, template, runner, "checksum")
+			container := deployment.Spec.Template.Spec.Containers[0]
+
+			Expect(deployment.Name).To(Equal("runner-gitlab-runner"))
+			Expect(deployment.Spec.Template.Spec.ServiceAccountName).To(Equal("runner-gitlab-runner"))
+			Expect(container.Image).To(Equal(runner.Image))
+			Expect(container.Env[0].ValueFrom.SecretKeyRef.Name).To(Equal(runner.TokenSecret))
+		})
+
+		It("Should allow the executor to run the jobs", func() {
+			Expect(RunnerServiceAccounts(runner)).To(HaveLen(2))
+			Expect(RunnerRole(runner).Rules).NotTo(BeEmpty())
+			Expect(RunnerRoleBinding(runner).RoleRef.Name).To(Equal("runner-gitlab-runner"))
+		})
+	})
+
+	When("The instance uses custom certificate authorities", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.certificates.customCAs", []interface{}{
+			map[string]interface{}{"secret": "custom-ca"},
+			map[string]interface{}{"configMap": "corporate-ca", "keys": []interface{}{"trust-bundle.pem"}},
+		})
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should trust the custom certificate authorities", func() {
+			config := RunnerConfigMap(adapter, template, runner).Data["config.toml"]
+			Expect(config).To(ContainSubstring(`tls-ca-file = "/etc/gitlab-runner/ca.crt"`))
+
+			sources := []string{}
+			for _, volume := range RunnerDeployment(adapter, template, runner, "checksum").Spec.Template.Spec.Volumes {
+				if volume.Name != "certificates" {
+					continue
+				}
+
+				for _, source := range volume.Projected.Sources {
+					if source.Secret != nil {
+						sources = append(sources, source.Secret.Name)
+					}
+
+					if source.ConfigMap != nil {
+						sources = append(sources, source.ConfigMap.Name)
+					}
+				}
+			}
+
+			Expect(sources).To(ContainElements("custom-ca", "corporate-ca"))
+		})
+	})
+})
@@ -0,0 +1,470 @@
+package gitlab
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+)
+
+const (
+	// Kinds of the generated Secrets.
+	GitalySecret    = gitlab.GitalyTokenSecret
+	WorkhorseSecret = "Workhorse"
+	ShellSecret     = "Shell"
+	KASSecret       = "KAS"
+	RegistrySecret  = "Registry"
+
+	// selfSignedCertificateValidity matches the ten years of the certificates
+	// that the shared secrets Jobs generate.
+	selfSignedCertificateValidity = 10 * 365 * 24 * time.Hour
+)
+
+// GeneratedSecret describes a Secret that the shared secrets Job generates and
+// how the value of its key is generated.
+type GeneratedSecret struct {
+	// Name is the name of the Kubernetes Secret.
+	Name string
+
+	// Key is the key of the Secret that holds the generated value.
+	Key string
+
+	// Generate returns a new value for the key.
+	Generate func() (string, error)
+}
+
+// RotatableSecret returns the generated Secret of the specified kind. It uses
+// the same Secret name, key, and value format as the shared secrets Job.
+// Only the Secrets that have RotationServers can be rotated automatically.
+func RotatableSecret(adapter gitlab.Adapter, kind string) (GeneratedSecret, error) {
+	values := adapter.Values()
+	release := adapter.ReleaseName()
+
+	switch kind {
+	case GitalySecret:
+		return GeneratedSecret{
+			Name:     values.GetString("global.gitaly.authToken.secret", fmt.Sprintf("%s-gitaly-secret", release)),
+			Key:      values.GetString("global.gitaly.authToken.key", "token"),
+			Generate: randomString(internal.AlphanumericCharset, 64),
+		}, nil
+	case WorkhorseSecret:
+		return GeneratedSecret{
+			Name:     values.GetString("global.workhorse.secret", fmt.Sprintf("%s-gitlab-workhorse-secret", release)),
+			Key:      values.GetString("global.workhorse.key", "shared_secret"),
+			Generate: randomBase64String(internal.AlphanumericCharset, 32),
+		}, nil
+	case ShellSecret:
+		return GeneratedSecret{
+			Name:     values.GetString("global.shell.authToken.secret", fmt.Sprintf("%s-gitlab-shell-secret", release)),
+			Key:      values.GetString("global.shell.authToken.key", "secret"),
+			Generate: randomString(internal.AlphanumericCharset, 64),
+		}, nil
+	case KASSecret:
+		return GeneratedSecret{
+			Name:     values.GetString("global.appConfig.gitlab_kas.secret", fmt.Sprintf("%s-gitlab-kas-secret", release)),
+			Key:      values.GetString("global.appConfig.gitlab_kas.key", "kas_shared_secret"),
+			Generate: randomBase64String(internal.AlphanumericCharset, 32),
+		}, nil
+	case RegistrySecret:
+		return GeneratedSecret{
+			Name:     values.GetString("global.registry.httpSecret.secret", fmt.Sprintf("%s-registry-httpsecret", release)),
+			Key:      values.GetString("global.registry.httpSecret.key", "secret"),
+			Generate: randomBase64String(internal.LowerAlphanumericCharset, 128),
+		}, nil
+	default:
+		return GeneratedSecret{}, fmt.Errorf("unknown generated secret: %s", kind)
+	}
+}
+
+// RotationServers returns the app labels of the components that verify the
+// generated Secret of the specified kind. When the Secret is rotated they are
+// rolled before the other components that use it and accept the previous
+// value until the rotation completes.
+//
+// It returns nil when the servers can not accept both values. These Secrets
+// are not rotated automatically because requests between Pods with different
+// values fail while they roll out.
+func RotationServers(kind string) []string {
+	switch kind {
+	case GitalySecret:
+		return []string{GitalyComponentName, PraefectComponentName}
+	default:
+		return nil
+	}
+}
+
+// IsGeneratedSecret returns true when the Secret was generated by the shared
+// secrets Job or by the Operator. Secrets that the user provides do not have
+// the labels of the shared secrets.
+func IsGeneratedSecret(adapter gitlab.Adapter, secret *corev1.Secret) bool {
+	return secret.Labels[appLabel] == SharedSecretsComponentName &&
+		secret.Labels["release"] == adapter.ReleaseName()
+}
+
+// SharedSecret describes a Secret that the shared secrets Job generates.
+type SharedSecret struct {
+	// Name is the name of the Kubernetes Secret.
+	Name string
+
+	// Generate returns the data of a new Secret.
+	Generate func() (map[string][]byte, error)
+}
+
+// SharedSecrets returns the Secrets that the shared secrets Job generates for
+// the configuration of the GitLab resource. Each Secret has the same name,
+// keys, and value format as the one that the Job generates.
+func SharedSecrets(adapter gitlab.Adapter) ([]SharedSecret, error) {
+	values := adapter.Values()
+	release := adapter.ReleaseName()
+
+	kinds := []string{GitalySecret, WorkhorseSecret, ShellSecret}
+
+	if adapter.WantsComponent(component.GitLabKAS) {
+		kinds = append(kinds, KASSecret)
+	}
+
+	if adapter.WantsComponent(component.Registry) {
+		kinds = append(kinds, RegistrySecret)
+	}
+
+	result := []SharedSecret{}
+
+	for _, kind := range kinds {
+		secret, err := RotatableSecret(adapter, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, SharedSecret{
+			Name:     secret.Name,
+			Generate: singleKey(secret.Key, secret.Generate),
+		})
+	}
+
+	result = append(result,
+		SharedSecret{
+			Name: values.GetString("global.initialRootPassword.secret", fmt.Sprintf("%s-gitlab-initial-root-password", release)),
+			Generate: singleKey(values.GetString("global.initialRootPassword.key", "password"),
+				randomString(internal.AlphanumericCharset, 64)),
+		},
+		SharedSecret{
+			Name:     values.GetString("global.railsSecrets.secret", fmt.Sprintf("%s-rails-secret", release)),
+			Generate: railsSecrets,
+		},
+		SharedSecret{
+			Name:     values.GetString("global.shell.hostKeys.secret", fmt.Sprintf("%s-gitlab-shell-host-keys", release)),
+			Generate: internal.SSHHostKeys,
+		},
+		SharedSecret{
+			Name: fmt.Sprintf("%s-gitlab-runner-secret", release),
+			Generate: func() (map[string][]byte, error) {
+				token, err := internal.RandomString(internal.AlphanumericCharset, 64)
+
+				return map[string][]byte{
+					"runner-registration-token": []byte(token),
+					"runner-token":              {},
+				}, err
+			},
+		})
+
+	if adapter.WantsComponent(component.Praefect) {
+		dbSecret, dbKey := PraefectDatabaseSecret(adapter)
+
+		result = append(result,
+			SharedSecret{
+				Name: values.GetString("global.praefect.authToken.secret", fmt.Sprintf("%s-praefect-secret", release)),
+				Generate: singleKey(values.GetString("global.praefect.authToken.key", "token"),
+					randomString(internal.AlphanumericCharset, 64)),
+			},
+			SharedSecret{
+				Name:     dbSecret,
+				Generate: singleKey(dbKey, randomString(internal.AlphanumericCharset, 32)),
+			})
+	}
+
+	if adapter.WantsComponent(component.MinIO) {
+		result = append(result, SharedSecret{
+			Name: values.GetString("global.minio.credentials.secret", fmt.Sprintf("%s-minio-secret", release)),
+			Generate: func() (map[string][]byte, error) {
+				return generateKeys(map[string]func() (string, error){
+					"accesskey": randomString(internal.AlphanumericCharset, 64),
+					"secretkey": randomString(internal.AlphanumericCharset, 64),
+				})
+			},
+		})
+	}
+
+	if adapter.WantsComponent(component.Registry) {
+		result = append(result,
+			SharedSecret{
+				Name: values.GetString("global.registry.notificationSecret.secret", fmt.Sprintf("%s-registry-notification", release)),
+				Generate: singleKey(values.GetString("global.registry.notificationSecret.key", "secret"),
+					func() (string, error) {
+						value, err := internal.RandomString(internal.AlphanumericCharset, 32)
+
+						return fmt.Sprintf("[%q]", value), err
+					}),
+			},
+			SharedSecret{
+				Name:     values.GetString("global.registry.certificate.secret", fmt.Sprintf("%s-registry-secret", release)),
+				Generate: registryCertificate,
+			})
+	}
+
+	if adapter.WantsComponent(component.GitLabKAS) {
+		result = append(result, SharedSecret{
+			Name: values.GetString("global.kas.privateApi.secret", fmt.Sprintf("%s-kas-private-api", release)),
+			Generate: singleKey(values.GetString("global.kas.privateApi.key", "kas_private_api_secret"),
+				randomBase64String(internal.AlphanumericCharset, 32)),
+		})
+	}
+
+	if adapter.WantsComponent(component.GitLabPages) {
+		result = append(result, SharedSecret{
+			Name: values.GetString("global.pages.apiSecret.secret", fmt.Sprintf("%s-gitlab-pages-secret", release)),
+			Generate: singleKey(values.GetString("global.pages.apiSecret.key", "shared_secret"),
+				randomBase64String(internal.AlphanumericCharset, 32)),
+		})
+	}
+
+	if adapter.WantsComponent(component.Redis) {
+		result = append(result, SharedSecret{
+			Name: values.GetString("global.redis.auth.secret", fmt.Sprintf("%s-redis-secret", release)),
+			Generate: singleKey(values.GetString("global.redis.auth.key", "secret"),
+				randomString(internal.AlphanumericCharset, 64)),
+		})
+
+		if adapter.RedisMigration() != nil {
+			migrationSecret, migrationKey := RedisMigrationSecret(adapter)
+
+			result = append(result, SharedSecret{
+				Name:     migrationSecret,
+				Generate: singleKey(migrationKey, randomString(internal.AlphanumericCharset, 64)),
+			})
+		}
+	}
+
+	if adapter.WantsComponent(component.PostgreSQL) {
+		result = append(result, bundledPostgresSecret(adapter))
+	}
+
+	return result, nil
+}
+
+// GeoSecrets returns the Secrets of a secondary Geo site that the shared
+// secrets Job of the GitLab Chart does not generate: the passwords of the
+// bundled PostgreSQL, which are separate from the password of the primary
+// database, and the password of the tracking database.
+func GeoSecrets(adapter gitlab.Adapter) []SharedSecret {
+	if !ProvisionsGeoTrackingDatabase(adapter) {
+		return nil
+	}
+
+	tracking := GeoTrackingDatabaseConnection(adapter)
+
+	return []SharedSecret{
+		bundledPostgresSecret(adapter),
+		{
+			Name:     tracking.PasswordSecret,
+			Generate: singleKey(tracking.PasswordKey, randomString(internal.AlphanumericCharset, 64)),
+		},
+	}
+}
+
+func bundledPostgresSecret(adapter gitlab.Adapter) SharedSecret {
+	passwordSecret, passwordKey := BundledPostgresPasswordSecret(adapter)
+
+	return SharedSecret{
+		Name: passwordSecret,
+		Generate: func() (map[string][]byte, error) {
+			return generateKeys(map[string]func() (string, error){
+				passwordKey:                    randomString(internal.AlphanumericCharset, 64),
+				"postgresql-postgres-password": randomString(internal.AlphanumericCharset, 64),
+			})
+		},
+	}
+}
+
+// SelfSignedCertificateSecretName returns the name of the Secret that holds
+// the self-signed wildcard certificate.
+func SelfSignedCertificateSecretName(adapter gitlab.Adapter) string {
+	return fmt.Sprintf("%s-wildcard-tls", adapter.ReleaseName())
+}
+
+// SelfSignedCertificates generates a certificate authority and a wildcard
+// certificate for the domain of the GitLab resource. It returns the same
+// Secrets as the self-signed certificates Job: the wildcard certificate, the
+// certificate authority, and the certificate chain.
+func SelfSignedCertificates(adapter gitlab.Adapter) ([]*corev1.Secret, error) {
+	domain := adapter.Values().GetString("global.hosts.domain", "example.com")
+	name := SelfSignedCertificateSecretName(adapter)
+
+	ca, err := internal.NewCertificateAuthority(fmt.Sprintf("GitLab CA for %s", domain), selfSignedCertificateValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	wildcard, err := ca.IssueCertificate([]string{fmt.Sprintf("*.%s", domain), domain}, selfSignedCertificateValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := map[string][]byte{}
+	for _, host := range []string{"gitlab", "registry", "minio"} {
+		chain[fmt.Sprintf("%s.crt", Hostname(adapter, host))] = ca.CertificatePEM
+	}
+
+	return []*corev1.Secret{
+		newSecret(adapter, name, corev1.SecretTypeTLS, map[string][]byte{
+			corev1.TLSCertKey:       wildcard.CertificatePEM,
+			corev1.TLSPrivateKeyKey: wildcard.PrivateKeyPEM,
+		}),
+		newSecret(adapter, name+"-ca", corev1.SecretTypeOpaque, map[string][]byte{
+			"cfssl_ca": ca.CertificatePEM,
+		}),
+		newSecret(adapter, name+"-chain", corev1.SecretTypeOpaque, chain),
+	}, nil
+}
+
+// Hostname returns the fully qualified hostname of the specified host, for
+// example `gitlab` or `registry`, the same way that the GitLab Chart does.
+func Hostname(adapter gitlab.Adapter, host string) string {
+	values := adapter.Values()
+
+	if name := values.GetString(fmt.Sprintf("global.hosts.%s.name", host)); name != "" {
+		return name
+	}
+
+	if suffix := values.GetString("global.hosts.hostSuffix"); suffix != "" {
+		host = fmt.Sprintf("%s-%s", host, suffix)
+	}
+
+	return fmt.Sprintf("%s.%s", host, values.GetString("global.hosts.domain", "example.com"))
+}
+
+// NewSharedSecret returns a new Secret object with the specified data and the
+// labels of the shared secrets.
+func NewSharedSecret(adapter gitlab.Adapter, name string, data map[string][]byte) *corev1.Secret {
+	return newSecret(adapter, name, corev1.SecretTypeOpaque, data)
+}
+
+func newSecret(adapter gitlab.Adapter, name string, secretType corev1.SecretType, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       SecretKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: adapter.Name().Namespace,
+			Labels: map[string]string{
+				appLabel:  SharedSecretsComponentName,
+				"release": adapter.ReleaseName(),
+			},
+		},
+		Type: secretType,
+		Data: data,
+	}
+}
+
+func railsSecrets() (map[string][]byte, error) {
+	secrets := map[string]interface{}{}
+
+	for _, key := range []string{"secret_key_base", "otp_key_base", "db_key_base", "encrypted_settings_key_base"} {
+		value, err := internal.RandomString(internal.HexCharset, 128)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets[key] = value
+	}
+
+	for _, key := range []string{"openid_connect_signing_key", "ci_jwt_signing_key"} {
+		value, err := internal.RSAPrivateKeyPEM(2048)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets[key] = string(value)
+	}
+
+	for _, key := range []string{"active_record_encryption_primary_key", "active_record_encryption_deterministic_key"} {
+		value, err := internal.RandomString(internal.AlphanumericCharset, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets[key] = []string{value}
+	}
+
+	salt, err := internal.RandomString(internal.AlphanumericCharset, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets["active_record_encryption_key_derivation_salt"] = salt
+
+	content, err := yaml.Marshal(map[string]interface{}{
+		"production": secrets,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		"secrets.yml": content,
+	}, nil
+}
+
+func registryCertificate() (map[string][]byte, error) {
+	cert, err := internal.NewSelfSignedCertificate("gitlab-issuer", 4096, selfSignedCertificateValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		"registry-auth.crt": cert.CertificatePEM,
+		"registry-auth.key": cert.PrivateKeyPEM,
+	}, nil
+}
+
+func singleKey(key string, generate func() (string, error)) func() (map[string][]byte, error) {
+	return func() (map[string][]byte, error) {
+		return generateKeys(map[string]func() (string, error){
+			key: generate,
+		})
+	}
+}
+
+func generateKeys(generators map[string]func() (string, error)) (map[string][]byte, error) {
+	result := map[string][]byte{}
+
+	for key, generate := range generators {
+		value, err := generate()
+		if err != nil {
+			return nil, err
+		}
+
+		result[key] = []byte(value)
+	}
+
+	return result, nil
+}
+
+func randomString(charset string, length int) func() (string, error) {
+	return func() (string, error) {
+		return internal.RandomString(charset, length)
+	}
+}
+
+func randomBase64String(charset string, length int) func() (string, error) {
+	return func() (string, error) {
+		return internal.RandomBase64String(charset, length)
+	}
+}
@@ -0,0 +1,130 @@
+package gitlab
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("Generated Secrets", func() {
+	sharedSecretNames := func(secrets []SharedSecret) []string {
+		names := []string{}
+		for _, s := range secrets {
+			names = append(names, s.Name)
+		}
+
+		return names
+	}
+
+	When("Using the default configuration", func() {
+		mockGitLab := CreateMockGitLab(releaseName, namespace, support.Values{})
+		adapter := CreateMockAdapter(mockGitLab)
+
+		It("Should return the rotatable Secrets with their default names", func() {
+			secret, err := RotatableSecret(adapter, GitalySecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Name).To(Equal(releaseName + "-gitaly-secret"))
+			Expect(secret.Key).To(Equal("token"))
+
+			secret, err = RotatableSecret(adapter, WorkhorseSecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Name).To(Equal(releaseName + "-gitlab-workhorse-secret"))
+			Expect(secret.Key).To(Equal("shared_secret"))
+
+			_, err = RotatableSecret(adapter, "Unknown")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should generate the Secrets of the shared secrets Job", func() {
+			secrets, err := SharedSecrets(adapter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sharedSecretNames(secrets)).To(ContainElements(
+				releaseName+"-gitaly-secret",
+				releaseName+"-gitlab-shell-secret",
+				releaseName+"-gitlab-workhorse-secret",
+				releaseName+"-rails-secret",
+				releaseName+"-gitlab-shell-host-keys",
+				releaseName+"-gitlab-initial-root-password",
+				releaseName+"-postgresql-password",
+				releaseName+"-redis-secret",
+				releaseName+"-minio-secret",
+				releaseName+"-registry-secret",
+			))
+		})
+
+		It("Should generate the Rails secrets", func() {
+			secrets, _ := SharedSecrets(adapter)
+			for _, s := range secrets {
+				if s.Name != releaseName+"-rails-secret" {
+					continue
+				}
+
+				data, err := s.Generate()
+				Expect(err).NotTo(HaveOccurred())
+
+				railsSecrets := map[string]map[string]interface{}{}
+				Expect(yaml.Unmarshal(data["secrets.yml"], &railsSecrets)).To(Succeed())
+				Expect(railsSecrets["production"]).To(HaveKey("db_key_base"))
+				Expect(railsSecrets["production"]["db_key_base"]).To(HaveLen(128))
+				Expect(railsSecrets["production"]).To(HaveKey("openid_connect_signing_key"))
+			}
+		})
+	})
+
+	When("Using custom Secret names", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.gitaly.authToken.secret", "custom-gitaly")
+		_ = chartValues.SetValue("global.gitaly.authToken.key", "custom-key")
+		_ = chartValues.SetValue("postgresql.install", false)
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+
+		It("Should use the configured names", func() {
+			secret, err := RotatableSecret(adapter, GitalySecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Name).To(Equal("custom-gitaly"))
+			Expect(secret.Key).To(Equal("custom-key"))
+		})
+
+		It("Should not generate the Secrets of disabled components", func() {
+			secrets, err := SharedSecrets(adapter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sharedSecretNames(secrets)).To(ContainElement("custom-gitaly"))
+			Expect(sharedSecretNames(secrets)).NotTo(ContainElement(releaseName + "-postgresql-password"))
+		})
+	})
+
+	When("Generating self-signed certificates", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.org")
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+
+		It("Should issue a wildcard certificate for the domain", func() {
+			secrets, err := SelfSignedCertificates(adapter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secrets).To(HaveLen(3))
+
+			wildcard := secrets[0]
+			Expect(wildcard.Name).To(Equal(SelfSignedCertificateSecretName(adapter)))
+			Expect(wildcard.Type).To(Equal(corev1.SecretTypeTLS))
+
+			block, _ := pem.Decode(wildcard.Data[corev1.TLSCertKey])
+			cert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cert.DNSNames).To(ConsistOf("*.example.org", "example.org"))
+
+			Expect(secrets[1].Data).To(HaveKey("cfssl_ca"))
+			Expect(secrets[2].Data).To(HaveKey("gitlab.example.org.crt"))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
 )
 
 // SidekiqDeployments returns the Deployments of the Sidekiq component.
@@ -30,3 +31,27 @@ func SidekiqConfigMaps(template helm.Template) []client.Object {
 func SidekiqPodMonitor(template helm.Template) client.Object {
 	return template.Query().ObjectByKindAndComponent(PodMonitorKind, SidekiqComponentName)
 }
+
+// IsSidekiqHorizontalPodAutoscaler returns true when the object is the
+// HorizontalPodAutoscaler of a Sidekiq Deployment.
+func IsSidekiqHorizontalPodAutoscaler(obj client.Object) bool {
+	return obj.GetObjectKind().GroupVersionKind().Kind == HorizontalPodAutoscalerKind &&
+		obj.GetLabels()["app"] == SidekiqComponentName
+}
+
+// SidekiqRedisConnection returns the connection settings of the Redis instance
+// that holds the Sidekiq queues. It is the `queues` subqueue when it is
+// configured, otherwise the external or the bundled Redis.
+func SidekiqRedisConnection(adapter gitlab.Adapter, template helm.Template) RedisConnection {
+	connections := ExternalRedisConnections(adapter)
+
+	for _, instance := range []string{"queues", "default"} {
+		for _, connection := range connections {
+			if connection.Instance == instance {
+				return connection
+			}
+		}
+	}
+
+	return BundledRedisConnection(adapter, template)
+}
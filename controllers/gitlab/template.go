@@ -59,6 +59,10 @@ func GetTemplate(adapter gitlab.Adapter) (helm.Template, error) {
 		return template, err
 	}
 
+	if adapter.ManagesAvailability() {
+		SpreadWorkloads(adapter, template)
+	}
+
 	logger.V(1).Info("The template is rendered. Check the warnings (if any).",
 		"warnings", len(template.Warnings()))
 
@@ -8,9 +8,13 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
 
+	corev1 "k8s.io/api/core/v1"
 	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
 )
@@ -49,8 +53,66 @@ var _ = Describe("Template", func() {
 		Expect(template2).NotTo(BeNil())
 		Expect(template2).NotTo(BeIdenticalTo(template1))
 	})
+
+	It("must render the template again when an external migration completes", func() {
+		mockGitLab := CreateMockGitLab(releaseName, namespace, support.Values{})
+		mockGitLab.UID = "b"
+		mockGitLab.Generation = 1
+		mockGitLab.Spec.ExternalMigration = &gitlabv1beta1.ExternalMigrationSpec{
+			PostgreSQL: &gitlabv1beta1.ExternalPostgreSQLSpec{
+				Host:     "postgres.example.com",
+				Password: gitlabv1beta1.SecretKeySpec{Secret: "postgres", Key: "password"},
+			},
+		}
+		mockGitLab.Status.ExternalMigrations = []gitlabv1beta1.ExternalMigrationStatus{
+			{Service: status.ExternalMigrationPostgreSQL, Phase: status.ExternalMigrationMigrating},
+		}
+
+		migrating, err := GetTemplate(CreateMockAdapter(mockGitLab))
+
+		Expect(err).To(BeNil())
+		Expect(statefulSetNames(migrating)).To(ContainElement(ContainSubstring("postgresql")))
+
+		/* The migration completes without a new generation */
+		mockGitLab.Status.ExternalMigrations[0].Phase = status.ExternalMigrationCompleted
+
+		adapter := CreateMockAdapter(mockGitLab)
+		completed, err := GetTemplate(adapter)
+
+		Expect(err).To(BeNil())
+		Expect(completed).NotTo(BeIdenticalTo(migrating))
+
+		/* The bundled PostgreSQL is pruned and the components use the external server */
+		Expect(adapter.WantsComponent(component.PostgreSQL)).To(BeFalse())
+		Expect(statefulSetNames(completed)).NotTo(ContainElement(ContainSubstring("postgresql")))
+		Expect(configMapData(completed)).To(ContainSubstring("postgres.example.com"))
+	})
 })
 
+func statefulSetNames(template helm.Template) []string {
+	names := []string{}
+
+	for _, o := range template.Query().ObjectsByKind(StatefulSetKind) {
+		names = append(names, o.GetName())
+	}
+
+	return names
+}
+
+func configMapData(template helm.Template) string {
+	data := &strings.Builder{}
+
+	for _, o := range template.Query().ObjectsByKind(ConfigMapKind) {
+		if cm, ok := o.(*corev1.ConfigMap); ok {
+			for _, value := range cm.Data {
+				data.WriteString(value)
+			}
+		}
+	}
+
+	return data.String()
+}
+
 // dumpTemplate() will serialize the template and display the YAML for debugging.
 func dumpTemplate(template helm.Template) string { //nolint:golint,unused
 	output := new(strings.Builder)
@@ -0,0 +1,63 @@
+package gitlab
+
+import (
+	"sort"
+
+	networkingv1 "k8s.io/api/networking/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+)
+
+// TLSEndpoint is a TLS Secret of an Ingress and the hosts that it secures.
+type TLSEndpoint struct {
+	Ingress string
+	Secret  string
+	Hosts   []string
+}
+
+// IngressTLSEndpoints returns the TLS Secrets of the Ingresses of the
+// template, whether they are provided by the user or issued by cert-manager.
+// When a TLS entry of an Ingress does not list its hosts, it secures the
+// hosts of the rules of the Ingress.
+func IngressTLSEndpoints(template helm.Template) []TLSEndpoint {
+	result := []TLSEndpoint{}
+
+	for _, object := range template.Query().ObjectsByKind(IngressKind) {
+		ingress, ok := object.(*networkingv1.Ingress)
+		if !ok {
+			continue
+		}
+
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+
+			hosts := append([]string{}, tls.Hosts...)
+
+			if len(hosts) == 0 {
+				for _, rule := range ingress.Spec.Rules {
+					if rule.Host != "" {
+						hosts = append(hosts, rule.Host)
+					}
+				}
+			}
+
+			result = append(result, TLSEndpoint{
+				Ingress: ingress.Name,
+				Secret:  tls.SecretName,
+				Hosts:   hosts,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Ingress != result[j].Ingress {
+			return result[i].Ingress < result[j].Ingress
+		}
+
+		return result[i].Secret < result[j].Secret
+	})
+
+	return result
+}
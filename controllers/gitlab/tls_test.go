@@ -0,0 +1,39 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("TLS endpoints", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	When("a wildcard certificate is provided", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue("global.ingress.configureCertmanager", false)
+		_ = chartValues.SetValue("global.ingress.tls.secretName", "wildcard-tls")
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should return the Secret and the hosts of each Ingress", func() {
+			endpoints := IngressTLSEndpoints(template)
+
+			Expect(endpoints).To(ContainElements(
+				TLSEndpoint{Ingress: releaseName + "-webservice-default", Secret: "wildcard-tls", Hosts: []string{"gitlab.example.com"}},
+				TLSEndpoint{Ingress: releaseName + "-registry", Secret: "wildcard-tls", Hosts: []string{"registry.example.com"}},
+			))
+		})
+	})
+})
@@ -3,6 +3,10 @@ package gitlab
 import (
 	"fmt"
 
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
@@ -31,3 +35,58 @@ func ToolboxCronJobPersistentVolumeClaim(adapter gitlab.Adapter, template helm.T
 	return template.Query().ObjectByKindAndName(PersistentVolumeClaimKind,
 		fmt.Sprintf("%s-%s-backup-tmp", adapter.ReleaseName(), ToolboxComponentName))
 }
+
+// railsScriptEnv is the environment variable that holds the script of a Rails
+// runner Job.
+const railsScriptEnv = "RAILS_RUNNER_SCRIPT"
+
+// railsRunnerJob returns a Job that runs the script with the Rails runner of
+// the Toolbox, using the Pod template of the Toolbox Deployment. The script
+// can report its result in the termination message of the container.
+func railsRunnerJob(adapter gitlab.Adapter, template helm.Template, name, script string, backoffLimit int32, env ...corev1.EnvVar) (*batchv1.Job, error) {
+	toolbox := ToolboxDeployment(adapter, template)
+	if toolbox == nil {
+		return nil, fmt.Errorf("the Toolbox Deployment is required to run %s", name)
+	}
+
+	deployment, ok := toolbox.(*appsv1.Deployment)
+	if !ok {
+		return nil, helm.NewTypeMistmatchError(deployment, toolbox)
+	}
+
+	podTemplate := deployment.Spec.Template.DeepCopy()
+	podTemplate.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	if len(podTemplate.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("the Toolbox Deployment does not have any containers")
+	}
+
+	container := &podTemplate.Spec.Containers[0]
+	container.Command = []string{"/bin/bash", "-c", fmt.Sprintf("exec gitlab-rails runner \"$%s\"", railsScriptEnv)}
+	container.Args = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	container.Env = append(append(container.Env, env...),
+		corev1.EnvVar{Name: railsScriptEnv, Value: script})
+
+	podTemplate.Spec.Containers = podTemplate.Spec.Containers[:1]
+
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       JobKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: deployment.Namespace,
+			Labels:    deployment.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     *podTemplate,
+		},
+	}
+
+	return job, nil
+}
@@ -0,0 +1,67 @@
+package gitlab
+
+import (
+	"fmt"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+// CertificateAuthoritySource is a ConfigMap or Secret in
+// `global.certificates.customCAs` of the GitLab Chart.
+type CertificateAuthoritySource struct {
+	// Kind is either ConfigMap or Secret.
+	Kind string
+
+	// Name is the name of the ConfigMap or Secret.
+	Name string
+
+	// Keys are the keys that hold the certificates. When it is empty all keys
+	// are used.
+	Keys []string
+}
+
+// CustomCertificateAuthorities returns the sources of the custom certificate
+// authorities that the GitLab components trust, including the bundle of the
+// custom certificate authorities of the GitLab resource.
+func CustomCertificateAuthorities(adapter gitlab.Adapter) []CertificateAuthoritySource {
+	value, err := adapter.Values().GetValue("global.certificates.customCAs")
+	if err != nil {
+		return nil
+	}
+
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := []CertificateAuthoritySource{}
+
+	for _, entry := range entries {
+		entry, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		source := CertificateAuthoritySource{}
+
+		if name, ok := entry["secret"].(string); ok && name != "" {
+			source.Kind = SecretKind
+			source.Name = name
+		} else if name, ok := entry["configMap"].(string); ok && name != "" {
+			source.Kind = ConfigMapKind
+			source.Name = name
+		} else {
+			continue
+		}
+
+		if keys, ok := entry["keys"].([]interface{}); ok {
+			for _, key := range keys {
+				source.Keys = append(source.Keys, fmt.Sprint(key))
+			}
+		}
+
+		result = append(result, source)
+	}
+
+	return result
+}
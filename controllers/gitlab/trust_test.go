@@ -0,0 +1,52 @@
+package gitlab
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	gitlabv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
+)
+
+var _ = Describe("Custom CA bundle", func() {
+	if namespace == "" {
+		namespace = testNamespace
+	}
+
+	When("a CA bundle is referenced", func() {
+		chartValues := support.Values{}
+		_ = chartValues.SetValue("global.hosts.domain", "example.com")
+		_ = chartValues.SetValue("global.certificates.customCAs", []interface{}{
+			map[string]interface{}{"secret": "legacy-ca"},
+		})
+
+		mockGitLab := CreateMockGitLab(releaseName, namespace, chartValues)
+		mockGitLab.Spec.Trust = &gitlabv1beta1.TrustSpec{
+			CABundleRef: &gitlabv1beta1.CABundleReferenceSpec{Kind: "Bundle", Name: "corporate-ca", Key: "trust-bundle.pem"},
+		}
+
+		adapter := CreateMockAdapter(mockGitLab)
+		template, err := GetTemplate(adapter)
+
+		It("Should render the template", func() {
+			Expect(err).To(BeNil())
+			Expect(template).NotTo(BeNil())
+		})
+
+		It("Should add the bundle to the custom CAs of the chart", func() {
+			customCAs, err := adapter.Values().GetValue("global.certificates.customCAs")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(customCAs).To(ConsistOf(
+				map[string]interface{}{"secret": "legacy-ca"},
+				map[string]interface{}{"configMap": "corporate-ca", "keys": []interface{}{"trust-bundle.pem"}},
+			))
+		})
+
+		It("Should list the sources of the custom CAs", func() {
+			Expect(CustomCertificateAuthorities(adapter)).To(ConsistOf(
+				CertificateAuthoritySource{Kind: SecretKind, Name: "legacy-ca"},
+				CertificateAuthoritySource{Kind: ConfigMapKind, Name: "corporate-ca", Keys: []string{"trust-bundle.pem"}},
+			))
+		})
+	})
+})
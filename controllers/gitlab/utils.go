@@ -16,14 +16,17 @@ const (
 	DaemonSetKind               = "DaemonSet"
 	DeploymentKind              = "Deployment"
 	HorizontalPodAutoscalerKind = "HorizontalPodAutoscaler"
+	HTTPRouteKind               = "HTTPRoute"
 	IngressKind                 = "Ingress"
 	JobKind                     = "Job"
 	PersistentVolumeClaimKind   = "PersistentVolumeClaim"
 	PodMonitorKind              = "PodMonitor"
+	RouteKind                   = "Route"
 	SecretKind                  = "Secret"
 	ServiceKind                 = "Service"
 	ServiceMonitorKind          = "ServiceMonitor"
 	StatefulSetKind             = "StatefulSet"
+	TCPRouteKind                = "TCPRoute"
 
 	// GitlabComponentName is the com mon name of GitLab.
 	GitLabComponentName = "gitlab"
@@ -95,6 +98,7 @@ const (
 	appLabel             = "app"
 
 	ChartVersion7 = "7.0.0"
+	ChartVersion8 = "8.0.0"
 )
 
 // RedisSubqueues is the array of possible Redis subqueues.
@@ -32,6 +32,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
@@ -41,6 +42,7 @@ import (
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
@@ -89,15 +91,24 @@ type GitLabReconciler struct {
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=podmonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheuses,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=issuers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=route.openshift.io,resources=routes/custom-host,verbs=create
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=tcproutes,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile triggers when an event occurs on the watched resource.
 //
@@ -149,6 +160,12 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return requeue(err)
 	}
 
+	if adapter.WantsNetworkPolicies() {
+		if err := r.reconcileNetworkPolicies(ctx, adapter, template); err != nil {
+			return requeue(err)
+		}
+	}
+
 	if adapter.WantsComponent(component.NginxIngress) {
 		if err := r.reconcileNGINX(ctx, adapter, template); err != nil {
 			return requeue(err)
@@ -164,6 +181,10 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return requeueWithDelay()
 	}
 
+	if err := r.generateGeoSecrets(ctx, adapter); err != nil {
+		return requeue(err)
+	}
+
 	finished, err = r.runSelfSignedCertsJob(ctx, adapter, template)
 	if err != nil {
 		return requeue(err)
@@ -173,12 +194,28 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return requeueWithDelay()
 	}
 
+	nextSecretRotation, err := r.rotateSecrets(ctx, adapter)
+	if err != nil {
+		return requeue(err)
+	}
+
+	if err := r.reconcileSidekiqScaling(ctx, adapter, template); err != nil {
+		return requeue(err)
+	}
+
+	postgresReady := true
+
 	if adapter.WantsComponent(component.PostgreSQL) {
 		if err := r.reconcilePostgres(ctx, adapter, template); err != nil {
 			return requeue(err)
 		}
+
+		if isPostgresUpgradePending(adapter) {
+			log.Info("PostgreSQL major version upgrade is pending. Waiting and retrying", "interval", postgresUpgradeRetryDelay)
+			return ctrl.Result{RequeueAfter: postgresUpgradeRetryDelay}, nil
+		}
 	} else {
-		if err := r.validateExternalPostgresConfiguration(ctx, adapter); err != nil {
+		if postgresReady, err = r.validateExternalPostgresConfiguration(ctx, adapter); err != nil {
 			return requeue(err)
 		}
 	}
@@ -187,10 +224,23 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		if err := r.reconcileRedis(ctx, adapter, template); err != nil {
 			return requeue(err)
 		}
-	} else {
-		if err := r.validateExternalRedisConfiguration(ctx, adapter); err != nil {
-			return requeue(err)
-		}
+	}
+
+	externalMigrationPending, err := r.reconcileExternalMigration(ctx, adapter, template)
+	if err != nil {
+		return requeue(err)
+	}
+
+	if externalMigrationPending {
+		log.Info("Migration to external services is in progress. Waiting and retrying", "interval", externalMigrationCheckInterval)
+		return ctrl.Result{RequeueAfter: externalMigrationCheckInterval}, nil
+	}
+
+	// Subqueues may use external Redis instances even when the bundled Redis
+	// is enabled.
+	redisReady, err := r.validateExternalRedisConfiguration(ctx, adapter)
+	if err != nil {
+		return requeue(err)
 	}
 
 	if adapter.WantsComponent(component.Gitaly) {
@@ -201,6 +251,21 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	praefectDatabaseReady, err := r.provisionPraefectDatabase(ctx, adapter, template)
+	if err != nil {
+		return requeue(err)
+	}
+
+	geoTrackingDatabaseReady, err := r.provisionGeoTrackingDatabase(ctx, adapter, template)
+	if err != nil {
+		return requeue(err)
+	}
+
+	if !geoTrackingDatabaseReady {
+		log.Info("Geo tracking database is not ready. Waiting and retrying", "interval", defaultRequeueDelay)
+		return requeueWithDelay()
+	}
+
 	if adapter.WantsComponent(component.Praefect) {
 		if err := r.reconcilePraefect(ctx, adapter, template); err != nil {
 			return requeue(err)
@@ -213,10 +278,16 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	objectStorageReady := true
+
 	if adapter.WantsComponent(component.MinIO) {
 		if err := r.reconcileMinioInstance(ctx, adapter, template); err != nil {
 			return requeue(err)
 		}
+	} else {
+		if objectStorageReady, err = r.validateObjectStorageConfiguration(ctx, adapter); err != nil {
+			return requeue(err)
+		}
 	}
 
 	if adapter.WantsComponent(component.Mailroom) {
@@ -225,6 +296,12 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	if adapter.WantsComponent(component.GeoLogcursor) {
+		if err := r.reconcileGeoLogcursor(ctx, adapter, template); err != nil {
+			return requeue(err)
+		}
+	}
+
 	if adapter.WantsComponent(component.Spamcheck) {
 		if err := r.reconcileSpamcheck(ctx, adapter, template); err != nil {
 			return requeue(err)
@@ -410,10 +487,20 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	if err := r.reconcileGitalyMigration(ctx, adapter, template); err != nil {
+		return requeue(err)
+	}
+
 	if err := r.setupAutoscaling(ctx, adapter, template); err != nil {
 		return requeue(err)
 	}
 
+	if adapter.ManagesAvailability() {
+		if err := r.reconcilePodDisruptionBudgets(ctx, adapter, template); err != nil {
+			return requeue(err)
+		}
+	}
+
 	if settings.IsGroupVersionKindSupported("monitoring.coreos.com/v1", "ServiceMonitor") {
 		if err := r.reconcileServiceMonitors(ctx, adapter, template); err != nil {
 			return requeue(err)
@@ -471,8 +558,61 @@ func (r *GitLabReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	if err := r.reconcileGeoReplicationStatus(ctx, adapter, template); err != nil {
+		return requeue(err)
+	}
+
+	nextCertificateCheck, err := r.reconcileCertificateStatus(ctx, adapter, template)
+	if err != nil {
+		return requeue(err)
+	}
+
 	result, err := r.reconcileGitLabStatus(ctx, adapter, template)
 
+	if nextSecretRotation > 0 && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, nextSecretRotation)
+	}
+
+	if adapter.SidekiqQueueScalingEnabled() && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, sidekiqScalingInterval)
+	}
+
+	if nextCertificateCheck > 0 && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, nextCertificateCheck)
+	}
+
+	if !postgresReady && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, databaseProbeRetryDelay)
+	}
+
+	if !redisReady && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, redisProbeRetryDelay)
+	}
+
+	if !objectStorageReady && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, objectStorageProbeRetryDelay)
+	}
+
+	if !praefectDatabaseReady && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, praefectDatabaseRetryDelay)
+	}
+
+	if adapter.GeoRole() != "" && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, geoCheckInterval)
+	}
+
+	if adapter.VolumeExpansionInProgress() && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, volumeExpansionRetryDelay)
+	}
+
+	if adapter.GitalyRolloutInProgress() && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, gitalyRolloutRetryDelay)
+	}
+
+	if isGitalyMigrationInProgress(adapter) && err == nil {
+		result.RequeueAfter = earliestDelay(result.RequeueAfter, gitalyMigrationCheckInterval)
+	}
+
 	return result, err
 }
 
@@ -534,7 +674,30 @@ func (r *GitLabReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&appsv1.DaemonSet{}).
 		Owns(&batchv1.Job{}).
 		Owns(&networkingv1.Ingress{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{})
+		Owns(&networkingv1.NetworkPolicy{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.gitLabsOfValuesSource)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.gitLabsOfValuesSource)).
+		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, r.valuesSourceChanged()))
+
+	if settings.IsGroupVersionKindSupported(gitlabctl.RouteAPIVersion, gitlabctl.RouteKind) {
+		r.Log.Info("Using route.openshift.io/v1 for Route")
+		builder.Owns(newRoute())
+	}
+
+	if settings.IsGroupVersionKindSupported(gitlabctl.HTTPRouteAPIVersion, gitlabctl.HTTPRouteKind) {
+		r.Log.Info("Using gateway.networking.k8s.io/v1 for HTTPRoute")
+		builder.Owns(newGatewayRoute(gitlabctl.HTTPRouteAPIVersion, gitlabctl.HTTPRouteKind))
+	}
+
+	if settings.IsGroupVersionKindSupported(gitlabctl.TCPRouteAPIVersion, gitlabctl.TCPRouteKind) {
+		r.Log.Info("Using gateway.networking.k8s.io/v1alpha2 for TCPRoute")
+		builder.Owns(newGatewayRoute(gitlabctl.TCPRouteAPIVersion, gitlabctl.TCPRouteKind))
+	}
+
+	if settings.IsGroupVersionKindSupported("policy/v1", gitlabctl.PodDisruptionBudgetKind) {
+		r.Log.Info("Using policy/v1 for PodDisruptionBudget")
+		builder.Owns(&policyv1.PodDisruptionBudget{})
+	}
 
 	if settings.IsGroupVersionKindSupported("batch/v1", "CronJob") {
 		r.Log.Info("Using batch/v1 for CronJob")
@@ -652,8 +815,21 @@ func (r *GitLabReconciler) reconcilePodMonitors(ctx context.Context, adapter git
 	return nil
 }
 
-// The boolean return parameter is unused at the moment, but may be useful in the future.
+// createOrPatch applies the object. StatefulSets and Services are deleted and
+// created again when the changes modify their immutable fields.
 func (r *GitLabReconciler) createOrPatch(ctx context.Context, templateObject client.Object, adapter gitlab.Adapter) error {
+	return r.applyObject(ctx, templateObject, adapter, kube.DefaultRecreatePolicy)
+}
+
+// createOrRecreate applies the Job like createOrPatch, and deletes it to
+// create it again when the changes modify its immutable fields, for example
+// the image of a chart Job between chart versions. It is meant for the Jobs
+// that the Operator runs to completion. A running Job is never deleted.
+func (r *GitLabReconciler) createOrRecreate(ctx context.Context, job client.Object, adapter gitlab.Adapter) error {
+	return r.applyObject(ctx, job, adapter, kube.JobRecreatePolicy)
+}
+
+func (r *GitLabReconciler) applyObject(ctx context.Context, templateObject client.Object, adapter gitlab.Adapter, recreate kube.RecreatePolicy) error {
 	if templateObject == nil {
 		r.Log.Info("Controller is not able to delete managed resources. This is a known issue",
 			"gitlab", adapter.Name())
@@ -677,17 +853,30 @@ func (r *GitLabReconciler) createOrPatch(ctx context.Context, templateObject cli
 
 	obj := templateObject.DeepCopyObject().(client.Object)
 
+	if err := r.injectCABundle(ctx, adapter, obj); err != nil {
+		return err
+	}
+
 	if err := controllerutil.SetControllerReference(adapter.Origin(), obj, r.Scheme); err != nil {
 		return err
 	}
 
 	outcome, err := kube.ApplyObject(obj, apply.WithContext(ctx),
-		apply.WithClient(r.Client), apply.WithLogger(logger))
+		apply.WithClient(r.Client), apply.WithLogger(logger),
+		apply.WithRecreatePolicy(recreate))
 
 	if err != nil {
 		return err
 	}
 
+	// The deletion of the owned object triggers the reconcile that creates
+	// it again.
+	if outcome == kube.ObjectDeleted {
+		r.Recorder.Event(adapter.Origin(), "Normal", "ObjectRecreated",
+			fmt.Sprintf("Deleted %s %s to create it again because the changes modify its immutable fields",
+				obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()))
+	}
+
 	if outcome != kube.ObjectUnchanged {
 		logger.V(1).Info("CreateOrPatch", "outcome", outcome)
 	}
@@ -695,7 +884,7 @@ func (r *GitLabReconciler) createOrPatch(ctx context.Context, templateObject cli
 	return nil
 }
 
-func (r *GitLabReconciler) reconcileIngress(ctx context.Context, templateObject client.Object, adapter gitlab.Adapter) error {
+func (r *GitLabReconciler) reconcileIngress(ctx context.Context, templateObject client.Object, adapter gitlab.Adapter, template helm.Template) error {
 	if templateObject == nil {
 		r.Log.V(2).Info("Controller received a nil templateObject",
 			"type", "Ingress",
@@ -709,6 +898,13 @@ func (r *GitLabReconciler) reconcileIngress(ctx context.Context, templateObject
 		return err
 	}
 
+	switch adapter.NetworkingMode() {
+	case gitlab.NetworkingModeRoute:
+		return r.reconcileRoutes(ctx, ingress, adapter, template)
+	case gitlab.NetworkingModeGatewayAPI:
+		return r.reconcileHTTPRoutes(ctx, ingress, adapter, template)
+	}
+
 	logger := r.Log.WithValues("gitlab", adapter.Name())
 
 	found := &networkingv1.Ingress{}
@@ -751,7 +947,14 @@ func (r *GitLabReconciler) reconcileIngress(ctx context.Context, templateObject
 	return nil
 }
 
+// reconcileCertManagerCertificates applies the Issuer of the Operator. The
+// Issuer is removed when all the hosts reference existing issuers. Issuers
+// are not pruned with the other unmanaged objects.
 func (r *GitLabReconciler) reconcileCertManagerCertificates(ctx context.Context, adapter gitlab.Adapter) error {
+	if !internal.RequiresCertificateIssuer(adapter) {
+		return r.deleteIfExists(ctx, internal.CertificateIssuer(adapter))
+	}
+
 	return r.createOrPatch(ctx,
 		internal.CertificateIssuer(adapter),
 		adapter)
@@ -759,6 +962,12 @@ func (r *GitLabReconciler) reconcileCertManagerCertificates(ctx context.Context,
 
 func (r *GitLabReconciler) setupAutoscaling(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
 	for _, hpa := range template.Query().ObjectsByKind(gitlabctl.HorizontalPodAutoscalerKind) {
+		// The Operator scales Sidekiq on its queues. The HorizontalPodAutoscalers
+		// of Sidekiq are removed with the other unmanaged objects.
+		if adapter.SidekiqQueueScalingEnabled() && gitlabctl.IsSidekiqHorizontalPodAutoscaler(hpa) {
+			continue
+		}
+
 		if err := r.createOrPatch(ctx, hpa, adapter); err != nil {
 			return err
 		}
@@ -767,6 +976,19 @@ func (r *GitLabReconciler) setupAutoscaling(ctx context.Context, adapter gitlab.
 	return nil
 }
 
+// reconcilePodDisruptionBudgets applies the PodDisruptionBudgets of the
+// workloads. The PodDisruptionBudgets that are no longer needed are removed
+// with the other unmanaged objects.
+func (r *GitLabReconciler) reconcilePodDisruptionBudgets(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
+	for _, pdb := range gitlabctl.PodDisruptionBudgets(adapter, template) {
+		if err := r.createOrPatch(ctx, pdb, adapter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *GitLabReconciler) isEndpointReady(ctx context.Context, service string, adapter gitlab.Adapter) bool {
 	var addresses []corev1.EndpointAddress
 
@@ -798,7 +1020,7 @@ func (r *GitLabReconciler) ifCoreServicesReady(ctx context.Context, adapter gitl
 	}
 
 	if adapter.WantsComponent(component.Gitaly) {
-		if !adapter.WantsComponent(component.Praefect) || !adapter.WantsFeature(feature.ReplaceGitalyWithPraefect) {
+		if (!adapter.WantsComponent(component.Praefect) || !adapter.WantsFeature(feature.ReplaceGitalyWithPraefect)) && !isGitalyRetired(adapter) {
 			if !r.isEndpointReady(ctx, gitlabctl.GitalyService(template).GetName(), adapter) {
 				return false
 			}
@@ -888,9 +1110,12 @@ func (r *GitLabReconciler) annotateSecretsChecksum(ctx context.Context, adapter
 			template.ObjectMeta.Annotations = map[string]string{}
 		}
 
-		key := fmt.Sprintf("checksum/secret-%s", secretName)
+		truncatedKey, err := secretChecksumKey(secretName)
+		if err != nil {
+			return err
+		}
 
-		truncatedKey, err := internal.Truncate(key, maxKeyLength)
+		hash, err = r.stagedSecretChecksum(ctx, secret, obj, truncatedKey, hash)
 		if err != nil {
 			return err
 		}
@@ -901,6 +1126,12 @@ func (r *GitLabReconciler) annotateSecretsChecksum(ctx context.Context, adapter
 	return nil
 }
 
+// secretChecksumKey returns the annotation of the Pod template that holds the
+// checksum of the Secret.
+func secretChecksumKey(secretName string) (string, error) {
+	return internal.Truncate(fmt.Sprintf("checksum/secret-%s", secretName), maxKeyLength)
+}
+
 func (r *GitLabReconciler) ensureSecret(ctx context.Context, adapter gitlab.Adapter, secretName string) error {
 	secret := &corev1.Secret{}
 	lookupKey := types.NamespacedName{Name: secretName, Namespace: adapter.Name().Namespace}
@@ -917,6 +1148,38 @@ func (r *GitLabReconciler) ensureSecret(ctx context.Context, adapter gitlab.Adap
 	return nil
 }
 
+// secretValue returns the value of the key of the specified Secret. It fails
+// when either the Secret or the key does not exist.
+func (r *GitLabReconciler) secretValue(ctx context.Context, adapter gitlab.Adapter, secretName, key string) ([]byte, error) {
+	secret := &corev1.Secret{}
+	lookupKey := types.NamespacedName{Name: secretName, Namespace: adapter.Name().Namespace}
+
+	if err := r.Get(ctx, lookupKey, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("Secret '%s' not found", lookupKey)
+		}
+
+		return nil, err
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key '%s' not found in Secret '%s'", key, lookupKey)
+	}
+
+	return value, nil
+}
+
+// optionalSecretValue is similar to secretValue but it returns nil when the
+// key is not specified.
+func (r *GitLabReconciler) optionalSecretValue(ctx context.Context, adapter gitlab.Adapter, secretName, key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	return r.secretValue(ctx, adapter, secretName, key)
+}
+
 func doNotRequeue() (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
@@ -21,6 +21,12 @@ func (r *GitLabReconciler) reconcileGitLabShell(ctx context.Context, adapter git
 		return err
 	}
 
+	if adapter.NetworkingMode() == gitlab.NetworkingModeGatewayAPI {
+		if err := r.reconcileShellTCPRoute(ctx, adapter, template); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -90,6 +90,20 @@ func (r *GitLabReconciler) sidekiqAndWebserviceRunning(ctx context.Context, adap
 	return r.sidekiqRunning(ctx, adapter, template) && r.webserviceRunning(ctx, adapter, template)
 }
 
+// conditionChanges returns true when the recorded condition does not have the
+// status yet. Warning events are only recorded when the condition changes, not
+// on every reconcile.
+func conditionChanges(adapter gitlab.Adapter, conditionType gitlab.ConditionType, status bool) bool {
+	statusValue := metav1.ConditionFalse
+	if status {
+		statusValue = metav1.ConditionTrue
+	}
+
+	current := adapter.Condition(conditionType)
+
+	return current == nil || current.Status != statusValue
+}
+
 func (r *GitLabReconciler) setStatusCondition(ctx context.Context, adapter gitlab.Adapter, reason gitlab.ConditionType, status bool, message string) error {
 	statusValue := metav1.ConditionFalse
 	if status {
@@ -0,0 +1,627 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apiv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/adapter"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+	rt "gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/runtime"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support/kube"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support/kube/apply"
+)
+
+// runnerFinalizer deletes the runner that the Operator created from GitLab
+// before the GitLabRunner resource is removed.
+const runnerFinalizer = "apps.gitlab.com/unregister-runner"
+
+// GitLabRunnerReconciler reconciles a GitLabRunner object.
+type GitLabRunnerReconciler struct {
+	client.Client
+
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=apps.gitlab.com,resources=gitlabrunners,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps.gitlab.com,resources=gitlabrunners/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps.gitlab.com,resources=gitlabrunners/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods/exec;pods/attach,verbs=get;create;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods/log,verbs=get;list
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile deploys a GitLab Runner for the GitLab instance that the
+// GitLabRunner resource references.
+//
+// The runner authentication token is either provided by the user or created
+// by the Operator. In the latter case, a Toolbox Job creates an instance
+// runner in GitLab with the token, and another Toolbox Job deletes it when
+// the resource is deleted.
+func (r *GitLabRunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("gitlabrunner", req.NamespacedName)
+
+	log.Info("Reconciling GitLabRunner")
+
+	runner := &apiv1beta1.GitLabRunner{}
+	if err := r.Get(ctx, req.NamespacedName, runner); err != nil {
+		if errors.IsNotFound(err) {
+			return doNotRequeue()
+		}
+
+		return requeue(err)
+	}
+
+	if !runner.DeletionTimestamp.IsZero() {
+		return r.finalizeRunner(ctx, runner)
+	}
+
+	gitlabKey := types.NamespacedName{Name: runner.Spec.GitLab.Name, Namespace: runner.Namespace}
+
+	instance := &apiv1beta1.GitLab{}
+	if err := r.Get(ctx, gitlabKey, instance); err != nil {
+		if !errors.IsNotFound(err) {
+			return requeue(err)
+		}
+
+		return r.setPhase(ctx, runner, status.RunnerPending, false,
+			fmt.Sprintf("GitLab %s does not exist", gitlabKey.Name))
+	}
+
+	rtCtx := rt.NewContext(ctx,
+		rt.WithLogger(log),
+		rt.WithClient(r.Client),
+		rt.WithEventRecorder(r.Recorder))
+
+	adapter, err := adapter.NewV1Beta1(rtCtx, instance)
+	if err != nil {
+		return requeue(err)
+	}
+
+	template, err := gitlabctl.GetTemplate(adapter)
+	if err != nil {
+		return r.setPhase(ctx, runner, status.RunnerPending, false,
+			fmt.Sprintf("The configuration of GitLab %s is not valid: %v", gitlabKey.Name, err))
+	}
+
+	spec := runnerSpec(adapter, runner)
+	if spec.Version == "" {
+		return r.setPhase(ctx, runner, status.RunnerFailed, false,
+			"Can not determine the version of GitLab Runner. Set the version of the runner")
+	}
+
+	if runner.Spec.Token != nil {
+		if err := r.checkRunnerToken(ctx, spec); err != nil {
+			return r.setPhase(ctx, runner, status.RunnerPending, false, err.Error())
+		}
+	} else {
+		registered, err := r.registerRunner(ctx, adapter, template, runner, spec)
+		if err != nil {
+			return requeue(err)
+		}
+
+		if !registered {
+			return requeueWithDelay()
+		}
+	}
+
+	configMap := gitlabctl.RunnerConfigMap(adapter, template, spec)
+	checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(configMap.Data["config.toml"])))
+	deployment := gitlabctl.RunnerDeployment(adapter, template, spec, checksum)
+
+	objects := []client.Object{}
+	for _, serviceAccount := range gitlabctl.RunnerServiceAccounts(spec) {
+		objects = append(objects, serviceAccount)
+	}
+
+	objects = append(objects,
+		gitlabctl.RunnerRole(spec),
+		gitlabctl.RunnerRoleBinding(spec),
+		configMap,
+		deployment)
+
+	for _, obj := range objects {
+		if err := r.createOrPatch(ctx, runner, obj); err != nil {
+			return requeue(err)
+		}
+	}
+
+	runner.Status.Version = spec.Version
+	runner.Status.TokenSecret = spec.TokenSecret
+
+	lookup := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(deployment), lookup); err != nil {
+		return requeue(err)
+	}
+
+	if lookup.Status.AvailableReplicas == 0 {
+		return r.setPhase(ctx, runner, status.RunnerPending, false,
+			fmt.Sprintf("Waiting for Deployment %s to become available", deployment.Name))
+	}
+
+	return r.setPhase(ctx, runner, status.RunnerRunning, true,
+		fmt.Sprintf("GitLab Runner %s is running", spec.Version))
+}
+
+// SetupWithManager configures the custom resource watched resources.
+func (r *GitLabRunnerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1beta1.GitLabRunner{}).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.ServiceAccount{}).
+		Owns(&rbacv1.Role{}).
+		Owns(&rbacv1.RoleBinding{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&batchv1.Job{}).
+		Watches(&apiv1beta1.GitLab{}, handler.EnqueueRequestsFromMapFunc(r.runnersOfGitLab)).
+		Complete(r)
+}
+
+// runnersOfGitLab maps a GitLab resource to the runners that reference it, so
+// that the runners follow the changes of the instance, for example its
+// version.
+func (r *GitLabRunnerReconciler) runnersOfGitLab(ctx context.Context, obj client.Object) []reconcile.Request {
+	runners := &apiv1beta1.GitLabRunnerList{}
+	if err := r.List(ctx, runners, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list the runners of GitLab", "gitlab", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, runner := range runners.Items {
+		if runner.Spec.GitLab.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&runner),
+			})
+		}
+	}
+
+	return requests
+}
+
+// runnerSpec describes the runner of the resource. The version of the runner
+// follows the version of the instance unless it is set explicitly.
+func runnerSpec(adapter gitlab.Adapter, runner *apiv1beta1.GitLabRunner) gitlabctl.Runner {
+	spec := gitlabctl.Runner{
+		Name:        runner.Name,
+		Namespace:   runner.Namespace,
+		Version:     runner.Spec.Version,
+		Image:       runner.Spec.Image,
+		Concurrent:  int(runner.Spec.Concurrent),
+		Tags:        runner.Spec.Tags,
+		RunUntagged: runner.Spec.RunUntagged,
+		BuildImage:  "alpine:latest",
+		Labels:      internal.ResourceLabels(runner.Name, gitlabctl.RunnerComponentName, "gitlab"),
+	}
+
+	if spec.Version == "" {
+		spec.Version = gitlabctl.RunnerVersion(adapter)
+	}
+
+	if spec.Image == "" {
+		spec.Image = gitlabctl.RunnerImage(spec.Version)
+	}
+
+	if spec.Concurrent < 1 {
+		spec.Concurrent = 1
+	}
+
+	if runner.Spec.Kubernetes != nil {
+		spec.Privileged = runner.Spec.Kubernetes.Privileged
+
+		if runner.Spec.Kubernetes.Image != "" {
+			spec.BuildImage = runner.Spec.Kubernetes.Image
+		}
+	}
+
+	if runner.Spec.Token != nil {
+		spec.TokenSecret = runner.Spec.Token.Secret
+		spec.TokenKey = runner.Spec.Token.Key
+	} else {
+		spec.TokenSecret = gitlabctl.RunnerTokenSecretName(spec)
+		spec.TokenKey = gitlabctl.RunnerTokenKey
+	}
+
+	return spec
+}
+
+// checkRunnerToken checks that the provided runner authentication token
+// exists.
+func (r *GitLabRunnerReconciler) checkRunnerToken(ctx context.Context, spec gitlabctl.Runner) error {
+	secret := &corev1.Secret{}
+	lookupKey := types.NamespacedName{Name: spec.TokenSecret, Namespace: spec.Namespace}
+
+	if err := r.Get(ctx, lookupKey, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("Secret '%s' not found", lookupKey)
+		}
+
+		return err
+	}
+
+	if _, ok := secret.Data[spec.TokenKey]; !ok {
+		return fmt.Errorf("key '%s' not found in Secret '%s'", spec.TokenKey, lookupKey)
+	}
+
+	return nil
+}
+
+// registerRunner creates the runner authentication token and runs the Job that
+// creates the runner in GitLab. It returns true once the runner is created
+// with its current tags.
+//
+// The succeeded Job is kept as the record of the registration. Changing the
+// tags of the runner starts a new Job, and the Jobs of the previous tags are
+// deleted. A failed Job is kept until it is deleted to retry the registration.
+func (r *GitLabRunnerReconciler) registerRunner(ctx context.Context, adapter gitlab.Adapter, template helm.Template, runner *apiv1beta1.GitLabRunner, spec gitlabctl.Runner) (bool, error) {
+	if !adapter.WantsComponent(component.Toolbox) {
+		_, err := r.setPhase(ctx, runner, status.RunnerFailed, false,
+			"The Toolbox of GitLab is required to create the runner. Provide the runner authentication token instead")
+
+		return false, err
+	}
+
+	if controllerutil.AddFinalizer(runner, runnerFinalizer) {
+		if err := r.Update(ctx, runner); err != nil {
+			return false, err
+		}
+	}
+
+	if err := r.createTokenSecretIfMissing(ctx, runner, spec); err != nil {
+		return false, err
+	}
+
+	job, err := gitlabctl.RunnerRegistrationJob(adapter, template, spec)
+	if err != nil {
+		return false, err
+	}
+
+	lookup := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(job), lookup); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+
+		lookup = nil
+	}
+
+	gitLabRunning := adapter.Origin().(*apiv1beta1.GitLab).Status.Phase == status.PhaseRunning
+
+	switch internal.RunnerRegistrationPhase(internal.JobOutcomeOf(lookup), gitLabRunning) {
+	case status.RunnerPending:
+		_, err := r.setPhase(ctx, runner, status.RunnerPending, false,
+			fmt.Sprintf("Waiting for GitLab %s to be running", adapter.Name().Name))
+
+		return false, err
+	case status.RunnerRegistering:
+		if lookup != nil {
+			return false, nil
+		}
+
+		if err := controllerutil.SetControllerReference(runner, job, r.Scheme); err != nil {
+			return false, err
+		}
+
+		if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return false, err
+		}
+
+		r.Recorder.Event(runner, "Normal", "RunnerRegistrationStarted",
+			fmt.Sprintf("Creating the runner in GitLab with Job %s", job.Name))
+
+		_, err := r.setPhase(ctx, runner, status.RunnerRegistering, false,
+			fmt.Sprintf("Creating the runner in GitLab with Job %s", job.Name))
+
+		return false, err
+	case status.RunnerFailed:
+		message := fmt.Sprintf("Job %s failed to create the runner in GitLab. Delete the Job to retry", job.Name)
+
+		if runner.Status.Phase != status.RunnerFailed {
+			r.Recorder.Event(runner, "Warning", "RunnerRegistrationFailed", message)
+		}
+
+		_, err := r.setPhase(ctx, runner, status.RunnerFailed, false, message)
+
+		return false, err
+	}
+
+	if err := r.recordRunnerRegistration(ctx, runner, lookup); err != nil {
+		return false, err
+	}
+
+	return true, r.deleteStaleRegistrationJobs(ctx, spec, job.Name)
+}
+
+// finalizeRunner deletes the runner that the Operator created from GitLab and
+// then removes the finalizer, so that the resource is removed.
+func (r *GitLabRunnerReconciler) finalizeRunner(ctx context.Context, runner *apiv1beta1.GitLabRunner) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(runner, runnerFinalizer) {
+		return doNotRequeue()
+	}
+
+	unregistered, err := r.unregisterRunner(ctx, runner)
+	if err != nil {
+		return requeue(err)
+	}
+
+	if !unregistered {
+		return requeueWithDelay()
+	}
+
+	controllerutil.RemoveFinalizer(runner, runnerFinalizer)
+
+	if err := r.Update(ctx, runner); err != nil {
+		return requeue(err)
+	}
+
+	return doNotRequeue()
+}
+
+// unregisterRunner runs the Job that deletes the runner from GitLab. It
+// returns true once the runner is deleted, or when it can not be deleted
+// because the GitLab instance, its Toolbox or the runner authentication token
+// does not exist anymore.
+//
+// A failed Job is kept until it is deleted to retry. Remove the finalizer to
+// remove the resource without deleting the runner.
+func (r *GitLabRunnerReconciler) unregisterRunner(ctx context.Context, runner *apiv1beta1.GitLabRunner) (bool, error) {
+	log := r.Log.WithValues("gitlabrunner", client.ObjectKeyFromObject(runner))
+
+	if runner.Status.RunnerID == 0 {
+		return true, nil
+	}
+
+	instance := &apiv1beta1.GitLab{}
+	if err := r.Get(ctx, types.NamespacedName{Name: runner.Spec.GitLab.Name, Namespace: runner.Namespace}, instance); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("GitLab does not exist anymore, skipping the deletion of the runner")
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	if !instance.DeletionTimestamp.IsZero() {
+		log.Info("GitLab is being deleted, skipping the deletion of the runner")
+		return true, nil
+	}
+
+	rtCtx := rt.NewContext(ctx,
+		rt.WithLogger(log),
+		rt.WithClient(r.Client),
+		rt.WithEventRecorder(r.Recorder))
+
+	adapter, err := adapter.NewV1Beta1(rtCtx, instance)
+	if err != nil {
+		return false, err
+	}
+
+	if !adapter.WantsComponent(component.Toolbox) {
+		r.Recorder.Event(runner, "Warning", "RunnerUnregistrationSkipped",
+			fmt.Sprintf("The Toolbox of GitLab is required to delete runner %d from GitLab", runner.Status.RunnerID))
+
+		return true, nil
+	}
+
+	template, err := gitlabctl.GetTemplate(adapter)
+	if err != nil {
+		return false, err
+	}
+
+	spec := runnerSpec(adapter, runner)
+
+	tokenKey := types.NamespacedName{Name: gitlabctl.RunnerTokenSecretName(spec), Namespace: spec.Namespace}
+	if err := r.Get(ctx, tokenKey, &corev1.Secret{}); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("runner authentication token does not exist anymore, skipping the deletion of the runner")
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	job, err := gitlabctl.RunnerUnregistrationJob(adapter, template, spec, runner.Status.RunnerID)
+	if err != nil {
+		return false, err
+	}
+
+	lookup := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(job), lookup); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+
+		if err := controllerutil.SetControllerReference(runner, job, r.Scheme); err != nil {
+			return false, err
+		}
+
+		if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return false, err
+		}
+
+		r.Recorder.Event(runner, "Normal", "RunnerUnregistrationStarted",
+			fmt.Sprintf("Deleting runner %d from GitLab with Job %s", runner.Status.RunnerID, job.Name))
+
+		return false, nil
+	}
+
+	if !isJobFinished(lookup) {
+		return false, nil
+	}
+
+	if lookup.Status.Succeeded == 0 {
+		message := fmt.Sprintf("Job %s failed to delete runner %d from GitLab. Delete the Job to retry, or remove the %s finalizer to skip it",
+			job.Name, runner.Status.RunnerID, runnerFinalizer)
+
+		if runner.Status.Phase != status.RunnerFailed {
+			r.Recorder.Event(runner, "Warning", "RunnerUnregistrationFailed", message)
+		}
+
+		_, err := r.setPhase(ctx, runner, status.RunnerFailed, false, message)
+
+		return false, err
+	}
+
+	r.Recorder.Event(runner, "Normal", "RunnerUnregistered",
+		fmt.Sprintf("Deleted runner %d from GitLab", runner.Status.RunnerID))
+
+	return true, nil
+}
+
+// recordRunnerRegistration records the ID of the runner that the registration
+// Job reported.
+func (r *GitLabRunnerReconciler) recordRunnerRegistration(ctx context.Context, runner *apiv1beta1.GitLabRunner, job *batchv1.Job) error {
+	message, err := jobTerminationMessage(ctx, r, job)
+	if err != nil {
+		return err
+	}
+
+	// The Pod of the Job may be deleted. The runner is already recorded then.
+	if message == "" && runner.Status.RunnerID != 0 {
+		return nil
+	}
+
+	registration, err := internal.ParseTerminationMessage[internal.RunnerRegistration](message)
+	if err != nil {
+		return err
+	}
+
+	if runner.Status.RunnerID != registration.ID {
+		r.Recorder.Event(runner, "Normal", "RunnerRegistered",
+			fmt.Sprintf("Created runner %d in GitLab", registration.ID))
+	}
+
+	runner.Status.RunnerID = registration.ID
+	meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
+		Type:    status.ConditionRunnerRegistered.Name(),
+		Status:  metav1.ConditionTrue,
+		Reason:  status.ConditionRunnerRegistered.Name(),
+		Message: fmt.Sprintf("The runner is created in GitLab with Job %s", job.Name),
+	})
+
+	return nil
+}
+
+func (r *GitLabRunnerReconciler) createTokenSecretIfMissing(ctx context.Context, runner *apiv1beta1.GitLabRunner, spec gitlabctl.Runner) error {
+	lookupKey := types.NamespacedName{Name: spec.TokenSecret, Namespace: spec.Namespace}
+
+	if err := r.Get(ctx, lookupKey, &corev1.Secret{}); err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	secret, err := gitlabctl.RunnerTokenSecret(spec)
+	if err != nil {
+		return fmt.Errorf("failed to generate secret %s: %w", spec.TokenSecret, err)
+	}
+
+	if err := controllerutil.SetControllerReference(runner, secret, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, secret); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	r.Log.Info("generated secret", "gitlabrunner", client.ObjectKeyFromObject(runner), "secret", secret.Name)
+
+	return nil
+}
+
+// deleteStaleRegistrationJobs deletes the registration Jobs of the previous
+// tags of the runner.
+func (r *GitLabRunnerReconciler) deleteStaleRegistrationJobs(ctx context.Context, spec gitlabctl.Runner, current string) error {
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(spec.Namespace), client.MatchingLabels(spec.Labels)); err != nil {
+		return err
+	}
+
+	propagation := metav1.DeletePropagationBackground
+
+	for i := range jobs.Items {
+		if jobs.Items[i].Name == current {
+			continue
+		}
+
+		if err := r.Delete(ctx, &jobs.Items[i], &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *GitLabRunnerReconciler) createOrPatch(ctx context.Context, runner *apiv1beta1.GitLabRunner, templateObject client.Object) error {
+	key := client.ObjectKeyFromObject(templateObject)
+
+	logger := r.Log.WithValues(
+		"gitlabrunner", client.ObjectKeyFromObject(runner),
+		"type", fmt.Sprintf("%T", templateObject),
+		"reference", key)
+
+	obj := templateObject.DeepCopyObject().(client.Object)
+
+	if err := controllerutil.SetControllerReference(runner, obj, r.Scheme); err != nil {
+		return err
+	}
+
+	_, err := kube.ApplyObject(obj, apply.WithContext(ctx),
+		apply.WithClient(r.Client), apply.WithLogger(logger))
+
+	return err
+}
+
+// setPhase records the phase of the runner and requeues the runner until it
+// is running.
+func (r *GitLabRunnerReconciler) setPhase(ctx context.Context, runner *apiv1beta1.GitLabRunner, phase string, available bool, message string) (ctrl.Result, error) {
+	conditionStatus := metav1.ConditionFalse
+	if available {
+		conditionStatus = metav1.ConditionTrue
+	}
+
+	runner.Status.Phase = phase
+	meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
+		Type:    status.ConditionAvailable.Name(),
+		Status:  conditionStatus,
+		Reason:  phase,
+		Message: message,
+	})
+
+	if err := r.Status().Update(ctx, runner); err != nil {
+		return requeue(err)
+	}
+
+	if phase == status.RunnerRunning {
+		return doNotRequeue()
+	}
+
+	return requeueWithDelay()
+}
@@ -9,6 +9,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
 	feature "gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/features"
 )
 
@@ -30,11 +31,6 @@ func GetIssuerConfig(adapter gitlab.Adapter) certmanagerv1.IssuerConfig {
 			server = specCertIssuerServer
 		}
 
-		ingressClass := adapter.Values().GetString("global.ingress.class")
-		if ingressClass == "" {
-			ingressClass = fmt.Sprintf("%s-nginx", adapter.ReleaseName())
-		}
-
 		return certmanagerv1.IssuerConfig{
 			ACME: &acmev1.ACMEIssuer{
 				Email:  email,
@@ -45,14 +41,7 @@ func GetIssuerConfig(adapter gitlab.Adapter) certmanagerv1.IssuerConfig {
 					},
 				},
 				Solvers: []acmev1.ACMEChallengeSolver{
-					{
-						Selector: &acmev1.CertificateDNSNameSelector{},
-						HTTP01: &acmev1.ACMEChallengeSolverHTTP01{
-							Ingress: &acmev1.ACMEChallengeSolverHTTP01Ingress{
-								Class: &ingressClass,
-							},
-						},
-					},
+					issuerSolver(adapter),
 				},
 			},
 		}
@@ -63,6 +52,52 @@ func GetIssuerConfig(adapter gitlab.Adapter) certmanagerv1.IssuerConfig {
 	}
 }
 
+// issuerSolver returns the ACME challenge solver of the Issuer. It is the
+// DNS-01 solver of the GitLab resource, if specified, or an HTTP-01 solver
+// that uses the NGINX Ingress Controller.
+func issuerSolver(adapter gitlab.Adapter) acmev1.ACMEChallengeSolver {
+	if dns01 := adapter.CertManagerDNS01(); dns01 != nil {
+		return acmev1.ACMEChallengeSolver{
+			Selector: &acmev1.CertificateDNSNameSelector{},
+			DNS01:    dns01.DeepCopy(),
+		}
+	}
+
+	ingressClass := adapter.Values().GetString("global.ingress.class")
+	if ingressClass == "" {
+		ingressClass = fmt.Sprintf("%s-nginx", adapter.ReleaseName())
+	}
+
+	return acmev1.ACMEChallengeSolver{
+		Selector: &acmev1.CertificateDNSNameSelector{},
+		HTTP01: &acmev1.ACMEChallengeSolverHTTP01{
+			Ingress: &acmev1.ACMEChallengeSolverHTTP01Ingress{
+				Class: &ingressClass,
+			},
+		},
+	}
+}
+
+// RequiresCertificateIssuer returns true when an enabled host uses the Issuer
+// of the Operator. The Issuer is not needed when all the hosts reference
+// existing issuers.
+func RequiresCertificateIssuer(adapter gitlab.Adapter) bool {
+	hosts := map[string]bool{
+		gitlab.CertManagerHostGitLab:   true,
+		gitlab.CertManagerHostRegistry: adapter.WantsComponent(component.Registry),
+		gitlab.CertManagerHostPages:    adapter.WantsComponent(component.GitLabPages),
+		gitlab.CertManagerHostKAS:      adapter.WantsComponent(component.GitLabKAS),
+	}
+
+	for host, enabled := range hosts {
+		if enabled && adapter.CertManagerIssuer(host) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
 // CertificateIssuer create a certificate generator.
 func CertificateIssuer(adapter gitlab.Adapter) *certmanagerv1.Issuer {
 	labels := ResourceLabels(adapter.ReleaseName(), "issuer", GitlabType)
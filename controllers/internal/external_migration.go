@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+// ExternalMigrationCounts is the amount of data that the migration Job
+// copied from a bundled service to an external service. It is the number of
+// rows of all tables for PostgreSQL and the number of keys for Redis.
+type ExternalMigrationCounts struct {
+	// Source is the count of the bundled service.
+	Source int64 `json:"source"`
+
+	// Destination is the count of the external service.
+	Destination int64 `json:"destination"`
+}
+
+// NextExternalMigrationPhase returns the phase of the migration of a service
+// for the outcome of its Job.
+//
+// A migration that is not running starts when its Job does not exist and the
+// bundled service is ready. The failed Job of a previous attempt is kept
+// until it is deleted, so the migration does not start again before that. A
+// running migration completes or fails with its Job.
+func NextExternalMigrationPhase(phase string, job JobOutcome, serviceReady bool) string {
+	if phase != status.ExternalMigrationMigrating {
+		if job != JobMissing || !serviceReady {
+			return phase
+		}
+
+		return status.ExternalMigrationMigrating
+	}
+
+	switch job {
+	case JobSucceeded:
+		return status.ExternalMigrationCompleted
+	case JobFailed:
+		return status.ExternalMigrationFailed
+	default:
+		return status.ExternalMigrationMigrating
+	}
+}
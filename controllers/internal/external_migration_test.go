@@ -0,0 +1,32 @@
+package internal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+var _ = Describe("External migration", func() {
+	DescribeTable("Deciding the phase of the migration",
+		func(phase string, job JobOutcome, serviceReady bool, expected string) {
+			Expect(NextExternalMigrationPhase(phase, job, serviceReady)).To(Equal(expected))
+		},
+		Entry("waits for the bundled service",
+			"", JobMissing, false, ""),
+		Entry("starts when the bundled service is ready",
+			"", JobMissing, true, status.ExternalMigrationMigrating),
+		Entry("keeps the failed Job of the previous attempt",
+			status.ExternalMigrationFailed, JobFailed, true, status.ExternalMigrationFailed),
+		Entry("starts again when the failed Job is deleted",
+			status.ExternalMigrationFailed, JobMissing, true, status.ExternalMigrationMigrating),
+		Entry("keeps running while the Job is created",
+			status.ExternalMigrationMigrating, JobMissing, false, status.ExternalMigrationMigrating),
+		Entry("keeps running while the Job runs",
+			status.ExternalMigrationMigrating, JobRunning, false, status.ExternalMigrationMigrating),
+		Entry("fails with the Job",
+			status.ExternalMigrationMigrating, JobFailed, false, status.ExternalMigrationFailed),
+		Entry("completes with the Job",
+			status.ExternalMigrationMigrating, JobSucceeded, false, status.ExternalMigrationCompleted),
+	)
+})
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// PostgreSQLReplicationStatus is the replication state of the database of a
+// Geo site.
+type PostgreSQLReplicationStatus struct {
+	// InRecovery is true when the server is a read-only replica.
+	InRecovery bool
+
+	// ReplayLag is the number of bytes of the WAL that the replica received
+	// from the primary database but did not replay yet.
+	ReplayLag int64
+
+	// StreamingReplicas is the number of replicas that stream from the
+	// primary database.
+	StreamingReplicas int
+}
+
+// ValidatePrimary checks that the database of a primary site is writable and
+// streams to at least one replica.
+func (s PostgreSQLReplicationStatus) ValidatePrimary() error {
+	if s.InRecovery {
+		return errors.New("the database of the primary site is a read-only replica")
+	}
+
+	if s.StreamingReplicas == 0 {
+		return errors.New("no replica streams from the database of the primary site")
+	}
+
+	return nil
+}
+
+// ValidateSecondary checks that the database that a secondary site reads from
+// is a replica that is at most maximumLag behind the primary database.
+func (s PostgreSQLReplicationStatus) ValidateSecondary(maximumLag int64) error {
+	if !s.InRecovery {
+		return errors.New("the database of the primary site is not a read-only replica")
+	}
+
+	if s.ReplayLag > maximumLag {
+		return fmt.Errorf("the replica of the primary database is %s of WAL behind, more than %s",
+			resource.NewQuantity(s.ReplayLag, resource.BinarySI), resource.NewQuantity(maximumLag, resource.BinarySI))
+	}
+
+	return nil
+}
+
+// ProbePostgreSQLReplication connects to the PostgreSQL server and reports
+// whether it is a replica and how far it is behind, or how many replicas
+// stream from it.
+func ProbePostgreSQLReplication(ctx context.Context, endpoint PostgreSQLEndpoint) (*PostgreSQLReplicationStatus, error) {
+	db, err := connectPostgreSQL(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	status := &PostgreSQLReplicationStatus{}
+
+	if err := db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&status.InRecovery); err != nil {
+		return nil, fmt.Errorf("failed to query recovery state: %w", err)
+	}
+
+	if status.InRecovery {
+		/* The lag is measured in WAL rather than in time, because the time
+		   since the last replayed transaction grows while the primary database
+		   is idle. */
+		if err := db.QueryRowContext(ctx,
+			"SELECT COALESCE(pg_wal_lsn_diff(pg_last_wal_receive_lsn(), pg_last_wal_replay_lsn()), 0)::bigint").Scan(&status.ReplayLag); err != nil {
+			return nil, fmt.Errorf("failed to query replication lag: %w", err)
+		}
+
+		return status, nil
+	}
+
+	/* The state of the replicas is NULL for roles without pg_read_all_stats,
+	   for example the user of an external database. Their replicas are
+	   counted as streaming. */
+	if err := db.QueryRowContext(ctx,
+		"SELECT count(*) FROM pg_stat_replication WHERE state = 'streaming' OR state IS NULL").Scan(&status.StreamingReplicas); err != nil {
+		return nil, fmt.Errorf("failed to query replicas: %w", err)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Geo replication", func() {
+	Context("Primary site", func() {
+		It("Should accept a database with streaming replicas", func() {
+			status := PostgreSQLReplicationStatus{StreamingReplicas: 1}
+			Expect(status.ValidatePrimary()).To(Succeed())
+		})
+
+		It("Should report a database without replicas", func() {
+			status := PostgreSQLReplicationStatus{}
+			Expect(status.ValidatePrimary()).To(MatchError(ContainSubstring("no replica streams")))
+		})
+
+		It("Should report a read-only replica", func() {
+			status := PostgreSQLReplicationStatus{InRecovery: true}
+			Expect(status.ValidatePrimary()).To(MatchError(ContainSubstring("is a read-only replica")))
+		})
+	})
+
+	Context("Secondary site", func() {
+		It("Should accept a replica that is up to date", func() {
+			status := PostgreSQLReplicationStatus{InRecovery: true, ReplayLag: 4096}
+			Expect(status.ValidateSecondary(64 << 20)).To(Succeed())
+		})
+
+		It("Should report a replica that falls behind", func() {
+			status := PostgreSQLReplicationStatus{InRecovery: true, ReplayLag: 96 << 20}
+			Expect(status.ValidateSecondary(64 << 20)).To(MatchError(
+				"the replica of the primary database is 96Mi of WAL behind, more than 64Mi"))
+		})
+
+		It("Should report a writable database", func() {
+			status := PostgreSQLReplicationStatus{}
+			Expect(status.ValidateSecondary(64 << 20)).To(MatchError(ContainSubstring("is not a read-only replica")))
+		})
+	})
+})
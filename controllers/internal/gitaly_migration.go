@@ -0,0 +1,51 @@
+package internal
+
+// GitalyMigrationCheck is the progress of the migration from the standalone
+// Gitaly to Praefect, as reported by the check Job.
+type GitalyMigrationCheck struct {
+	// Remaining is the number of repositories in the source storage.
+	Remaining int64 `json:"remaining"`
+
+	// InProgress is the number of scheduled or running repository moves.
+	InProgress int64 `json:"inProgress"`
+
+	// Failed is the number of failed repository moves.
+	Failed int64 `json:"failed"`
+}
+
+// GitalyMigrationStep is what the migration from the standalone Gitaly to
+// Praefect does after its progress is checked.
+type GitalyMigrationStep string
+
+const (
+	// GitalyMigrationDone completes the migration and retires the standalone
+	// Gitaly.
+	GitalyMigrationDone GitalyMigrationStep = "Done"
+
+	// GitalyMigrationWait waits for the scheduled moves to finish.
+	GitalyMigrationWait GitalyMigrationStep = "Wait"
+
+	// GitalyMigrationRetry schedules the moves of the remaining repositories
+	// again.
+	GitalyMigrationRetry GitalyMigrationStep = "Retry"
+
+	// GitalyMigrationGiveUp fails the migration.
+	GitalyMigrationGiveUp GitalyMigrationStep = "GiveUp"
+)
+
+// NextGitalyMigrationStep returns the step of the migration for the reported
+// progress of the current attempt. The moves are scheduled again while
+// repositories remain in the source storage and no moves are in progress,
+// until the maximum number of attempts is reached.
+func NextGitalyMigrationStep(check GitalyMigrationCheck, attempt, maxAttempts int32) GitalyMigrationStep {
+	switch {
+	case check.Remaining == 0:
+		return GitalyMigrationDone
+	case check.InProgress > 0:
+		return GitalyMigrationWait
+	case attempt < maxAttempts:
+		return GitalyMigrationRetry
+	default:
+		return GitalyMigrationGiveUp
+	}
+}
@@ -0,0 +1,28 @@
+package internal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gitaly migration", func() {
+	DescribeTable("Deciding the next step after the progress is checked",
+		func(check GitalyMigrationCheck, attempt int32, expected GitalyMigrationStep) {
+			Expect(NextGitalyMigrationStep(check, attempt, 3)).To(Equal(expected))
+		},
+		Entry("completes when the source storage is empty",
+			GitalyMigrationCheck{}, int32(1), GitalyMigrationDone),
+		Entry("completes on the last attempt when the source storage is empty",
+			GitalyMigrationCheck{Failed: 2}, int32(3), GitalyMigrationDone),
+		Entry("waits while moves are in progress",
+			GitalyMigrationCheck{Remaining: 12, InProgress: 10}, int32(1), GitalyMigrationWait),
+		Entry("waits on the last attempt while moves are in progress",
+			GitalyMigrationCheck{Remaining: 12, InProgress: 10, Failed: 2}, int32(3), GitalyMigrationWait),
+		Entry("retries when moves failed",
+			GitalyMigrationCheck{Remaining: 2, Failed: 2}, int32(1), GitalyMigrationRetry),
+		Entry("retries when repositories were added after the moves were scheduled",
+			GitalyMigrationCheck{Remaining: 1}, int32(2), GitalyMigrationRetry),
+		Entry("gives up after the last attempt",
+			GitalyMigrationCheck{Remaining: 2, Failed: 2}, int32(3), GitalyMigrationGiveUp),
+	)
+})
@@ -43,6 +43,24 @@ func AsDeployment(obj client.Object) (*appsv1.Deployment, error) {
 	return deployment, nil
 }
 
+func AsStatefulSet(obj client.Object) (*appsv1.StatefulSet, error) {
+	statefulSet, ok := obj.(*appsv1.StatefulSet)
+	if !ok {
+		return nil, helm.NewTypeMistmatchError(statefulSet, obj)
+	}
+
+	return statefulSet, nil
+}
+
+func AsPersistentVolumeClaim(obj client.Object) (*corev1.PersistentVolumeClaim, error) {
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return nil, helm.NewTypeMistmatchError(pvc, obj)
+	}
+
+	return pvc, nil
+}
+
 func ToggleDeploymentPause(obj client.Object, pause bool) error {
 	deployment, err := AsDeployment(obj)
 	if err != nil {
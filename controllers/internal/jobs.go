@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// JobOutcome is the state of a Job that the Operator waits for.
+type JobOutcome string
+
+const (
+	// JobMissing means that the Job does not exist.
+	JobMissing JobOutcome = "Missing"
+
+	// JobRunning means that the Job is not finished yet.
+	JobRunning JobOutcome = "Running"
+
+	// JobSucceeded means that the Job completed.
+	JobSucceeded JobOutcome = "Succeeded"
+
+	// JobFailed means that the Job failed.
+	JobFailed JobOutcome = "Failed"
+)
+
+// JobOutcomeOf returns the outcome of the Job. A nil Job is missing.
+func JobOutcomeOf(job *batchv1.Job) JobOutcome {
+	if job == nil {
+		return JobMissing
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return JobSucceeded
+		case batchv1.JobFailed:
+			return JobFailed
+		}
+	}
+
+	return JobRunning
+}
+
+// reportValidator is implemented by the reports of the Jobs that have
+// required fields.
+type reportValidator interface {
+	validate() error
+}
+
+// ParseTerminationMessage parses the JSON report that a Job writes to the
+// termination message of its Pod.
+func ParseTerminationMessage[T any](message string) (T, error) {
+	var report T
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return report, fmt.Errorf("the Job did not report a result")
+	}
+
+	if err := json.Unmarshal([]byte(message), &report); err != nil {
+		return report, fmt.Errorf("can not parse the reported result: %w", err)
+	}
+
+	if validator, ok := any(&report).(reportValidator); ok {
+		if err := validator.validate(); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Jobs", func() {
+	It("Should parse the termination message of a Job", func() {
+		check, err := ParseTerminationMessage[GitalyMigrationCheck]("{\"remaining\":12,\"inProgress\":10,\"failed\":2}\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(check).To(Equal(GitalyMigrationCheck{Remaining: 12, InProgress: 10, Failed: 2}))
+
+		_, err = ParseTerminationMessage[GitalyMigrationCheck]("\n")
+		Expect(err).To(MatchError(ContainSubstring("did not report a result")))
+
+		_, err = ParseTerminationMessage[GitalyMigrationCheck]("rails runner failed")
+		Expect(err).To(MatchError(ContainSubstring("can not parse")))
+	})
+
+	It("Should return the outcome of a Job", func() {
+		job := &batchv1.Job{}
+
+		Expect(JobOutcomeOf(nil)).To(Equal(JobMissing))
+		Expect(JobOutcomeOf(job)).To(Equal(JobRunning))
+
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+		Expect(JobOutcomeOf(job)).To(Equal(JobFailed))
+
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		Expect(JobOutcomeOf(job)).To(Equal(JobSucceeded))
+	})
+})
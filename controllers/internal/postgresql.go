@@ -0,0 +1,330 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgreSQLRequiredExtensions are the extensions that GitLab requires.
+var PostgreSQLRequiredExtensions = []string{"btree_gist", "pg_trgm", "plpgsql"}
+
+// PostgreSQLEndpoint describes how to connect to a PostgreSQL server.
+type PostgreSQLEndpoint struct {
+	Host     string
+	Port     int
+	Database string
+	Username string
+	Password string
+
+	// TLS settings in PEM format. TLS is verified against ServerCA when it
+	// is set. Otherwise TLS is preferred but not required.
+	ServerCA          []byte
+	ClientCertificate []byte
+	ClientKey         []byte
+
+	ConnectTimeout time.Duration
+}
+
+// PostgreSQLProbeResult is the outcome of probing a PostgreSQL server.
+type PostgreSQLProbeResult struct {
+	// ServerVersion is the numeric server version, for example 130011.
+	ServerVersion int
+
+	// MissingExtensions lists the required extensions that are not available.
+	MissingExtensions []string
+}
+
+// MajorVersion returns the major version of the PostgreSQL server.
+func (r PostgreSQLProbeResult) MajorVersion() int {
+	return r.ServerVersion / 10000
+}
+
+// Validate checks the probe result against the minimum major version and
+// returns an error that describes all of the problems.
+func (r PostgreSQLProbeResult) Validate(minimumMajorVersion int) error {
+	problems := []string{}
+
+	if r.MajorVersion() < minimumMajorVersion {
+		problems = append(problems, fmt.Sprintf("server version %d is older than the minimum required version %d",
+			r.MajorVersion(), minimumMajorVersion))
+	}
+
+	if len(r.MissingExtensions) > 0 {
+		problems = append(problems, fmt.Sprintf("required extensions are not available: %s",
+			strings.Join(r.MissingExtensions, ", ")))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// ProbePostgreSQL connects to the PostgreSQL server with the provided
+// credentials and TLS settings and checks its version and the availability of
+// the required extensions.
+func ProbePostgreSQL(ctx context.Context, endpoint PostgreSQLEndpoint) (*PostgreSQLProbeResult, error) {
+	db, err := connectPostgreSQL(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	result := &PostgreSQLProbeResult{}
+
+	if err := db.QueryRowContext(ctx, "SHOW server_version_num").Scan(&result.ServerVersion); err != nil {
+		return nil, fmt.Errorf("failed to query server version: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pg_available_extensions WHERE name = ANY($1)",
+		pq.Array(PostgreSQLRequiredExtensions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query available extensions: %w", err)
+	}
+
+	defer rows.Close()
+
+	available := map[string]struct{}{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		available[name] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range PostgreSQLRequiredExtensions {
+		if _, ok := available[name]; !ok {
+			result.MissingExtensions = append(result.MissingExtensions, name)
+		}
+	}
+
+	sort.Strings(result.MissingExtensions)
+
+	return result, nil
+}
+
+// PostgreSQLDatabase describes a database and the role that owns it.
+type PostgreSQLDatabase struct {
+	Database string
+	Role     string
+	Password string
+}
+
+// ProvisionPostgreSQLDatabase connects to the PostgreSQL server as a
+// superuser and creates the role and the database when they do not exist. The
+// password of an existing role is only updated when the role can not log in
+// with the password of the Secret, so that the password is not written, and
+// possibly logged, on every reconcile. It returns true when the role or the
+// database were created.
+func ProvisionPostgreSQLDatabase(ctx context.Context, endpoint PostgreSQLEndpoint, database PostgreSQLDatabase) (bool, error) {
+	db, err := connectPostgreSQL(ctx, endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	roleExists := false
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)",
+		database.Role).Scan(&roleExists); err != nil {
+		return false, fmt.Errorf("failed to query role %s: %w", database.Role, err)
+	}
+
+	databaseExists := false
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)",
+		database.Database).Scan(&databaseExists); err != nil {
+		return false, fmt.Errorf("failed to query database %s: %w", database.Database, err)
+	}
+
+	passwordChanged := !roleExists || !database.canLogin(ctx, endpoint, databaseExists)
+
+	for _, statement := range database.provisioningStatements(roleExists, passwordChanged, databaseExists) {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return false, fmt.Errorf("failed to provision database %s: %w", database.Database, err)
+		}
+	}
+
+	return !roleExists || !databaseExists, nil
+}
+
+// canLogin returns true when the role logs in to the server with the
+// password. It connects to the database of the role when it exists.
+func (d PostgreSQLDatabase) canLogin(ctx context.Context, endpoint PostgreSQLEndpoint, databaseExists bool) bool {
+	login := endpoint
+	login.Username = d.Role
+	login.Password = d.Password
+	login.ClientCertificate = nil
+	login.ClientKey = nil
+
+	if databaseExists {
+		login.Database = d.Database
+	}
+
+	db, err := connectPostgreSQL(ctx, login)
+	if err != nil {
+		return false
+	}
+
+	db.Close()
+
+	return true
+}
+
+// provisioningStatements returns the statements that create or update the
+// role and create the database. CREATE DATABASE can not run in a transaction
+// block, so each statement runs on its own.
+func (d PostgreSQLDatabase) provisioningStatements(roleExists, passwordChanged, databaseExists bool) []string {
+	role := pq.QuoteIdentifier(d.Role)
+	password := pq.QuoteLiteral(d.Password)
+
+	statements := []string{}
+
+	if roleExists {
+		if passwordChanged {
+			statements = append(statements, fmt.Sprintf("ALTER ROLE %s WITH LOGIN PASSWORD %s", role, password))
+		}
+	} else {
+		statements = append(statements, fmt.Sprintf("CREATE ROLE %s WITH LOGIN PASSWORD %s", role, password))
+	}
+
+	if !databaseExists {
+		statements = append(statements, fmt.Sprintf("CREATE DATABASE %s WITH OWNER %s",
+			pq.QuoteIdentifier(d.Database), role))
+	}
+
+	return statements
+}
+
+// PostgreSQLImageMajorVersion returns the major version of PostgreSQL from
+// the tag of the image, for example 14 for `bitnami/postgresql:14.8.0`. It
+// returns 0 when the tag does not start with a version.
+func PostgreSQLImageMajorVersion(image string) int {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return 0
+	}
+
+	tag := image[i+1:]
+	end := 0
+
+	for end < len(tag) && tag[end] >= '0' && tag[end] <= '9' {
+		end++
+	}
+
+	version, err := strconv.Atoi(tag[:end])
+	if err != nil {
+		return 0
+	}
+
+	return version
+}
+
+// ConnectionString returns the connection string of the endpoint in the
+// key/value format of libpq.
+func (e PostgreSQLEndpoint) ConnectionString(sslMode string) string {
+	params := map[string]string{
+		"host":     e.Host,
+		"port":     fmt.Sprintf("%d", e.Port),
+		"dbname":   e.Database,
+		"user":     e.Username,
+		"password": e.Password,
+		"sslmode":  sslMode,
+	}
+
+	if e.ConnectTimeout > 0 {
+		params["connect_timeout"] = fmt.Sprintf("%d", int(e.ConnectTimeout.Seconds()))
+	}
+
+	if sslMode != "disable" && (len(e.ServerCA) > 0 || len(e.ClientCertificate) > 0) {
+		params["sslinline"] = "true"
+
+		if len(e.ServerCA) > 0 {
+			params["sslrootcert"] = string(e.ServerCA)
+		}
+
+		if len(e.ClientCertificate) > 0 && len(e.ClientKey) > 0 {
+			params["sslcert"] = string(e.ClientCertificate)
+			params["sslkey"] = string(e.ClientKey)
+		}
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, quoteConnectionValue(params[k])))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (e PostgreSQLEndpoint) sslMode() string {
+	if len(e.ServerCA) > 0 {
+		return "verify-ca"
+	}
+
+	return "require"
+}
+
+// connectPostgreSQL opens a connection with TLS. It falls back to an
+// unencrypted connection when the server does not support TLS, unless the
+// server CA is set.
+func connectPostgreSQL(ctx context.Context, endpoint PostgreSQLEndpoint) (*sql.DB, error) {
+	db, err := openPostgreSQL(ctx, endpoint, endpoint.sslMode())
+	if errors.Is(err, pq.ErrSSLNotSupported) && len(endpoint.ServerCA) == 0 {
+		// TLS is preferred but the server does not support it.
+		db, err = openPostgreSQL(ctx, endpoint, "disable")
+	}
+
+	return db, err
+}
+
+func openPostgreSQL(ctx context.Context, endpoint PostgreSQLEndpoint, sslMode string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(endpoint.ConnectionString(sslMode))
+	if err != nil {
+		return nil, err
+	}
+
+	db := sql.OpenDB(connector)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func quoteConnectionValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return fmt.Sprintf("'%s'", value)
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PostgreSQL probe", func() {
+	Context("Building the connection string", func() {
+		endpoint := PostgreSQLEndpoint{
+			Host:           "db.example.com",
+			Port:           5432,
+			Database:       "gitlabhq_production",
+			Username:       "gitlab",
+			Password:       `it's a \secret`,
+			ConnectTimeout: 5 * time.Second,
+		}
+
+		It("Should quote and escape the values", func() {
+			Expect(endpoint.ConnectionString("require")).To(Equal(
+				`connect_timeout='5' dbname='gitlabhq_production' host='db.example.com' ` +
+					`password='it\'s a \\secret' port='5432' sslmode='require' user='gitlab'`))
+		})
+
+		It("Should inline the TLS certificates", func() {
+			tlsEndpoint := endpoint
+			tlsEndpoint.ServerCA = []byte("CA")
+			tlsEndpoint.ClientCertificate = []byte("CERT")
+			tlsEndpoint.ClientKey = []byte("KEY")
+
+			connStr := tlsEndpoint.ConnectionString(tlsEndpoint.sslMode())
+			Expect(connStr).To(ContainSubstring("sslmode='verify-ca'"))
+			Expect(connStr).To(ContainSubstring("sslinline='true'"))
+			Expect(connStr).To(ContainSubstring("sslrootcert='CA'"))
+			Expect(connStr).To(ContainSubstring("sslcert='CERT'"))
+			Expect(connStr).To(ContainSubstring("sslkey='KEY'"))
+		})
+
+		It("Should not use TLS settings when TLS is disabled", func() {
+			tlsEndpoint := endpoint
+			tlsEndpoint.ServerCA = []byte("CA")
+
+			Expect(tlsEndpoint.ConnectionString("disable")).NotTo(ContainSubstring("sslrootcert"))
+		})
+	})
+
+	Context("Validating the probe result", func() {
+		It("Should accept a supported server", func() {
+			result := PostgreSQLProbeResult{ServerVersion: 140009}
+			Expect(result.MajorVersion()).To(Equal(14))
+			Expect(result.Validate(13)).To(Succeed())
+		})
+
+		It("Should report an old server and missing extensions", func() {
+			result := PostgreSQLProbeResult{
+				ServerVersion:     120016,
+				MissingExtensions: []string{"btree_gist", "pg_trgm"},
+			}
+
+			err := result.Validate(13)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("server version 12 is older than the minimum required version 13"))
+			Expect(err.Error()).To(ContainSubstring("btree_gist, pg_trgm"))
+		})
+	})
+
+	Context("Provisioning a database", func() {
+		database := PostgreSQLDatabase{
+			Database: "praefect",
+			Role:     "praefect",
+			Password: "it's secret",
+		}
+
+		It("Should create the role and the database", func() {
+			Expect(database.provisioningStatements(false, true, false)).To(Equal([]string{
+				`CREATE ROLE "praefect" WITH LOGIN PASSWORD 'it''s secret'`,
+				`CREATE DATABASE "praefect" WITH OWNER "praefect"`,
+			}))
+		})
+
+		It("Should only update the password of the existing role when it changed", func() {
+			Expect(database.provisioningStatements(true, true, true)).To(Equal([]string{
+				`ALTER ROLE "praefect" WITH LOGIN PASSWORD 'it''s secret'`,
+			}))
+		})
+
+		It("Should not update the password of the existing role that can log in", func() {
+			Expect(database.provisioningStatements(true, false, true)).To(BeEmpty())
+			Expect(database.provisioningStatements(true, false, false)).To(Equal([]string{
+				`CREATE DATABASE "praefect" WITH OWNER "praefect"`,
+			}))
+		})
+	})
+
+	Context("Reading the major version of the image", func() {
+		It("Should read the major version from the tag", func() {
+			Expect(PostgreSQLImageMajorVersion("docker.io/bitnami/postgresql:14.8.0")).To(Equal(14))
+			Expect(PostgreSQLImageMajorVersion("registry:5000/bitnami/postgresql:12.7.0-debian-10-r0@sha256:abc")).To(Equal(12))
+		})
+
+		It("Should not guess the version without a tag", func() {
+			Expect(PostgreSQLImageMajorVersion("registry:5000/bitnami/postgresql")).To(Equal(0))
+			Expect(PostgreSQLImageMajorVersion("bitnami/postgresql:latest")).To(Equal(0))
+		})
+	})
+})
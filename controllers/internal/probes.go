@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProbeResult is the outcome of the probe of an external service.
+type ProbeResult struct {
+	// Fingerprint identifies the configuration that was probed.
+	Fingerprint string
+
+	// CheckedAt is the time of the probe.
+	CheckedAt time.Time
+
+	// Err is the error of the probe. It is nil when the probe succeeded.
+	Err error
+}
+
+// ProbeCache keeps the results of the probes of external services so that
+// they are not probed on every reconcile.
+type ProbeCache struct {
+	results map[string]ProbeResult
+	locker  sync.Locker
+}
+
+// NewProbeCache returns an empty cache of probe results.
+func NewProbeCache() *ProbeCache {
+	return &ProbeCache{
+		results: map[string]ProbeResult{},
+		locker:  &sync.Mutex{},
+	}
+}
+
+// Lookup returns the result of the last probe of the key. It returns false
+// when the key was not probed yet, when the probe used a different
+// configuration, or when it is older than maxAge.
+func (c *ProbeCache) Lookup(key, fingerprint string, maxAge time.Duration, now time.Time) (ProbeResult, bool) {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+
+	result, ok := c.results[key]
+	if !ok || result.Fingerprint != fingerprint || !now.Before(result.CheckedAt.Add(maxAge)) {
+		return ProbeResult{}, false
+	}
+
+	return result, true
+}
+
+// Store records the result of the probe of the key.
+func (c *ProbeCache) Store(key, fingerprint string, err error, now time.Time) ProbeResult {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+
+	result := ProbeResult{
+		Fingerprint: fingerprint,
+		CheckedAt:   now,
+		Err:         err,
+	}
+
+	c.results[key] = result
+
+	return result
+}
+
+// ProbeFingerprint returns a checksum of the configuration of a probe, for
+// example the endpoint and the content of its credentials.
+func ProbeFingerprint(values ...string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(values, "\x00"))))
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Probe cache", func() {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	It("Should return the result of the last probe of the same configuration", func() {
+		cache := NewProbeCache()
+		fingerprint := ProbeFingerprint("bucket", "credentials")
+
+		_, ok := cache.Lookup("gitlab/bucket", fingerprint, time.Minute, now)
+		Expect(ok).To(BeFalse())
+
+		cache.Store("gitlab/bucket", fingerprint, errors.New("access denied"), now)
+
+		result, ok := cache.Lookup("gitlab/bucket", fingerprint, time.Minute, now.Add(30*time.Second))
+		Expect(ok).To(BeTrue())
+		Expect(result.Err).To(MatchError("access denied"))
+	})
+
+	It("Should probe again when the configuration changes", func() {
+		cache := NewProbeCache()
+		cache.Store("gitlab/bucket", ProbeFingerprint("bucket", "credentials"), nil, now)
+
+		_, ok := cache.Lookup("gitlab/bucket", ProbeFingerprint("bucket", "rotated credentials"), time.Minute, now)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should probe again when the result expires", func() {
+		cache := NewProbeCache()
+		fingerprint := ProbeFingerprint("bucket", "credentials")
+		cache.Store("gitlab/bucket", fingerprint, nil, now)
+
+		_, ok := cache.Lookup("gitlab/bucket", fingerprint, time.Minute, now.Add(time.Minute))
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support/redis"
+)
+
+// RedisEndpoint describes how to connect to a Redis server, either directly
+// or through Sentinels.
+type RedisEndpoint struct {
+	// Host is the address of the Redis server or, when Sentinels are used,
+	// the name of the master group.
+	Host     string
+	Port     int
+	TLS      bool
+	Username string
+	Password string
+
+	// RootCAs are the certificate authorities that are trusted when TLS is
+	// enabled. The system defaults are used when it is nil.
+	RootCAs *x509.CertPool
+
+	// Sentinels are the `host:port` addresses of the Sentinels.
+	Sentinels        []string
+	SentinelPassword string
+
+	Timeout time.Duration
+}
+
+// RedisProbeResult is the outcome of probing a Redis server.
+type RedisProbeResult struct {
+	// Address is the address of the Redis server that was probed.
+	Address string
+
+	// ServerVersion is the version of the Redis server, for example 7.0.12.
+	ServerVersion string
+}
+
+// Validate checks the probe result against the minimum server version.
+func (r RedisProbeResult) Validate(minimumVersion string) error {
+	version, err := semver.NewVersion(r.ServerVersion)
+	if err != nil {
+		return fmt.Errorf("can not parse server version %q: %w", r.ServerVersion, err)
+	}
+
+	minimum, err := semver.NewVersion(minimumVersion)
+	if err != nil {
+		return err
+	}
+
+	if version.LessThan(minimum) {
+		return fmt.Errorf("server version %s is older than the minimum required version %s",
+			r.ServerVersion, minimumVersion)
+	}
+
+	return nil
+}
+
+// ProbeRedis connects to the Redis server with the provided credentials and
+// TLS settings and reads its version. When Sentinels are configured, the
+// address of the master is discovered first.
+func ProbeRedis(ctx context.Context, endpoint RedisEndpoint) (*RedisProbeResult, error) {
+	client, address, err := DialRedis(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Close()
+
+	version, err := client.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query server version of %s: %w", address, err)
+	}
+
+	return &RedisProbeResult{
+		Address:       address,
+		ServerVersion: version,
+	}, nil
+}
+
+// DialRedis connects to the Redis server with the provided credentials and
+// TLS settings and returns the client and the address of the server. When
+// Sentinels are configured, the address of the master is discovered first.
+// The caller must close the client.
+func DialRedis(ctx context.Context, endpoint RedisEndpoint) (*redis.Client, string, error) {
+	options := redis.Options{
+		Timeout: endpoint.Timeout,
+	}
+
+	if endpoint.TLS {
+		options.TLS = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    endpoint.RootCAs,
+		}
+	}
+
+	address := net.JoinHostPort(endpoint.Host, strconv.Itoa(endpoint.Port))
+
+	if len(endpoint.Sentinels) > 0 {
+		sentinelOptions := options
+		sentinelOptions.Password = endpoint.SentinelPassword
+
+		var err error
+
+		if address, err = redis.DiscoverMaster(ctx, endpoint.Sentinels, endpoint.Host, sentinelOptions); err != nil {
+			return nil, "", err
+		}
+	}
+
+	options.Address = address
+	options.Username = endpoint.Username
+	options.Password = endpoint.Password
+
+	client, err := redis.Dial(ctx, options)
+	if err != nil {
+		return nil, "", fmt.Errorf("can not connect to %s: %w", address, err)
+	}
+
+	return client, address, nil
+}
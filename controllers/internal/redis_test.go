@@ -0,0 +1,25 @@
+package internal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redis probe", func() {
+	Context("Validating the probe result", func() {
+		It("Should accept newer versions", func() {
+			Expect(RedisProbeResult{ServerVersion: "7.0.12"}.Validate("6.2.0")).To(Succeed())
+			Expect(RedisProbeResult{ServerVersion: "6.2.0"}.Validate("6.2.0")).To(Succeed())
+		})
+
+		It("Should reject older versions", func() {
+			Expect(RedisProbeResult{ServerVersion: "6.0.16"}.Validate("6.2.0")).To(
+				MatchError(ContainSubstring("older than the minimum required version 6.2.0")))
+		})
+
+		It("Should reject unknown versions", func() {
+			Expect(RedisProbeResult{ServerVersion: "unknown"}.Validate("6.2.0")).To(
+				MatchError(ContainSubstring("can not parse server version")))
+		})
+	})
+})
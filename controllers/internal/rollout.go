@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+// RolloutStep is the next step of the staged rollout of a StatefulSet.
+type RolloutStep struct {
+	// Partition is the ordinal from which the Pods are updated.
+	Partition int32
+
+	// UpdatedReplicas is the number of Pods that run the update revision and
+	// are ready.
+	UpdatedReplicas int32
+
+	// Phase is the phase of the rollout.
+	Phase string
+
+	// Message describes the phase of the rollout.
+	Message string
+}
+
+// NextRolloutStep decides the partition of the StatefulSet so that its Pods
+// are updated one at a time, starting from the highest ordinal. The next Pod
+// is only updated when all updated Pods are ready for at least the soak time.
+//
+// pods maps the ordinals of the existing Pods of the StatefulSet to the Pods.
+// When blocked is true the rollout does not progress, for example because
+// the rollout of another StatefulSet must be completed first.
+//
+// When there are no pending changes the partition is set to the number of
+// replicas, so that the next change of the Pod template does not update any
+// Pod until the rollout progresses.
+func NextRolloutStep(current *appsv1.StatefulSet, replicas int32, pods map[int32]*corev1.Pod, soakTime time.Duration, now time.Time, blocked bool) RolloutStep {
+	partition := int32(0)
+	if update := current.Spec.UpdateStrategy.RollingUpdate; update != nil && update.Partition != nil {
+		partition = *update.Partition
+	}
+
+	if partition > replicas {
+		partition = replicas
+	}
+
+	step := RolloutStep{
+		Partition:       partition,
+		UpdatedReplicas: updatedReadyPods(pods, current.Status.UpdateRevision),
+		Phase:           status.GitalyRolloutRollingOut,
+	}
+
+	if current.Status.ObservedGeneration < current.Generation {
+		step.Message = "Waiting for the StatefulSet controller to observe the changes"
+		return step
+	}
+
+	if current.Status.UpdateRevision == current.Status.CurrentRevision && podsUpdated(pods, current.Status.UpdateRevision) {
+		return RolloutStep{
+			Partition:       replicas,
+			UpdatedReplicas: updatedReadyPods(pods, current.Status.UpdateRevision),
+			Phase:           status.GitalyRolloutCompleted,
+			Message:         "All Pods are up to date",
+		}
+	}
+
+	if blocked {
+		step.Phase = status.GitalyRolloutPending
+		step.Message = "Waiting for the rollout of the previous storage to complete"
+
+		return step
+	}
+
+	soakRemaining := time.Duration(0)
+
+	for ordinal := partition; ordinal < replicas; ordinal++ {
+		name := fmt.Sprintf("%s-%d", current.Name, ordinal)
+		pod := pods[ordinal]
+
+		if pod == nil || pod.Labels[appsv1.ControllerRevisionHashLabelKey] != current.Status.UpdateRevision {
+			step.Message = fmt.Sprintf("Waiting for Pod %s to be updated", name)
+			return step
+		}
+
+		readySince, ready := podReadySince(pod)
+		if !ready {
+			step.Message = fmt.Sprintf("Waiting for Pod %s to become ready", name)
+			return step
+		}
+
+		if remaining := readySince.Add(soakTime).Sub(now); remaining > soakRemaining {
+			soakRemaining = remaining
+		}
+	}
+
+	if soakRemaining > 0 {
+		step.Phase = status.GitalyRolloutSoaking
+		step.Message = fmt.Sprintf("Updated Pods are ready, updating the next Pod in %s",
+			soakRemaining.Round(time.Second))
+
+		return step
+	}
+
+	if partition == 0 {
+		step.Message = "Waiting for the StatefulSet controller to complete the rollout"
+		return step
+	}
+
+	step.Partition = partition - 1
+	step.Message = fmt.Sprintf("Updating Pod %s-%d", current.Name, step.Partition)
+
+	return step
+}
+
+// podsUpdated returns true when the existing Pods run the revision. Pods that
+// a recreated StatefulSet adopts can run an older revision even though the
+// current and update revisions of the StatefulSet are the same.
+func podsUpdated(pods map[int32]*corev1.Pod, revision string) bool {
+	for _, pod := range pods {
+		if pod != nil && pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+			return false
+		}
+	}
+
+	return true
+}
+
+// updatedReadyPods returns the number of Pods that run the revision and are
+// ready.
+func updatedReadyPods(pods map[int32]*corev1.Pod, revision string) int32 {
+	count := int32(0)
+
+	for _, pod := range pods {
+		if pod == nil || pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+			continue
+		}
+
+		if _, ready := podReadySince(pod); ready {
+			count++
+		}
+	}
+
+	return count
+}
+
+func podReadySince(pod *corev1.Pod) (time.Time, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.LastTransitionTime.Time, condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return time.Time{}, false
+}
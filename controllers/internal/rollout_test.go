@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+var _ = Describe("Staged rollout", func() {
+	now := time.Now()
+	soakTime := 5 * time.Minute
+
+	statefulSet := func(partition int32, currentRevision, updateRevision string) *appsv1.StatefulSet {
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gitaly", Generation: 2},
+		}
+		sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+		sts.Status.ObservedGeneration = 2
+		sts.Status.CurrentRevision = currentRevision
+		sts.Status.UpdateRevision = updateRevision
+
+		return sts
+	}
+
+	pod := func(revision string, ready bool, readySince time.Time) *corev1.Pod {
+		readyStatus := corev1.ConditionFalse
+		if ready {
+			readyStatus = corev1.ConditionTrue
+		}
+
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: revision},
+			},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodReady,
+					Status:             readyStatus,
+					LastTransitionTime: metav1.NewTime(readySince),
+				}},
+			},
+		}
+	}
+
+	It("Should hold the Pods when there are no pending changes", func() {
+		step := NextRolloutStep(statefulSet(0, "rev-1", "rev-1"), 3, nil, soakTime, now, false)
+
+		Expect(step.Partition).To(Equal(int32(3)))
+		Expect(step.Phase).To(Equal(status.GitalyRolloutCompleted))
+	})
+
+	It("Should update the Pods that a recreated StatefulSet adopts", func() {
+		pods := map[int32]*corev1.Pod{
+			0: pod("rev-1", true, now.Add(-time.Hour)),
+			1: pod("rev-1", true, now.Add(-time.Hour)),
+		}
+		step := NextRolloutStep(statefulSet(2, "rev-2", "rev-2"), 2, pods, soakTime, now, false)
+
+		Expect(step.Partition).To(Equal(int32(1)))
+		Expect(step.Phase).To(Equal(status.GitalyRolloutRollingOut))
+	})
+
+	It("Should update the Pod with the highest ordinal first", func() {
+		pods := map[int32]*corev1.Pod{
+			0: pod("rev-1", true, now.Add(-time.Hour)),
+			1: pod("rev-1", true, now.Add(-time.Hour)),
+			2: pod("rev-1", true, now.Add(-time.Hour)),
+		}
+		step := NextRolloutStep(statefulSet(3, "rev-1", "rev-2"), 3, pods, soakTime, now, false)
+
+		Expect(step.Partition).To(Equal(int32(2)))
+		Expect(step.Phase).To(Equal(status.GitalyRolloutRollingOut))
+		Expect(step.Message).To(ContainSubstring("test-gitaly-2"))
+	})
+
+	It("Should wait for the updated Pod to become ready", func() {
+		pods := map[int32]*corev1.Pod{
+			2: pod("rev-2", false, now),
+		}
+		step := NextRolloutStep(statefulSet(2, "rev-1", "rev-2"), 3, pods, soakTime, now, false)
+
+		Expect(step.Partition).To(Equal(int32(2)))
+		Expect(step.UpdatedReplicas).To(BeZero())
+		Expect(step.Message).To(ContainSubstring("become ready"))
+	})
+
+	It("Should soak the updated Pod before updating the next one", func() {
+		pods := map[int32]*corev1.Pod{
+			2: pod("rev-2", true, now.Add(-time.Minute)),
+		}
+		step := NextRolloutStep(statefulSet(2, "rev-1", "rev-2"), 3, pods, soakTime, now, false)
+
+		Expect(step.Partition).To(Equal(int32(2)))
+		Expect(step.Phase).To(Equal(status.GitalyRolloutSoaking))
+	})
+
+	It("Should update the next Pod after the soak time", func() {
+		pods := map[int32]*corev1.Pod{
+			2: pod("rev-2", true, now.Add(-10*time.Minute)),
+		}
+		step := NextRolloutStep(statefulSet(2, "rev-1", "rev-2"), 3, pods, soakTime, now, false)
+
+		Expect(step.Partition).To(Equal(int32(1)))
+		Expect(step.UpdatedReplicas).To(Equal(int32(1)))
+	})
+
+	It("Should not progress when it is blocked", func() {
+		step := NextRolloutStep(statefulSet(3, "rev-1", "rev-2"), 3, nil, soakTime, now, true)
+
+		Expect(step.Partition).To(Equal(int32(3)))
+		Expect(step.Phase).To(Equal(status.GitalyRolloutPending))
+	})
+
+	It("Should wait for the StatefulSet controller to observe the changes", func() {
+		sts := statefulSet(1, "rev-1", "rev-1")
+		sts.Generation = 3
+
+		step := NextRolloutStep(sts, 3, nil, soakTime, now, false)
+
+		Expect(step.Partition).To(Equal(int32(1)))
+		Expect(step.Phase).To(Equal(status.GitalyRolloutRollingOut))
+	})
+})
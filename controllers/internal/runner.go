@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"fmt"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+// RunnerRegistration is the runner that the registration Job created or
+// updated in GitLab.
+type RunnerRegistration struct {
+	// ID is the ID of the runner in GitLab.
+	ID int64 `json:"id"`
+}
+
+func (r *RunnerRegistration) validate() error {
+	if r.ID == 0 {
+		return fmt.Errorf("the registration did not report the ID of the runner")
+	}
+
+	return nil
+}
+
+// RunnerRegistrationPhase returns the phase of a runner that the Operator
+// creates in GitLab for the outcome of its registration Job.
+//
+// The Job is only created once GitLab is running. A failed Job is kept until
+// it is deleted to retry the registration. The runner is running once the
+// Job succeeded.
+func RunnerRegistrationPhase(job JobOutcome, gitLabRunning bool) string {
+	switch job {
+	case JobMissing:
+		if !gitLabRunning {
+			return status.RunnerPending
+		}
+
+		return status.RunnerRegistering
+	case JobRunning:
+		return status.RunnerRegistering
+	case JobFailed:
+		return status.RunnerFailed
+	default:
+		return status.RunnerRunning
+	}
+}
@@ -0,0 +1,27 @@
+package internal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+var _ = Describe("Runner registration", func() {
+	DescribeTable("Deciding the phase of the runner",
+		func(job JobOutcome, gitLabRunning bool, expected string) {
+			Expect(RunnerRegistrationPhase(job, gitLabRunning)).To(Equal(expected))
+		},
+		Entry("waits for GitLab to be running", JobMissing, false, status.RunnerPending),
+		Entry("creates the Job when GitLab is running", JobMissing, true, status.RunnerRegistering),
+		Entry("waits for the Job", JobRunning, true, status.RunnerRegistering),
+		Entry("keeps waiting for the Job when GitLab is not running anymore", JobRunning, false, status.RunnerRegistering),
+		Entry("fails with the Job", JobFailed, true, status.RunnerFailed),
+		Entry("runs when the Job succeeded", JobSucceeded, false, status.RunnerRunning),
+	)
+
+	It("Should require the ID of the runner in the report", func() {
+		_, err := ParseTerminationMessage[RunnerRegistration](`{}`)
+		Expect(err).To(MatchError(ContainSubstring("did not report the ID")))
+	})
+})
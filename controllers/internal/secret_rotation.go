@@ -0,0 +1,35 @@
+package internal
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WorkloadRolledOut returns true when all replicas of the Deployment or
+// StatefulSet run its current Pod template and are available.
+func WorkloadRolledOut(obj client.Object) bool {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		replicas := int32(1)
+		if workload.Spec.Replicas != nil {
+			replicas = *workload.Spec.Replicas
+		}
+
+		return workload.Status.ObservedGeneration >= workload.Generation &&
+			workload.Status.UpdatedReplicas == replicas &&
+			workload.Status.Replicas == replicas &&
+			workload.Status.AvailableReplicas == replicas
+	case *appsv1.StatefulSet:
+		replicas := int32(1)
+		if workload.Spec.Replicas != nil {
+			replicas = *workload.Spec.Replicas
+		}
+
+		return workload.Status.ObservedGeneration >= workload.Generation &&
+			workload.Status.UpdateRevision == workload.Status.CurrentRevision &&
+			workload.Status.UpdatedReplicas == replicas &&
+			workload.Status.ReadyReplicas == replicas
+	default:
+		return true
+	}
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secret rotation", func() {
+	When("Checking the rollout of a workload", func() {
+		replicas := int32(2)
+
+		It("Should wait for the Deployment controller to observe the changes", func() {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 3},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           2,
+					UpdatedReplicas:    2,
+					AvailableReplicas:  2,
+				},
+			}
+
+			Expect(WorkloadRolledOut(deployment)).To(BeFalse())
+
+			deployment.Status.ObservedGeneration = 3
+			Expect(WorkloadRolledOut(deployment)).To(BeTrue())
+		})
+
+		It("Should wait for the old Pods of a Deployment to terminate", func() {
+			deployment := &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					Replicas:          3,
+					UpdatedReplicas:   2,
+					AvailableReplicas: 2,
+				},
+			}
+
+			Expect(WorkloadRolledOut(deployment)).To(BeFalse())
+		})
+
+		It("Should wait for all Pods of a StatefulSet to be updated", func() {
+			statefulSet := &appsv1.StatefulSet{
+				Spec: appsv1.StatefulSetSpec{Replicas: &replicas},
+				Status: appsv1.StatefulSetStatus{
+					CurrentRevision: "gitaly-1",
+					UpdateRevision:  "gitaly-2",
+					UpdatedReplicas: 1,
+					ReadyReplicas:   2,
+				},
+			}
+
+			Expect(WorkloadRolledOut(statefulSet)).To(BeFalse())
+
+			statefulSet.Status.CurrentRevision = "gitaly-2"
+			statefulSet.Status.UpdatedReplicas = 2
+			Expect(WorkloadRolledOut(statefulSet)).To(BeTrue())
+		})
+	})
+})
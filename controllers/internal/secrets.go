@@ -0,0 +1,317 @@
+package internal
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// AlphanumericCharset is the set of characters that the shared secrets Job
+	// uses for most of the generated tokens.
+	AlphanumericCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// LowerAlphanumericCharset is the set of characters that the shared secrets
+	// Job uses for hexadecimal-like secrets.
+	LowerAlphanumericCharset = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	// HexCharset is the set of characters of the Rails secrets.
+	HexCharset = "abcdef0123456789"
+)
+
+// RandomString returns a cryptographically secure random string of the given
+// length that only contains the characters of the given charset.
+func RandomString(charset string, length int) (string, error) {
+	if charset == "" || length < 0 {
+		return "", fmt.Errorf("invalid charset or length: %q, %d", charset, length)
+	}
+
+	max := big.NewInt(int64(len(charset)))
+	result := make([]byte, length)
+
+	for i := range result {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		result[i] = charset[n.Int64()]
+	}
+
+	return string(result), nil
+}
+
+// RandomBase64String returns the base64 encoding of a random string of the
+// given length. This is equivalent to `gen_random <charset> <length> | base64`
+// in the shared secrets Job.
+func RandomBase64String(charset string, length int) (string, error) {
+	value, err := RandomString(charset, length)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(value)), nil
+}
+
+// RSAPrivateKeyPEM generates a new RSA private key of the given size and
+// returns it in PKCS #1 PEM format, similar to `openssl genrsa`.
+func RSAPrivateKeyPEM(bits int) ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeRSAPrivateKey(key), nil
+}
+
+// SSHHostKeys generates a new set of SSH host keys, like `ssh-keygen -A`. The
+// result uses the file names of `ssh-keygen -A` as keys, for example
+// `ssh_host_rsa_key` and `ssh_host_rsa_key.pub`.
+func SSHHostKeys() (map[string][]byte, error) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 3072)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaPEM, err := encodeECPrivateKey(ecdsaKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519PublicKey, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519PEM, err := encodeEd25519PrivateKey(ed25519Key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string][]byte{
+		"ssh_host_rsa_key":     encodeRSAPrivateKey(rsaKey),
+		"ssh_host_ecdsa_key":   ecdsaPEM,
+		"ssh_host_ed25519_key": ed25519PEM,
+	}
+
+	for name, public := range map[string]interface{}{
+		"ssh_host_rsa_key":     &rsaKey.PublicKey,
+		"ssh_host_ecdsa_key":   &ecdsaKey.PublicKey,
+		"ssh_host_ed25519_key": ed25519PublicKey,
+	} {
+		sshKey, err := ssh.NewPublicKey(public)
+		if err != nil {
+			return nil, err
+		}
+
+		result[name+".pub"] = ssh.MarshalAuthorizedKey(sshKey)
+	}
+
+	return result, nil
+}
+
+// Certificate is a PEM encoded certificate and its private key.
+type Certificate struct {
+	CertificatePEM []byte
+	PrivateKeyPEM  []byte
+
+	certificate *x509.Certificate
+	privateKey  crypto.Signer
+}
+
+// NewCertificateAuthority generates a new self-signed certificate authority
+// with the given common name.
+func NewCertificateAuthority(commonName string, validity time.Duration) (*Certificate, error) {
+	template := certificateTemplate(commonName, validity)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	return newCertificate(template, nil, 0)
+}
+
+// NewSelfSignedCertificate generates a new self-signed certificate with an RSA
+// key of the given size, similar to `openssl req -x509`. It is used for
+// signing tokens, not for serving TLS.
+func NewSelfSignedCertificate(commonName string, rsaBits int, validity time.Duration) (*Certificate, error) {
+	template := certificateTemplate(commonName, validity)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+	return newCertificate(template, nil, rsaBits)
+}
+
+// IssueCertificate generates a new serving certificate for the given DNS names
+// that is signed by this certificate authority.
+func (ca *Certificate) IssueCertificate(dnsNames []string, validity time.Duration) (*Certificate, error) {
+	if ca.certificate == nil || ca.privateKey == nil || !ca.certificate.IsCA {
+		return nil, fmt.Errorf("certificate is not a certificate authority")
+	}
+
+	template := certificateTemplate(dnsNames[0], validity)
+	template.DNSNames = dnsNames
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	return newCertificate(template, ca, 0)
+}
+
+func certificateTemplate(commonName string, validity time.Duration) *x509.Certificate {
+	now := time.Now()
+
+	return &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName: commonName,
+		},
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validity),
+	}
+}
+
+// newCertificate generates a new key and a certificate for it. It uses an
+// ECDSA P-256 key unless the size of an RSA key is specified.
+func newCertificate(template *x509.Certificate, issuer *Certificate, rsaBits int) (*Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template.SerialNumber = serial
+
+	key, keyPEM, err := generatePrivateKey(rsaBits)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.certificate, issuer.privateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificate{
+		CertificatePEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		PrivateKeyPEM:  keyPEM,
+		certificate:    certificate,
+		privateKey:     key,
+	}, nil
+}
+
+func generatePrivateKey(rsaBits int) (crypto.Signer, []byte, error) {
+	if rsaBits > 0 {
+		key, err := rsa.GenerateKey(rand.Reader, rsaBits)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return key, encodeRSAPrivateKey(key), nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPEM, err := encodeECPrivateKey(key)
+
+	return key, keyPEM, err
+}
+
+func encodeRSAPrivateKey(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+}
+
+func encodeECPrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// encodeEd25519PrivateKey returns the key in the OpenSSH private key format,
+// which is the only format of Ed25519 keys that OpenSSH reads. The key is not
+// encrypted.
+func encodeEd25519PrivateKey(key ed25519.PrivateKey) ([]byte, error) {
+	publicKey, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	check := make([]byte, 4)
+	if _, err := rand.Read(check); err != nil {
+		return nil, err
+	}
+
+	private := struct {
+		Check1  uint32
+		Check2  uint32
+		KeyType string
+		Public  []byte
+		Private []byte
+		Comment string
+		Pad     []byte `ssh:"rest"`
+	}{
+		Check1:  binary.BigEndian.Uint32(check),
+		Check2:  binary.BigEndian.Uint32(check),
+		KeyType: ssh.KeyAlgoED25519,
+		Public:  key.Public().(ed25519.PublicKey),
+		Private: key,
+	}
+
+	// The private section is padded to the block size of the cipher, which is
+	// 8 for an unencrypted key.
+	for i := 1; (len(ssh.Marshal(private)))%8 != 0; i++ {
+		private.Pad = append(private.Pad, byte(i))
+	}
+
+	envelope := struct {
+		CipherName string
+		KDFName    string
+		KDFOptions string
+		Keys       uint32
+		PublicKey  []byte
+		Private    []byte
+	}{
+		CipherName: "none",
+		KDFName:    "none",
+		Keys:       1,
+		PublicKey:  publicKey.Marshal(),
+		Private:    ssh.Marshal(private),
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte("openssh-key-v1\x00"), ssh.Marshal(envelope)...),
+	}), nil
+}
@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	// SidekiqPodNameLabel is the label of the Sidekiq Deployments that holds
+	// the name of the pod in `gitlab.sidekiq.pods`.
+	SidekiqPodNameLabel = "queue-pod-name"
+
+	sidekiqQueuesEnv       = "SIDEKIQ_QUEUES"
+	sidekiqNegateQueuesEnv = "SIDEKIQ_NEGATE_QUEUES"
+
+	// Timestamps beyond this value are in milliseconds rather than seconds.
+	millisecondTimestampThreshold = 1e11
+)
+
+var (
+	// SidekiqDefaultQueues are the queues that the Sidekiq pods of the chart
+	// process when no queues are configured.
+	SidekiqDefaultQueues = []string{"default", "mailers"}
+
+	// The prefixes of the Redis keys of the Sidekiq queues, with and without
+	// the namespace that older GitLab versions use.
+	sidekiqQueueKeyPrefixes = []string{"queue:", "resque:gitlab:queue:"}
+)
+
+// SidekiqQueueStore reads the Sidekiq queues from Redis.
+type SidekiqQueueStore interface {
+	ListLength(key string) (int64, error)
+	ListIndex(key string, index int64) (string, bool, error)
+}
+
+// SidekiqQueueStats is the backlog of the queues of a Sidekiq Deployment.
+type SidekiqQueueStats struct {
+	// Depth is the number of queued jobs.
+	Depth int64
+
+	// Latency is the time that the oldest queued job has waited.
+	Latency time.Duration
+}
+
+// SidekiqQueues returns the queues that the Sidekiq Deployment processes. It
+// returns an error when the Deployment processes all queues except the
+// listed ones, because they can not be enumerated.
+func SidekiqQueues(deployment *appsv1.Deployment) ([]string, error) {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		queues := []string{}
+		negate := false
+
+		for _, env := range container.Env {
+			switch env.Name {
+			case sidekiqQueuesEnv:
+				for _, queue := range strings.Split(env.Value, ",") {
+					if queue = strings.TrimSpace(queue); queue != "" {
+						queues = append(queues, queue)
+					}
+				}
+			case sidekiqNegateQueuesEnv:
+				negate = env.Value == "true"
+			}
+		}
+
+		if negate {
+			return nil, fmt.Errorf("negated queues of %s can not be measured", deployment.Name)
+		}
+
+		if len(queues) > 0 {
+			return queues, nil
+		}
+	}
+
+	return SidekiqDefaultQueues, nil
+}
+
+// ReadSidekiqQueues sums the number of queued jobs of the queues and finds
+// the oldest job to measure the latency.
+func ReadSidekiqQueues(store SidekiqQueueStore, queues []string, now time.Time) (SidekiqQueueStats, error) {
+	stats := SidekiqQueueStats{}
+
+	for _, queue := range queues {
+		for _, prefix := range sidekiqQueueKeyPrefixes {
+			key := prefix + queue
+
+			length, err := store.ListLength(key)
+			if err != nil {
+				return stats, fmt.Errorf("failed to read the length of %s: %w", key, err)
+			}
+
+			if length == 0 {
+				continue
+			}
+
+			stats.Depth += length
+
+			/* Sidekiq pushes to the head of the list, the oldest job is the tail */
+			job, found, err := store.ListIndex(key, -1)
+			if err != nil {
+				return stats, fmt.Errorf("failed to read the oldest job of %s: %w", key, err)
+			}
+
+			if !found {
+				continue
+			}
+
+			if latency := sidekiqJobLatency(job, now); latency > stats.Latency {
+				stats.Latency = latency
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+func sidekiqJobLatency(job string, now time.Time) time.Duration {
+	payload := struct {
+		EnqueuedAt float64 `json:"enqueued_at"`
+	}{}
+
+	if err := json.Unmarshal([]byte(job), &payload); err != nil || payload.EnqueuedAt <= 0 {
+		return 0
+	}
+
+	seconds := payload.EnqueuedAt
+	if seconds > millisecondTimestampThreshold {
+		seconds /= 1000
+	}
+
+	enqueuedAt := time.Unix(0, int64(seconds*float64(time.Second)))
+
+	if latency := now.Sub(enqueuedAt); latency > 0 {
+		return latency
+	}
+
+	return 0
+}
+
+// SidekiqReplicas decides the number of replicas of a Sidekiq Deployment from
+// the backlog of its queues.
+//
+// The replicas cover the queued jobs with the target depth per replica and
+// grow in proportion to the latency when the oldest job waits longer than the
+// target latency. Without queued jobs the Deployment is scaled to the minimum
+// number of replicas. The replicas are only reduced when the last scaling is
+// older than the scale down delay, except for the replicas beyond the maximum.
+func SidekiqReplicas(scaling gitlab.SidekiqQueueScaling, current int32, stats SidekiqQueueStats, lastScaleTime, now time.Time) int32 {
+	desired := int32(0)
+
+	if stats.Depth > 0 {
+		desired = int32(math.Ceil(float64(stats.Depth) / float64(scaling.TargetQueueDepth)))
+
+		if scaling.TargetQueueLatency > 0 && stats.Latency > scaling.TargetQueueLatency {
+			byLatency := int32(1)
+
+			if current > 0 {
+				byLatency = int32(math.Ceil(float64(current) * float64(stats.Latency) / float64(scaling.TargetQueueLatency)))
+			}
+
+			if byLatency > desired {
+				desired = byLatency
+			}
+		}
+	}
+
+	if desired < scaling.MinReplicas {
+		desired = scaling.MinReplicas
+	}
+
+	if desired > scaling.MaxReplicas {
+		desired = scaling.MaxReplicas
+	}
+
+	if desired < current && now.Sub(lastScaleTime) < scaling.ScaleDownDelay {
+		if current > scaling.MaxReplicas {
+			return scaling.MaxReplicas
+		}
+
+		return current
+	}
+
+	return desired
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"errors"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+type fakeQueueStore struct {
+	lists map[string][]string
+	err   error
+}
+
+func (s fakeQueueStore) ListLength(key string) (int64, error) {
+	return int64(len(s.lists[key])), s.err
+}
+
+func (s fakeQueueStore) ListIndex(key string, index int64) (string, bool, error) {
+	list := s.lists[key]
+	if index < 0 {
+		index += int64(len(list))
+	}
+
+	if index < 0 || index >= int64(len(list)) {
+		return "", false, s.err
+	}
+
+	return list[index], true, s.err
+}
+
+var _ = Describe("Sidekiq scaling", func() {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	Context("Listing the queues", func() {
+		newDeployment := func(env ...corev1.EnvVar) *appsv1.Deployment {
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test-sidekiq-all-in-1-v2"}}
+			deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "sidekiq", Env: env}}
+
+			return deployment
+		}
+
+		It("Should read the queues of the container", func() {
+			queues, err := SidekiqQueues(newDeployment(corev1.EnvVar{Name: "SIDEKIQ_QUEUES", Value: "default, mailers,urgent"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queues).To(Equal([]string{"default", "mailers", "urgent"}))
+		})
+
+		It("Should fall back to the default queues", func() {
+			queues, err := SidekiqQueues(newDeployment())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queues).To(Equal(SidekiqDefaultQueues))
+		})
+
+		It("Should reject negated queues", func() {
+			_, err := SidekiqQueues(newDeployment(
+				corev1.EnvVar{Name: "SIDEKIQ_QUEUES", Value: "mailers"},
+				corev1.EnvVar{Name: "SIDEKIQ_NEGATE_QUEUES", Value: "true"}))
+			Expect(err).To(MatchError(ContainSubstring("can not be measured")))
+		})
+	})
+
+	Context("Reading the queues", func() {
+		It("Should sum the depth and measure the oldest job", func() {
+			store := fakeQueueStore{lists: map[string][]string{
+				"queue:default": {
+					`{"enqueued_at":1704110390.0}`,
+					`{"enqueued_at":1704110370.0}`,
+				},
+				"resque:gitlab:queue:mailers": {
+					`{"enqueued_at":1704110340000}`,
+				},
+			}}
+
+			stats, err := ReadSidekiqQueues(store, []string{"default", "mailers"}, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats.Depth).To(Equal(int64(3)))
+			Expect(stats.Latency).To(Equal(time.Minute))
+		})
+
+		It("Should ignore jobs without a timestamp", func() {
+			store := fakeQueueStore{lists: map[string][]string{"queue:default": {"not a job"}}}
+
+			stats, err := ReadSidekiqQueues(store, []string{"default"}, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(SidekiqQueueStats{Depth: 1}))
+		})
+
+		It("Should report the errors of Redis", func() {
+			store := fakeQueueStore{err: errors.New("connection reset")}
+
+			_, err := ReadSidekiqQueues(store, []string{"default"}, now)
+			Expect(err).To(MatchError(ContainSubstring("connection reset")))
+		})
+	})
+
+	Context("Deciding the replicas", func() {
+		scaling := gitlab.SidekiqQueueScaling{
+			MinReplicas:        1,
+			MaxReplicas:        10,
+			TargetQueueDepth:   100,
+			TargetQueueLatency: 30 * time.Second,
+			ScaleDownDelay:     5 * time.Minute,
+		}
+
+		It("Should cover the depth of the queues", func() {
+			Expect(SidekiqReplicas(scaling, 1, SidekiqQueueStats{Depth: 250}, now, now)).To(Equal(int32(3)))
+		})
+
+		It("Should scale up in proportion to the latency", func() {
+			Expect(SidekiqReplicas(scaling, 2, SidekiqQueueStats{Depth: 10, Latency: 90 * time.Second}, now, now)).To(Equal(int32(6)))
+		})
+
+		It("Should stay within the limits", func() {
+			Expect(SidekiqReplicas(scaling, 1, SidekiqQueueStats{Depth: 5000}, now, now)).To(Equal(int32(10)))
+			Expect(SidekiqReplicas(scaling, 1, SidekiqQueueStats{}, now.Add(-time.Hour), now)).To(Equal(int32(1)))
+			Expect(SidekiqReplicas(scaling, 20, SidekiqQueueStats{}, now, now)).To(Equal(int32(10)))
+		})
+
+		It("Should scale to zero and back", func() {
+			toZero := scaling
+			toZero.MinReplicas = 0
+
+			Expect(SidekiqReplicas(toZero, 1, SidekiqQueueStats{}, now.Add(-time.Hour), now)).To(Equal(int32(0)))
+			Expect(SidekiqReplicas(toZero, 0, SidekiqQueueStats{Depth: 1, Latency: time.Hour}, now, now)).To(Equal(int32(1)))
+		})
+
+		It("Should delay scaling down", func() {
+			Expect(SidekiqReplicas(scaling, 5, SidekiqQueueStats{Depth: 100}, now.Add(-time.Minute), now)).To(Equal(int32(5)))
+			Expect(SidekiqReplicas(scaling, 5, SidekiqQueueStats{Depth: 100}, now.Add(-10*time.Minute), now)).To(Equal(int32(1)))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TLSCertificateInfo describes the leaf certificate of a TLS Secret.
+type TLSCertificateInfo struct {
+	DNSNames []string
+	Issuer   string
+	NotAfter time.Time
+}
+
+// ParseTLSCertificate parses the first certificate of the PEM-encoded chain,
+// which is the leaf certificate in a `kubernetes.io/tls` Secret.
+func ParseTLSCertificate(data []byte) (*TLSCertificateInfo, error) {
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM-encoded certificate found")
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the certificate: %w", err)
+		}
+
+		dnsNames := certificate.DNSNames
+		if len(dnsNames) == 0 && certificate.Subject.CommonName != "" {
+			dnsNames = []string{certificate.Subject.CommonName}
+		}
+
+		return &TLSCertificateInfo{
+			DNSNames: dnsNames,
+			Issuer:   certificate.Issuer.String(),
+			NotAfter: certificate.NotAfter,
+		}, nil
+	}
+}
+
+// ExpiresWithin returns true when the certificate is expired or expires in
+// less than the warning.
+func (c *TLSCertificateInfo) ExpiresWithin(now time.Time, warning time.Duration) bool {
+	return c.NotAfter.Sub(now) < warning
+}
+
+// UncoveredHosts returns the hosts that none of the DNS names of the
+// certificate match. A wildcard name only matches a single label.
+func (c *TLSCertificateInfo) UncoveredHosts(hosts []string) []string {
+	result := []string{}
+
+	for _, host := range hosts {
+		covered := false
+
+		for _, name := range c.DNSNames {
+			if matchesDNSName(name, host) {
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			result = append(result, host)
+		}
+	}
+
+	return result
+}
+
+func matchesDNSName(name, host string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if name == host {
+		return true
+	}
+
+	if !strings.HasPrefix(name, "*.") {
+		return false
+	}
+
+	label, parent, found := strings.Cut(host, ".")
+
+	return found && label != "" && parent == name[2:]
+}
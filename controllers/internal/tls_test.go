@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TLS certificates", func() {
+	notAfter := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	newCertificate := func(dnsNames ...string) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "gitlab.example.com"},
+			DNSNames:     dnsNames,
+			NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+			NotAfter:     notAfter,
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).NotTo(HaveOccurred())
+
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	Context("Parsing a certificate", func() {
+		It("Should read the leaf certificate of the chain", func() {
+			chain := append(newCertificate("gitlab.example.com", "registry.example.com"), newCertificate("ca.example.com")...)
+
+			info, err := ParseTLSCertificate(chain)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.DNSNames).To(ConsistOf("gitlab.example.com", "registry.example.com"))
+			Expect(info.NotAfter).To(BeTemporally("==", notAfter))
+			Expect(info.Issuer).To(ContainSubstring("gitlab.example.com"))
+		})
+
+		It("Should fall back to the common name", func() {
+			info, err := ParseTLSCertificate(newCertificate())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.DNSNames).To(ConsistOf("gitlab.example.com"))
+		})
+
+		It("Should report content without certificates", func() {
+			_, err := ParseTLSCertificate([]byte("not a certificate"))
+			Expect(err).To(MatchError("no PEM-encoded certificate found"))
+		})
+	})
+
+	Context("Checking the expiry", func() {
+		info := &TLSCertificateInfo{NotAfter: notAfter}
+
+		It("Should report a certificate that expires within the warning", func() {
+			Expect(info.ExpiresWithin(notAfter.Add(-10*24*time.Hour), 30*24*time.Hour)).To(BeTrue())
+			Expect(info.ExpiresWithin(notAfter.Add(time.Hour), 30*24*time.Hour)).To(BeTrue())
+		})
+
+		It("Should accept a certificate that expires later", func() {
+			Expect(info.ExpiresWithin(notAfter.Add(-60*24*time.Hour), 30*24*time.Hour)).To(BeFalse())
+		})
+	})
+
+	Context("Checking the hosts", func() {
+		info := &TLSCertificateInfo{DNSNames: []string{"gitlab.example.com", "*.pages.example.com"}}
+
+		It("Should match the hosts with the exact and the wildcard names", func() {
+			Expect(info.UncoveredHosts([]string{"GitLab.example.com", "group.pages.example.com", "*.pages.example.com"})).To(BeEmpty())
+		})
+
+		It("Should only match a single label with a wildcard name", func() {
+			Expect(info.UncoveredHosts([]string{"a.group.pages.example.com", "pages.example.com", "registry.example.com"})).To(
+				ConsistOf("a.group.pages.example.com", "pages.example.com", "registry.example.com"))
+		})
+	})
+})
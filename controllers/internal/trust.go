@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	// CABundleVolumeName is the name of the volume of the bundle of the custom
+	// certificate authorities.
+	CABundleVolumeName = "operator-ca-bundle"
+
+	// CABundleMountPath is the directory where the bundle of the custom
+	// certificate authorities is mounted in all the containers.
+	CABundleMountPath = "/etc/gitlab-operator/trust"
+
+	// CABundleFileName is the name of the bundle file in CABundleMountPath.
+	CABundleFileName = "ca-bundle.crt"
+
+	// CABundleChecksumAnnotation is the Pod annotation with the checksum of the
+	// bundle of the custom certificate authorities.
+	CABundleChecksumAnnotation = "checksum/ca-bundle"
+)
+
+// CABundleChecksum returns the checksum of the content of the bundle.
+func CABundleChecksum(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// CertificatePool returns the certificate authorities of the system together
+// with the certificates of the PEM bundles, which are keyed by their source.
+// It fails when a bundle does not contain any certificate.
+func CertificatePool(bundles map[string][]byte) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for source, bundle := range bundles {
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in %s", source)
+		}
+	}
+
+	return pool, nil
+}
+
+// InjectCABundle mounts the bundle of the custom certificate authorities into
+// all the containers of the Pod template of the object and annotates the Pod
+// template with its checksum, so that the Pods are restarted when it changes.
+//
+// Jobs only get the bundle. Their Pod template is immutable, so changing the
+// checksum would recreate them and run them again.
+func InjectCABundle(obj client.Object, bundle *gitlab.CABundleReference, checksum string) {
+	template, annotate := caBundlePodTemplate(obj)
+	if template == nil {
+		return
+	}
+
+	volume := corev1.Volume{Name: CABundleVolumeName}
+	items := []corev1.KeyToPath{{Key: bundle.Key, Path: CABundleFileName}}
+
+	if bundle.InSecret() {
+		volume.Secret = &corev1.SecretVolumeSource{SecretName: bundle.Name, Items: items}
+	} else {
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: bundle.Name},
+			Items:                items,
+		}
+	}
+
+	mountCABundleVolume(template, volume)
+
+	if annotate && checksum != "" {
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+
+		template.Annotations[CABundleChecksumAnnotation] = checksum
+	}
+}
+
+// KeepJobCABundle mounts the bundle of the existing Job into the Job, or no
+// bundle when the existing Job was created without one. The Pod template of a
+// Job is immutable, so a Job only gets a new bundle when it is created again.
+func KeepJobCABundle(job, existing *batchv1.Job) {
+	template := &job.Spec.Template
+
+	template.Spec.Volumes = withoutCABundleVolume(template.Spec.Volumes)
+
+	for _, containers := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+		for i := range containers {
+			containers[i].VolumeMounts = withoutCABundleMount(containers[i].VolumeMounts)
+		}
+	}
+
+	for _, volume := range existing.Spec.Template.Spec.Volumes {
+		if volume.Name == CABundleVolumeName {
+			mountCABundleVolume(template, volume)
+		}
+	}
+}
+
+// HasPodTemplate returns true when the object is a workload with a Pod
+// template.
+func HasPodTemplate(obj client.Object) bool {
+	template, _ := caBundlePodTemplate(obj)
+
+	return template != nil
+}
+
+// caBundlePodTemplate returns the Pod template of the object and whether it
+// can be annotated with the checksum of the bundle.
+func caBundlePodTemplate(obj client.Object) (*corev1.PodTemplateSpec, bool) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Template, true
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template, true
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template, true
+	case *batchv1.CronJob:
+		return &o.Spec.JobTemplate.Spec.Template, true
+	case *batchv1beta1.CronJob:
+		return &o.Spec.JobTemplate.Spec.Template, true
+	case *batchv1.Job:
+		return &o.Spec.Template, false
+	}
+
+	return nil, false
+}
+
+// mountCABundleVolume adds the volume of the bundle to the Pod template and
+// mounts it into all the containers.
+func mountCABundleVolume(template *corev1.PodTemplateSpec, volume corev1.Volume) {
+	template.Spec.Volumes = append(withoutCABundleVolume(template.Spec.Volumes), volume)
+
+	mount := corev1.VolumeMount{
+		Name:      CABundleVolumeName,
+		MountPath: CABundleMountPath,
+		ReadOnly:  true,
+	}
+
+	for _, containers := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+		for i := range containers {
+			containers[i].VolumeMounts = append(withoutCABundleMount(containers[i].VolumeMounts), mount)
+		}
+	}
+}
+
+func withoutCABundleVolume(volumes []corev1.Volume) []corev1.Volume {
+	result := make([]corev1.Volume, 0, len(volumes)+1)
+
+	for _, volume := range volumes {
+		if volume.Name != CABundleVolumeName {
+			result = append(result, volume)
+		}
+	}
+
+	return result
+}
+
+func withoutCABundleMount(mounts []corev1.VolumeMount) []corev1.VolumeMount {
+	result := make([]corev1.VolumeMount, 0, len(mounts)+1)
+
+	for _, mount := range mounts {
+		if mount.Name != CABundleVolumeName {
+			result = append(result, mount)
+		}
+	}
+
+	return result
+}
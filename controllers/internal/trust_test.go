@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+var _ = Describe("CA bundle", func() {
+	podSpec := func() corev1.PodSpec {
+		return corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "certificates"}},
+			Containers:     []corev1.Container{{Name: "webservice"}, {Name: "workhorse"}},
+		}
+	}
+
+	bundle := &gitlab.CABundleReference{Kind: gitlab.CABundleKindBundle, Name: "corporate-ca", Key: "trust-bundle.pem"}
+
+	It("Should mount the bundle into all the containers of a Deployment", func() {
+		deployment := &appsv1.Deployment{}
+		deployment.Spec.Template.Spec = podSpec()
+
+		Expect(HasPodTemplate(deployment)).To(BeTrue())
+		InjectCABundle(deployment, bundle, "abc")
+
+		template := deployment.Spec.Template
+		Expect(template.Spec.Volumes).To(ConsistOf(HaveField("ConfigMap.LocalObjectReference.Name", "corporate-ca")))
+		Expect(template.Spec.Volumes[0].ConfigMap.Items).To(ConsistOf(corev1.KeyToPath{Key: "trust-bundle.pem", Path: CABundleFileName}))
+
+		for _, container := range append(template.Spec.InitContainers, template.Spec.Containers...) {
+			Expect(container.VolumeMounts).To(ConsistOf(HaveField("MountPath", CABundleMountPath)), container.Name)
+		}
+
+		Expect(template.Annotations).To(HaveKeyWithValue(CABundleChecksumAnnotation, "abc"))
+	})
+
+	It("Should replace the bundle that is already injected", func() {
+		statefulSet := &appsv1.StatefulSet{}
+		statefulSet.Spec.Template.Spec = podSpec()
+
+		InjectCABundle(statefulSet, bundle, "abc")
+		InjectCABundle(statefulSet, &gitlab.CABundleReference{Kind: gitlab.CABundleKindSecret, Name: "ca", Key: "ca.crt"}, "def")
+
+		template := statefulSet.Spec.Template
+		Expect(template.Spec.Volumes).To(ConsistOf(HaveField("Secret.SecretName", "ca")))
+		Expect(template.Spec.Containers[0].VolumeMounts).To(HaveLen(1))
+		Expect(template.Annotations).To(HaveKeyWithValue(CABundleChecksumAnnotation, "def"))
+	})
+
+	It("Should not annotate the Pods of a Job", func() {
+		job := &batchv1.Job{}
+		job.Spec.Template.Spec = podSpec()
+
+		InjectCABundle(job, bundle, "abc")
+
+		Expect(job.Spec.Template.Spec.Volumes).To(HaveLen(1))
+		Expect(job.Spec.Template.Annotations).NotTo(HaveKey(CABundleChecksumAnnotation))
+	})
+
+	It("Should keep the bundle of an existing Job", func() {
+		existing := &batchv1.Job{}
+		existing.Spec.Template.Spec = podSpec()
+
+		job := existing.DeepCopy()
+		InjectCABundle(job, bundle, "")
+
+		KeepJobCABundle(job, existing)
+		Expect(job.Spec.Template.Spec.Volumes).To(BeEmpty())
+
+		for _, container := range append(job.Spec.Template.Spec.InitContainers, job.Spec.Template.Spec.Containers...) {
+			Expect(container.VolumeMounts).To(BeEmpty(), container.Name)
+		}
+
+		InjectCABundle(existing, bundle, "")
+		job = &batchv1.Job{}
+		job.Spec.Template.Spec = podSpec()
+		InjectCABundle(job, &gitlab.CABundleReference{Kind: gitlab.CABundleKindSecret, Name: "ca", Key: "ca.crt"}, "")
+
+		KeepJobCABundle(job, existing)
+		Expect(job.Spec.Template).To(Equal(existing.Spec.Template))
+	})
+
+	It("Should ignore objects without a Pod template", func() {
+		Expect(HasPodTemplate(&corev1.ConfigMap{})).To(BeFalse())
+	})
+})
+
+var _ = Describe("Certificate pool", func() {
+	It("Should trust the certificates of the bundles", func() {
+		ca, err := NewCertificateAuthority("Corporate CA", time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		cert, err := ca.IssueCertificate([]string{"redis.example.com"}, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		pool, err := CertificatePool(map[string][]byte{"ConfigMap corporate-ca": ca.CertificatePEM})
+		Expect(err).NotTo(HaveOccurred())
+
+		block, _ := pem.Decode(cert.CertificatePEM)
+		Expect(block).NotTo(BeNil())
+
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: pool, DNSName: "redis.example.com"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should reject a bundle without certificates", func() {
+		_, err := CertificatePool(map[string][]byte{"Secret legacy-ca": []byte("not a certificate")})
+		Expect(err).To(MatchError(ContainSubstring("Secret legacy-ca")))
+	})
+})
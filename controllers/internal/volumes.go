@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+// VolumeClaimResize describes a volume claim template of a StatefulSet whose
+// requested storage is changed.
+type VolumeClaimResize struct {
+	// Template is the name of the volume claim template.
+	Template string
+
+	// Current is the requested storage of the existing StatefulSet.
+	Current resource.Quantity
+
+	// Desired is the requested storage of the desired StatefulSet.
+	Desired resource.Quantity
+}
+
+// IsExpansion returns true when the desired size is larger than the current
+// size. Volumes can not be shrunk.
+func (r VolumeClaimResize) IsExpansion() bool {
+	return r.Desired.Cmp(r.Current) > 0
+}
+
+// VolumeClaimResizes compares the volume claim templates of the current and
+// the desired StatefulSets and returns the ones with different storage
+// requests. Templates that are added or removed are ignored.
+func VolumeClaimResizes(current, desired *appsv1.StatefulSet) []VolumeClaimResize {
+	resizes := []VolumeClaimResize{}
+
+	for _, desiredTemplate := range desired.Spec.VolumeClaimTemplates {
+		for _, currentTemplate := range current.Spec.VolumeClaimTemplates {
+			if currentTemplate.Name != desiredTemplate.Name {
+				continue
+			}
+
+			currentSize := currentTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+			desiredSize := desiredTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+
+			if currentSize.Cmp(desiredSize) != 0 {
+				resizes = append(resizes, VolumeClaimResize{
+					Template: desiredTemplate.Name,
+					Current:  currentSize,
+					Desired:  desiredSize,
+				})
+			}
+		}
+	}
+
+	return resizes
+}
+
+// SetVolumeClaimTemplateSize sets the requested storage of the named volume
+// claim template of the StatefulSet.
+func SetVolumeClaimTemplateSize(statefulSet *appsv1.StatefulSet, template string, size resource.Quantity) {
+	for i := range statefulSet.Spec.VolumeClaimTemplates {
+		claim := &statefulSet.Spec.VolumeClaimTemplates[i]
+
+		if claim.Name != template {
+			continue
+		}
+
+		if claim.Spec.Resources.Requests == nil {
+			claim.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+
+		claim.Spec.Resources.Requests[corev1.ResourceStorage] = size
+	}
+}
+
+// VolumeClaimName returns the name of the PersistentVolumeClaim that the
+// StatefulSet controller creates for the volume claim template and ordinal.
+func VolumeClaimName(template, statefulSet string, ordinal int) string {
+	return fmt.Sprintf("%s-%s-%d", template, statefulSet, ordinal)
+}
+
+// VolumeExpansionPhase returns the phase of expanding the
+// PersistentVolumeClaim to the desired size and a message that describes it.
+func VolumeExpansionPhase(pvc *corev1.PersistentVolumeClaim, desired resource.Quantity) (string, string) {
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+
+	if capacity.Cmp(desired) >= 0 {
+		return status.VolumeExpansionCompleted,
+			fmt.Sprintf("Volume is expanded to %s", capacity.String())
+	}
+
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case corev1.PersistentVolumeClaimFileSystemResizePending:
+			return status.VolumeExpansionFileSystemResizePending,
+				"Waiting for a Pod to mount the volume to resize the file system"
+		case corev1.PersistentVolumeClaimResizing:
+			return status.VolumeExpansionResizing,
+				fmt.Sprintf("Volume is resizing from %s to %s", capacity.String(), desired.String())
+		}
+	}
+
+	return status.VolumeExpansionPending,
+		fmt.Sprintf("Waiting for the volume to be resized from %s to %s", capacity.String(), desired.String())
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+func statefulSetWithVolumes(sizes map[string]string) *appsv1.StatefulSet {
+	sts := &appsv1.StatefulSet{}
+
+	for name, size := range sizes {
+		sts.Spec.VolumeClaimTemplates = append(sts.Spec.VolumeClaimTemplates, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse(size),
+					},
+				},
+			},
+		})
+	}
+
+	return sts
+}
+
+var _ = Describe("Volume expansion", func() {
+	Context("Comparing volume claim templates", func() {
+		It("Should detect expansions", func() {
+			resizes := VolumeClaimResizes(
+				statefulSetWithVolumes(map[string]string{"repo-data": "50Gi"}),
+				statefulSetWithVolumes(map[string]string{"repo-data": "100Gi"}))
+
+			Expect(resizes).To(HaveLen(1))
+			Expect(resizes[0].Template).To(Equal("repo-data"))
+			Expect(resizes[0].IsExpansion()).To(BeTrue())
+		})
+
+		It("Should detect shrinking", func() {
+			resizes := VolumeClaimResizes(
+				statefulSetWithVolumes(map[string]string{"data": "8Gi"}),
+				statefulSetWithVolumes(map[string]string{"data": "4Gi"}))
+
+			Expect(resizes).To(HaveLen(1))
+			Expect(resizes[0].IsExpansion()).To(BeFalse())
+		})
+
+		It("Should ignore equal sizes with different notations", func() {
+			Expect(VolumeClaimResizes(
+				statefulSetWithVolumes(map[string]string{"data": "1Gi"}),
+				statefulSetWithVolumes(map[string]string{"data": "1024Mi"}))).To(BeEmpty())
+		})
+
+		It("Should ignore added templates", func() {
+			Expect(VolumeClaimResizes(
+				statefulSetWithVolumes(map[string]string{}),
+				statefulSetWithVolumes(map[string]string{"data": "8Gi"}))).To(BeEmpty())
+		})
+
+		It("Should set the template size", func() {
+			sts := statefulSetWithVolumes(map[string]string{"data": "8Gi"})
+			SetVolumeClaimTemplateSize(sts, "data", resource.MustParse("4Gi"))
+
+			size := sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+			Expect(size.String()).To(Equal("4Gi"))
+		})
+	})
+
+	Context("Naming volume claims", func() {
+		It("Should follow the StatefulSet controller convention", func() {
+			Expect(VolumeClaimName("repo-data", "test-gitaly", 2)).To(Equal("repo-data-test-gitaly-2"))
+		})
+	})
+
+	Context("Reporting the expansion phase", func() {
+		desired := resource.MustParse("100Gi")
+
+		claim := func(capacity string, conditions ...corev1.PersistentVolumeClaimConditionType) *corev1.PersistentVolumeClaim {
+			pvc := &corev1.PersistentVolumeClaim{}
+			pvc.Status.Capacity = corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse(capacity),
+			}
+
+			for _, condition := range conditions {
+				pvc.Status.Conditions = append(pvc.Status.Conditions, corev1.PersistentVolumeClaimCondition{
+					Type:   condition,
+					Status: corev1.ConditionTrue,
+				})
+			}
+
+			return pvc
+		}
+
+		It("Should report pending expansions", func() {
+			phase, _ := VolumeExpansionPhase(claim("50Gi"), desired)
+			Expect(phase).To(Equal(status.VolumeExpansionPending))
+		})
+
+		It("Should report resizing volumes", func() {
+			phase, _ := VolumeExpansionPhase(claim("50Gi", corev1.PersistentVolumeClaimResizing), desired)
+			Expect(phase).To(Equal(status.VolumeExpansionResizing))
+		})
+
+		It("Should report pending file system resizes", func() {
+			phase, _ := VolumeExpansionPhase(claim("50Gi", corev1.PersistentVolumeClaimFileSystemResizePending), desired)
+			Expect(phase).To(Equal(status.VolumeExpansionFileSystemResizePending))
+		})
+
+		It("Should report completed expansions", func() {
+			phase, message := VolumeExpansionPhase(claim("100Gi"), desired)
+			Expect(phase).To(Equal(status.VolumeExpansionCompleted))
+			Expect(message).To(ContainSubstring("100Gi"))
+		})
+	})
+})
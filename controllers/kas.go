@@ -61,7 +61,7 @@ func (r *GitLabReconciler) reconcileKasService(ctx context.Context, adapter gitl
 }
 
 func (r *GitLabReconciler) reconcileKasIngress(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
-	if err := r.reconcileIngress(ctx, gitlabctl.KasIngress(template), adapter); err != nil {
+	if err := r.reconcileIngress(ctx, gitlabctl.KasIngress(template), adapter, template); err != nil {
 		return err
 	}
 
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/settings"
+)
+
+var watchedNamespaces = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "gitlab_operator_watched_namespaces",
+		Help: "Namespaces that the Operator watches. The namespace label is empty when the Operator watches all namespaces.",
+	},
+	[]string{"scope", "namespace"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(watchedNamespaces)
+}
+
+// RecordWatchScope reports the watch scope of the Operator on the metrics
+// endpoint.
+func RecordWatchScope(scope settings.WatchScope) {
+	watchedNamespaces.Reset()
+
+	if len(scope.Namespaces) == 0 {
+		watchedNamespaces.WithLabelValues(scope.Name(), "").Set(1)
+
+		return
+	}
+
+	for _, ns := range scope.Namespaces {
+		watchedNamespaces.WithLabelValues(scope.Name(), ns).Set(1)
+	}
+}
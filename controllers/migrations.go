@@ -19,7 +19,7 @@ func (r *GitLabReconciler) reconcileMigrationsConfigMap(ctx context.Context, ada
 }
 
 func (r *GitLabReconciler) runMigrationsJob(ctx context.Context, adapter gitlab.Adapter, job *batchv1.Job) (bool, error) {
-	if err := r.createOrPatch(ctx, job, adapter); err != nil {
+	if err := r.createOrRecreate(ctx, job, adapter); err != nil {
 		return false, err
 	}
 
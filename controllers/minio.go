@@ -24,12 +24,17 @@ func (r *GitLabReconciler) reconcileMinioInstance(ctx context.Context, adapter g
 		return err
 	}
 
+	minio := gitlabctl.MinioDeployment(adapter, template)
+
 	pvc := gitlabctl.MinioPersistentVolumeClaim(adapter, template)
+	if err := r.expandVolumeClaimOf(ctx, adapter, minio.GetName(), pvc); err != nil {
+		return err
+	}
+
 	if err := r.createOrPatch(ctx, pvc, adapter); err != nil {
 		return err
 	}
 
-	minio := gitlabctl.MinioDeployment(adapter, template)
 	if err := r.annotateSecretsChecksum(ctx, adapter, minio); err != nil {
 		return err
 	}
@@ -39,7 +44,7 @@ func (r *GitLabReconciler) reconcileMinioInstance(ctx context.Context, adapter g
 	}
 
 	ingress := gitlabctl.MinioIngress(adapter, template)
-	if err := r.reconcileIngress(ctx, ingress, adapter); err != nil {
+	if err := r.reconcileIngress(ctx, ingress, adapter, template); err != nil {
 		return err
 	}
 
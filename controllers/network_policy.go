@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"context"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+// reconcileNetworkPolicies applies the NetworkPolicies of the enabled
+// components. They are applied before the components, so that the Pods of
+// the components start with their traffic allowed. The NetworkPolicies of
+// disabled components are removed with the other unmanaged objects.
+func (r *GitLabReconciler) reconcileNetworkPolicies(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
+	for _, policy := range gitlabctl.NetworkPolicies(adapter, template) {
+		if err := r.createOrPatch(ctx, policy, adapter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
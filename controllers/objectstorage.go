@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support/objectstorage"
+)
+
+const (
+	objectStorageProbeTimeout    = 30 * time.Second
+	objectStorageProbeRetryDelay = time.Minute
+)
+
+// objectStorageProbes keeps the results of the bucket checks, so that the
+// buckets are not written to on every reconcile.
+var objectStorageProbes = internal.NewProbeCache()
+
+// validateObjectStorageConfiguration checks that the buckets of the external
+// object storage exist and are writable, and creates the missing buckets when
+// bucket provisioning is enabled. It fails when a connection Secret is missing
+// or invalid. Inaccessible buckets do not stop the reconcile loop. Instead it
+// reports that the object storage is not ready so that the buckets are
+// checked again later.
+//
+// A bucket is only checked again when its connection changes or when the
+// result of the last check is older than objectStorageProbeRetryDelay.
+func (r *GitLabReconciler) validateObjectStorageConfiguration(ctx context.Context, adapter gitlab.Adapter) (bool, error) {
+	buckets := gitlabctl.ObjectStorageBuckets(adapter)
+	if len(buckets) == 0 {
+		return true, nil
+	}
+
+	logger := r.Log.WithValues("gitlab", adapter.Name())
+	now := time.Now()
+	clients := map[string]objectstorage.Client{}
+	checked := map[string]bool{}
+	events := []string{}
+	problems := []string{}
+	skipped := []string{}
+
+	for _, bucket := range buckets {
+		data, err := r.secretValue(ctx, adapter, bucket.ConnectionSecret, bucket.ConnectionKey)
+		if err != nil {
+			return false, err
+		}
+
+		connectionID := bucket.ConnectionSecret + "/" + bucket.ConnectionKey
+		probeKey := fmt.Sprintf("%s/%s/%s", adapter.Name(), connectionID, bucket.Bucket)
+		fingerprint := internal.ProbeFingerprint(string(data), strconv.FormatBool(adapter.ProvisionsBuckets()))
+
+		result, ok := objectStorageProbes.Lookup(probeKey, fingerprint, objectStorageProbeRetryDelay, now)
+		if !ok {
+			client, ok := clients[connectionID]
+			if !ok {
+				client, err = objectStorageClient(ctx, bucket, data)
+				if err != nil && !errors.Is(err, objectstorage.ErrUnsupportedCredentials) {
+					return false, err
+				}
+			}
+
+			if err != nil {
+				logger.Info("Skipping validation of object storage bucket", "type", bucket.Type,
+					"bucket", bucket.Bucket, "reason", err.Error())
+			} else {
+				clients[connectionID] = client
+				err = r.checkObjectStorageBucket(ctx, adapter, client, bucket)
+			}
+
+			result = objectStorageProbes.Store(probeKey, fingerprint, err, now)
+		}
+
+		if errors.Is(result.Err, objectstorage.ErrUnsupportedCredentials) {
+			skipped = append(skipped, bucket.Type)
+
+			continue
+		}
+
+		if checked[probeKey] {
+			continue
+		}
+
+		checked[probeKey] = true
+
+		if result.Err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", bucket.Bucket, result.Err))
+			events = append(events, fmt.Sprintf("Bucket %s for %s is not ready: %v", bucket.Bucket, bucket.Type, result.Err))
+		}
+	}
+
+	if len(problems) > 0 {
+		if conditionChanges(adapter, status.ConditionObjectStorageReady, false) {
+			for _, event := range events {
+				r.Recorder.Event(adapter.Origin(), "Warning", "ObjectStorageNotReady", event)
+			}
+		}
+
+		message := fmt.Sprintf("Object storage is not ready: %s", strings.Join(problems, "; "))
+
+		return false, r.setStatusCondition(ctx, adapter, status.ConditionObjectStorageReady, false, message)
+	}
+
+	message := "Object storage buckets exist and are writable"
+	if len(skipped) > 0 {
+		message = fmt.Sprintf("%s. Validation was skipped for %s because their credentials are only available to GitLab",
+			message, strings.Join(skipped, ", "))
+	}
+
+	return true, r.setStatusCondition(ctx, adapter, status.ConditionObjectStorageReady, true, message)
+}
+
+// objectStorageClient parses the connection of the bucket and returns a
+// client for its provider.
+func objectStorageClient(ctx context.Context, bucket gitlabctl.ObjectStorageBucket, data []byte) (objectstorage.Client, error) {
+	connection, err := objectstorage.ParseConnection(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object storage connection in Secret '%s': %w", bucket.ConnectionSecret, err)
+	}
+
+	return objectstorage.NewClient(ctx, connection)
+}
+
+// checkObjectStorageBucket checks that the bucket exists, or creates it when
+// bucket provisioning is enabled, and that it is writable.
+func (r *GitLabReconciler) checkObjectStorageBucket(ctx context.Context, adapter gitlab.Adapter, client objectstorage.Client, bucket gitlabctl.ObjectStorageBucket) error {
+	probeCtx, cancel := context.WithTimeout(ctx, objectStorageProbeTimeout)
+	defer cancel()
+
+	exists, err := client.BucketExists(probeCtx, bucket.Bucket)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if !adapter.ProvisionsBuckets() {
+			return fmt.Errorf("bucket does not exist")
+		}
+
+		if err := client.CreateBucket(probeCtx, bucket.Bucket); err != nil {
+			return fmt.Errorf("can not create bucket: %w", err)
+		}
+
+		r.Recorder.Event(adapter.Origin(), "Normal", "BucketCreated",
+			fmt.Sprintf("Created bucket %s for %s", bucket.Bucket, bucket.Type))
+	}
+
+	if err := client.CheckWritable(probeCtx, bucket.Bucket); err != nil {
+		return fmt.Errorf("bucket is not writable: %w", err)
+	}
+
+	return nil
+}
@@ -63,7 +63,7 @@ func (r *GitLabReconciler) reconcilePagesServices(ctx context.Context, adapter g
 }
 
 func (r *GitLabReconciler) reconcilePagesIngress(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
-	if err := r.reconcileIngress(ctx, gitlabctl.PagesIngress(template), adapter); err != nil {
+	if err := r.reconcileIngress(ctx, gitlabctl.PagesIngress(template), adapter, template); err != nil {
 		return err
 	}
 
@@ -2,12 +2,27 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
 )
 
+const (
+	databaseProbeTimeout    = 10 * time.Second
+	databaseProbeRetryDelay = time.Minute
+	databaseProbeInterval   = 10 * time.Minute
+)
+
+// postgresProbes keeps the results of the probes of the external PostgreSQL
+// server, so that a new connection is not opened on every reconcile.
+var postgresProbes = internal.NewProbeCache()
+
 func (r *GitLabReconciler) reconcilePostgres(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
 	if err := r.reconcilePostgresConfigMap(ctx, adapter, template); err != nil {
 		return err
@@ -35,10 +50,23 @@ func (r *GitLabReconciler) reconcilePostgresConfigMap(ctx context.Context, adapt
 func (r *GitLabReconciler) reconcilePostgresStatefulSet(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
 	ss := gitlabctl.PostgresStatefulSet(adapter, template)
 
+	desired, err := internal.AsStatefulSet(ss)
+	if err != nil {
+		return err
+	}
+
+	if apply, err := r.upgradePostgres(ctx, adapter, template, desired); err != nil || !apply {
+		return err
+	}
+
 	if err := r.annotateSecretsChecksum(ctx, adapter, ss); err != nil {
 		return err
 	}
 
+	if err := r.expandStatefulSetVolumes(ctx, adapter, ss); err != nil {
+		return err
+	}
+
 	if err := r.createOrPatch(ctx, ss, adapter); err != nil {
 		return err
 	}
@@ -46,22 +74,105 @@ func (r *GitLabReconciler) reconcilePostgresStatefulSet(ctx context.Context, ada
 	return nil
 }
 
-func (r *GitLabReconciler) validateExternalPostgresConfiguration(ctx context.Context, adapter gitlab.Adapter) error {
+// validateExternalPostgresConfiguration probes the external PostgreSQL server
+// and records the result in the status. It fails when the password or TLS
+// Secret is missing. An unreachable server does not stop the reconcile loop.
+// Instead it reports that the database is not ready so that the server is
+// probed again later.
+//
+// The server is only probed again when its endpoint or Secrets change, or when
+// the result of the last probe is older than databaseProbeInterval, or
+// databaseProbeRetryDelay when the probe failed.
+func (r *GitLabReconciler) validateExternalPostgresConfiguration(ctx context.Context, adapter gitlab.Adapter) (bool, error) {
+	endpoint, err := r.postgresEndpoint(ctx, adapter, gitlabctl.ExternalPostgresConnection(adapter))
+	if err != nil {
+		if statusErr := r.setStatusCondition(ctx, adapter, status.ConditionDatabaseReady, false, err.Error()); statusErr != nil {
+			return false, statusErr
+		}
+
+		return false, err
+	}
+
+	now := time.Now()
+	probeKey := adapter.Name().String()
+	fingerprint := internal.ProbeFingerprint(endpoint.Host, strconv.Itoa(endpoint.Port), endpoint.Database,
+		endpoint.Username, endpoint.Password, string(endpoint.ServerCA), string(endpoint.ClientCertificate),
+		string(endpoint.ClientKey), strconv.Itoa(gitlabctl.PostgresMinimumVersion(adapter)))
+
+	result, ok := postgresProbes.Lookup(probeKey, fingerprint, databaseProbeInterval, now)
+	if ok && result.Err != nil {
+		result, ok = postgresProbes.Lookup(probeKey, fingerprint, databaseProbeRetryDelay, now)
+	}
+
+	if !ok {
+		result = postgresProbes.Store(probeKey, fingerprint, probeExternalPostgres(ctx, adapter, endpoint), now)
+	}
+
+	if result.Err != nil {
+		if conditionChanges(adapter, status.ConditionDatabaseReady, false) {
+			r.Recorder.Event(adapter.Origin(), "Warning", "DatabaseNotReady",
+				fmt.Sprintf("External PostgreSQL is not ready: %v", result.Err))
+		}
+
+		return false, r.setStatusCondition(ctx, adapter, status.ConditionDatabaseReady, false, result.Err.Error())
+	}
+
+	return true, r.setStatusCondition(ctx, adapter, status.ConditionDatabaseReady, true, "External PostgreSQL is reachable and meets the requirements")
+}
+
+// probeExternalPostgres connects to the external PostgreSQL server with the
+// configured credentials and TLS settings, and checks the server version and
+// the required extensions.
+func probeExternalPostgres(ctx context.Context, adapter gitlab.Adapter, endpoint internal.PostgreSQLEndpoint) error {
+	probeCtx, cancel := context.WithTimeout(ctx, databaseProbeTimeout)
+	defer cancel()
+
+	result, err := internal.ProbePostgreSQL(probeCtx, endpoint)
+	if err != nil {
+		return fmt.Errorf("can not connect to PostgreSQL at %s:%d: %w", endpoint.Host, endpoint.Port, err)
+	}
+
+	return result.Validate(gitlabctl.PostgresMinimumVersion(adapter))
+}
+
+// postgresEndpoint reads the password and the TLS certificates of the
+// connection from their Secrets.
+func (r *GitLabReconciler) postgresEndpoint(ctx context.Context, adapter gitlab.Adapter, connection gitlabctl.PostgresConnection) (internal.PostgreSQLEndpoint, error) {
 	// Ensure that the PostgreSQL password Secret was created.
-	pgSecretName := adapter.Values().GetString("global.psql.password.secret")
-	if err := r.ensureSecret(ctx, adapter, pgSecretName); err != nil {
-		return err
+	password, err := r.secretValue(ctx, adapter, connection.PasswordSecret, connection.PasswordKey)
+	if err != nil {
+		return internal.PostgreSQLEndpoint{}, err
+	}
+
+	endpoint := internal.PostgreSQLEndpoint{
+		Host:           connection.Host,
+		Port:           connection.Port,
+		Database:       connection.Database,
+		Username:       connection.Username,
+		Password:       string(password),
+		ConnectTimeout: databaseProbeTimeout,
 	}
 
 	// If set, ensure that the PostgreSQL SSL Secret was created.
-	pgSecretNameSSL := adapter.Values().GetString("global.psql.ssl.secret", "unset")
-	if pgSecretNameSSL != "unset" {
-		if err := r.ensureSecret(ctx, adapter, pgSecretNameSSL); err != nil {
-			return err
+	if connection.SSLSecret != "" {
+		if err := r.ensureSecret(ctx, adapter, connection.SSLSecret); err != nil {
+			return endpoint, err
+		}
+
+		if endpoint.ServerCA, err = r.optionalSecretValue(ctx, adapter, connection.SSLSecret, connection.ServerCAKey); err != nil {
+			return endpoint, err
+		}
+
+		if endpoint.ClientCertificate, err = r.optionalSecretValue(ctx, adapter, connection.SSLSecret, connection.ClientCertificateKey); err != nil {
+			return endpoint, err
+		}
+
+		if endpoint.ClientKey, err = r.optionalSecretValue(ctx, adapter, connection.SSLSecret, connection.ClientKeyKey); err != nil {
+			return endpoint, err
 		}
 	}
 
-	return nil
+	return endpoint, nil
 }
 
 func (r *GitLabReconciler) reconcilePostgresServices(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
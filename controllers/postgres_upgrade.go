@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+// postgresUpgradeRetryDelay is the delay between checking the progress of
+// the major version upgrade of the bundled PostgreSQL.
+const postgresUpgradeRetryDelay = 15 * time.Second
+
+// upgradePostgres upgrades the bundled PostgreSQL when the desired
+// StatefulSet uses a newer major version than the current one. It returns
+// true when the desired StatefulSet can be applied.
+//
+// The data directory of PostgreSQL can not be used by a newer major version.
+// Instead, the upgrade:
+//
+//  1. Stops the database users from logging in and dumps all databases to a
+//     backup volume with the previous major version.
+//  2. Retains the PersistentVolume of the data directory and deletes the
+//     StatefulSet and its PersistentVolumeClaim.
+//  3. Creates the StatefulSet with the new major version and an empty data
+//     volume, restores the dump, compares the number of rows of all tables
+//     and allows the database users to log in again.
+//
+// The progress is recorded in the status.
+func (r *GitLabReconciler) upgradePostgres(ctx context.Context, adapter gitlab.Adapter, template helm.Template, desired *appsv1.StatefulSet) (bool, error) {
+	upgrade, err := r.pendingPostgresUpgrade(ctx, adapter, desired)
+	if err != nil {
+		return true, err
+	}
+
+	if upgrade == nil {
+		return true, r.clearStalePostgresUpgrade(ctx, adapter)
+	}
+
+	apply := false
+
+	switch upgrade.Phase {
+	case status.PostgreSQLUpgradeBackingUp:
+		err = r.backUpPostgres(ctx, adapter, template, desired, upgrade)
+	case status.PostgreSQLUpgradeReplacing:
+		err = r.replacePostgres(ctx, adapter, desired, upgrade)
+	case status.PostgreSQLUpgradeRestoring:
+		apply = true
+		err = r.restorePostgres(ctx, adapter, template, desired, upgrade)
+	case status.PostgreSQLUpgradeFailed:
+		// Keep the previous major version until the upgrade is retried,
+		// unless it was already replaced.
+		apply = upgrade.RetainedVolume != ""
+		err = r.retryPostgresUpgrade(ctx, adapter, template, desired, upgrade)
+	}
+
+	if err != nil {
+		return apply, err
+	}
+
+	adapter.RecordPostgreSQLUpgrade(upgrade)
+
+	return apply, r.Status().Update(ctx, adapter.Origin())
+}
+
+// pendingPostgresUpgrade returns the upgrade that is in progress or failed,
+// or starts a new upgrade when the major version of the desired StatefulSet
+// is newer than the current one. It returns nil when there is nothing to do.
+func (r *GitLabReconciler) pendingPostgresUpgrade(ctx context.Context, adapter gitlab.Adapter, desired *appsv1.StatefulSet) (*gitlab.PostgreSQLUpgrade, error) {
+	desiredVersion := internal.PostgreSQLImageMajorVersion(gitlabctl.PostgresContainerImage(desired))
+
+	if upgrade := adapter.PostgreSQLUpgrade(); upgrade != nil &&
+		upgrade.ToVersion == desiredVersion && upgrade.Phase != status.PostgreSQLUpgradeCompleted {
+		return upgrade, nil
+	}
+
+	current := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), current); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	currentImage := gitlabctl.PostgresContainerImage(current)
+	currentVersion := internal.PostgreSQLImageMajorVersion(currentImage)
+
+	if currentVersion == 0 || desiredVersion <= currentVersion {
+		return nil, nil
+	}
+
+	r.Recorder.Event(adapter.Origin(), "Normal", "PostgreSQLUpgradeStarted",
+		fmt.Sprintf("Upgrading PostgreSQL from %d to %d. GitLab is unavailable during the upgrade",
+			currentVersion, desiredVersion))
+
+	return &gitlab.PostgreSQLUpgrade{
+		FromVersion: currentVersion,
+		ToVersion:   desiredVersion,
+		FromImage:   currentImage,
+		Phase:       status.PostgreSQLUpgradeBackingUp,
+		Message:     "Backing up the databases",
+	}, nil
+}
+
+// clearStalePostgresUpgrade removes the recorded upgrade when it is neither
+// completed nor matches the desired major version any more, for example when
+// the chart version is reverted after a failed upgrade.
+func (r *GitLabReconciler) clearStalePostgresUpgrade(ctx context.Context, adapter gitlab.Adapter) error {
+	if upgrade := adapter.PostgreSQLUpgrade(); upgrade == nil || upgrade.Phase == status.PostgreSQLUpgradeCompleted {
+		return nil
+	}
+
+	adapter.RecordPostgreSQLUpgrade(nil)
+
+	return r.Status().Update(ctx, adapter.Origin())
+}
+
+// backUpPostgres runs the Job that dumps the databases with the previous
+// major version.
+func (r *GitLabReconciler) backUpPostgres(ctx context.Context, adapter gitlab.Adapter, template helm.Template, desired *appsv1.StatefulSet, upgrade *gitlab.PostgreSQLUpgrade) error {
+	current := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), current); err != nil {
+		return err
+	}
+
+	// The backup is kept when the GitLab resource is deleted.
+	claim := gitlabctl.PostgresUpgradeBackupClaim(adapter, current)
+	if err := r.Create(ctx, claim); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	job, err := gitlabctl.PostgresUpgradeBackupJob(adapter, template, current, upgrade.FromImage)
+	if err != nil {
+		return err
+	}
+
+	finished, succeeded, err := r.runPostgresUpgradeJob(ctx, adapter, job)
+	if err != nil || !finished {
+		return err
+	}
+
+	if !succeeded {
+		r.failPostgresUpgrade(adapter, upgrade,
+			fmt.Sprintf("Job %s failed to back up the databases. PostgreSQL %d is kept", job.Name, upgrade.FromVersion))
+
+		return nil
+	}
+
+	upgrade.Phase = status.PostgreSQLUpgradeReplacing
+	upgrade.Message = fmt.Sprintf("Replacing PostgreSQL %d", upgrade.FromVersion)
+
+	return nil
+}
+
+// replacePostgres retains the data volume of the previous major version and
+// deletes the StatefulSet and its PersistentVolumeClaim, one step at a time.
+func (r *GitLabReconciler) replacePostgres(ctx context.Context, adapter gitlab.Adapter, desired *appsv1.StatefulSet, upgrade *gitlab.PostgreSQLUpgrade) error {
+	if len(desired.Spec.VolumeClaimTemplates) == 0 {
+		return fmt.Errorf("StatefulSet %s does not have a data volume", desired.Name)
+	}
+
+	claim := &corev1.PersistentVolumeClaim{}
+	claimKey := types.NamespacedName{
+		Name:      internal.VolumeClaimName(desired.Spec.VolumeClaimTemplates[0].Name, desired.Name, 0),
+		Namespace: desired.Namespace,
+	}
+
+	claimExists, err := r.lookup(ctx, claimKey, claim)
+	if err != nil {
+		return err
+	}
+
+	if claimExists && upgrade.RetainedVolume == "" && claim.Spec.VolumeName != "" {
+		if err := r.retainPersistentVolume(ctx, claim.Spec.VolumeName); err != nil {
+			return err
+		}
+
+		upgrade.RetainedVolume = claim.Spec.VolumeName
+	}
+
+	statefulSetExists, err := r.lookup(ctx, client.ObjectKeyFromObject(desired), &appsv1.StatefulSet{})
+	if err != nil {
+		return err
+	}
+
+	if statefulSetExists {
+		upgrade.Message = fmt.Sprintf("Deleting the StatefulSet of PostgreSQL %d", upgrade.FromVersion)
+
+		return r.deleteIfExists(ctx, &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{
+			Name: desired.Name, Namespace: desired.Namespace}})
+	}
+
+	podExists, err := r.lookup(ctx, types.NamespacedName{
+		Name: fmt.Sprintf("%s-0", desired.Name), Namespace: desired.Namespace}, &corev1.Pod{})
+	if err != nil {
+		return err
+	}
+
+	if podExists {
+		upgrade.Message = fmt.Sprintf("Waiting for PostgreSQL %d to stop", upgrade.FromVersion)
+		return nil
+	}
+
+	if claimExists {
+		upgrade.Message = fmt.Sprintf("Deleting the data volume claim of PostgreSQL %d", upgrade.FromVersion)
+		return r.deleteIfExists(ctx, claim)
+	}
+
+	upgrade.Phase = status.PostgreSQLUpgradeRestoring
+	upgrade.Message = fmt.Sprintf("Waiting for PostgreSQL %d to become ready", upgrade.ToVersion)
+
+	return nil
+}
+
+// restorePostgres runs the Job that restores the dump into the new major
+// version once it is ready.
+func (r *GitLabReconciler) restorePostgres(ctx context.Context, adapter gitlab.Adapter, template helm.Template, desired *appsv1.StatefulSet, upgrade *gitlab.PostgreSQLUpgrade) error {
+	service := gitlabctl.PostgresService(adapter, template)
+	if service == nil || !r.isEndpointReady(ctx, service.GetName(), adapter) {
+		upgrade.Message = fmt.Sprintf("Waiting for PostgreSQL %d to become ready", upgrade.ToVersion)
+		return nil
+	}
+
+	job, err := gitlabctl.PostgresUpgradeRestoreJob(adapter, template, desired, gitlabctl.PostgresContainerImage(desired))
+	if err != nil {
+		return err
+	}
+
+	upgrade.Message = "Restoring the databases"
+
+	finished, succeeded, err := r.runPostgresUpgradeJob(ctx, adapter, job)
+	if err != nil || !finished {
+		return err
+	}
+
+	if !succeeded {
+		r.failPostgresUpgrade(adapter, upgrade,
+			fmt.Sprintf("Job %s failed to restore the databases. The data of PostgreSQL %d is retained in PersistentVolume %s",
+				job.Name, upgrade.FromVersion, upgrade.RetainedVolume))
+
+		return nil
+	}
+
+	upgrade.Phase = status.PostgreSQLUpgradeCompleted
+	upgrade.Message = fmt.Sprintf("Upgraded PostgreSQL from %d to %d", upgrade.FromVersion, upgrade.ToVersion)
+
+	r.Recorder.Event(adapter.Origin(), "Normal", "PostgreSQLUpgradeCompleted", upgrade.Message)
+
+	return nil
+}
+
+// retryPostgresUpgrade retries the failed step of the upgrade once its Job
+// is deleted.
+func (r *GitLabReconciler) retryPostgresUpgrade(ctx context.Context, adapter gitlab.Adapter, template helm.Template, desired *appsv1.StatefulSet, upgrade *gitlab.PostgreSQLUpgrade) error {
+	var (
+		job   *batchv1.Job
+		err   error
+		phase string
+	)
+
+	if upgrade.RetainedVolume == "" {
+		job, err = gitlabctl.PostgresUpgradeBackupJob(adapter, template, desired, upgrade.FromImage)
+		phase = status.PostgreSQLUpgradeBackingUp
+	} else {
+		job, err = gitlabctl.PostgresUpgradeRestoreJob(adapter, template, desired, gitlabctl.PostgresContainerImage(desired))
+		phase = status.PostgreSQLUpgradeRestoring
+	}
+
+	if err != nil {
+		return err
+	}
+
+	exists, err := r.lookup(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{})
+	if err != nil || exists {
+		return err
+	}
+
+	upgrade.Phase = phase
+	upgrade.Message = fmt.Sprintf("Retrying the upgrade from %d to %d", upgrade.FromVersion, upgrade.ToVersion)
+
+	r.Recorder.Event(adapter.Origin(), "Normal", "PostgreSQLUpgradeRetrying", upgrade.Message)
+
+	return nil
+}
+
+// runPostgresUpgradeJob ensures that the Job exists and reports whether it
+// is finished and succeeded.
+func (r *GitLabReconciler) runPostgresUpgradeJob(ctx context.Context, adapter gitlab.Adapter, job *batchv1.Job) (bool, bool, error) {
+	if err := r.createOrPatch(ctx, job, adapter); err != nil {
+		return false, false, err
+	}
+
+	lookup, err := r.lookupJob(ctx, job)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, false, nil
+		}
+
+		return false, false, err
+	}
+
+	return isJobFinished(lookup), lookup.Status.Succeeded > 0, nil
+}
+
+func (r *GitLabReconciler) failPostgresUpgrade(adapter gitlab.Adapter, upgrade *gitlab.PostgreSQLUpgrade, message string) {
+	upgrade.Phase = status.PostgreSQLUpgradeFailed
+	upgrade.Message = message
+
+	r.Recorder.Event(adapter.Origin(), "Warning", "PostgreSQLUpgradeFailed", message)
+}
+
+// retainPersistentVolume sets the reclaim policy of the PersistentVolume to
+// Retain, so that it is not deleted with its PersistentVolumeClaim.
+func (r *GitLabReconciler) retainPersistentVolume(ctx context.Context, name string) error {
+	volume := &corev1.PersistentVolume{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, volume); err != nil {
+		return err
+	}
+
+	if volume.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain {
+		return nil
+	}
+
+	patch := client.MergeFrom(volume.DeepCopy())
+	volume.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+
+	return r.Patch(ctx, volume, patch)
+}
+
+// lookup gets the object and returns false when it does not exist.
+func (r *GitLabReconciler) lookup(ctx context.Context, key types.NamespacedName, obj client.Object) (bool, error) {
+	if err := r.Get(ctx, key, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *GitLabReconciler) deleteIfExists(ctx context.Context, obj client.Object) error {
+	propagation := metav1.DeletePropagationBackground
+
+	if err := r.Delete(ctx, obj, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// isPostgresUpgradePending returns true when the major version upgrade of
+// the bundled PostgreSQL is in progress or failed. The other components are
+// not reconciled until the upgrade is completed.
+func isPostgresUpgradePending(adapter gitlab.Adapter) bool {
+	upgrade := adapter.PostgreSQLUpgrade()
+
+	return upgrade != nil && upgrade.Phase != status.PostgreSQLUpgradeCompleted
+}
@@ -2,10 +2,18 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+const (
+	praefectDatabaseRetryDelay = 30 * time.Second
 )
 
 func (r *GitLabReconciler) reconcilePraefect(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
@@ -33,7 +41,13 @@ func (r *GitLabReconciler) reconcilePraefectConfigMap(ctx context.Context, adapt
 }
 
 func (r *GitLabReconciler) reconcilePraefectStatefulSet(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
-	if err := r.createOrPatch(ctx, gitlabctl.PraefectStatefulSet(template), adapter); err != nil {
+	praefect := gitlabctl.PraefectStatefulSet(template)
+
+	if err := r.annotateSecretsChecksum(ctx, adapter, praefect); err != nil {
+		return err
+	}
+
+	if err := r.createOrPatch(ctx, praefect, adapter); err != nil {
 		return err
 	}
 
@@ -47,3 +61,71 @@ func (r *GitLabReconciler) reconcilePraefectService(ctx context.Context, adapter
 
 	return nil
 }
+
+// provisionPraefectDatabase creates the Praefect database and user in the
+// bundled PostgreSQL, using the password from the Praefect database Secret.
+// The provisioning is idempotent, so it runs on every reconcile. It reports
+// that the database is not ready, without stopping the reconcile loop, while
+// the bundled PostgreSQL is not ready or the provisioning fails.
+func (r *GitLabReconciler) provisionPraefectDatabase(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (bool, error) {
+	if !gitlabctl.ProvisionsPraefectDatabase(adapter) {
+		return true, nil
+	}
+
+	if service := gitlabctl.PostgresService(adapter, template); service == nil || !r.isEndpointReady(ctx, service.GetName(), adapter) {
+		return false, r.setStatusCondition(ctx, adapter, status.ConditionPraefectDatabaseReady, false,
+			"Waiting for the bundled PostgreSQL to become ready")
+	}
+
+	created, err := r.createPraefectDatabase(ctx, adapter, template)
+	if err != nil {
+		r.Recorder.Event(adapter.Origin(), "Warning", "PraefectDatabaseNotReady",
+			fmt.Sprintf("Failed to provision the Praefect database: %v", err))
+
+		return false, r.setStatusCondition(ctx, adapter, status.ConditionPraefectDatabaseReady, false,
+			fmt.Sprintf("Failed to provision the Praefect database: %v", err))
+	}
+
+	if created {
+		r.Recorder.Event(adapter.Origin(), "Normal", "PraefectDatabaseCreated",
+			"Created the Praefect database in the bundled PostgreSQL")
+	}
+
+	return true, r.setStatusCondition(ctx, adapter, status.ConditionPraefectDatabaseReady, true,
+		"The Praefect database exists in the bundled PostgreSQL")
+}
+
+// createPraefectDatabase connects to the bundled PostgreSQL as the superuser
+// and creates the Praefect role and database when they do not exist.
+func (r *GitLabReconciler) createPraefectDatabase(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (bool, error) {
+	admin := gitlabctl.BundledPostgresAdminConnection(adapter, template)
+	praefect := gitlabctl.PraefectDatabaseConnection(adapter)
+
+	adminPassword, err := r.secretValue(ctx, adapter, admin.PasswordSecret, admin.PasswordKey)
+	if err != nil {
+		return false, err
+	}
+
+	praefectPassword, err := r.secretValue(ctx, adapter, praefect.PasswordSecret, praefect.PasswordKey)
+	if err != nil {
+		return false, err
+	}
+
+	endpoint := internal.PostgreSQLEndpoint{
+		Host:           admin.Host,
+		Port:           admin.Port,
+		Database:       admin.Database,
+		Username:       admin.Username,
+		Password:       string(adminPassword),
+		ConnectTimeout: databaseProbeTimeout,
+	}
+
+	provisionCtx, cancel := context.WithTimeout(ctx, databaseProbeTimeout)
+	defer cancel()
+
+	return internal.ProvisionPostgreSQLDatabase(provisionCtx, endpoint, internal.PostgreSQLDatabase{
+		Database: praefect.Database,
+		Role:     praefect.Username,
+		Password: string(praefectPassword),
+	})
+}
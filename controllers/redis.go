@@ -3,11 +3,18 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
-	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+const (
+	redisProbeRetryDelay = time.Minute
 )
 
 func (r *GitLabReconciler) reconcileRedis(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
@@ -43,6 +50,10 @@ func (r *GitLabReconciler) reconcileRedisStatefulSet(ctx context.Context, adapte
 		return err
 	}
 
+	if err := r.expandStatefulSetVolumes(ctx, adapter, redis); err != nil {
+		return err
+	}
+
 	if err := r.createOrPatch(ctx, redis, adapter); err != nil {
 		return err
 	}
@@ -50,34 +61,124 @@ func (r *GitLabReconciler) reconcileRedisStatefulSet(ctx context.Context, adapte
 	return nil
 }
 
-func (r *GitLabReconciler) validateExternalRedisConfiguration(ctx context.Context, adapter gitlab.Adapter) error {
-	defaultRedisSecretName := adapter.Values().GetString("global.redis.password.secret")
-	if defaultRedisSecretName == "" {
-		defaultRedisSecretName = fmt.Sprintf("%s-%s-secret", adapter.ReleaseName(), gitlabctl.RedisComponentName(adapter))
+// validateExternalRedisConfiguration probes every external Redis instance,
+// including the subqueues, and records their health in the status. It fails
+// when the password Secret of an instance is missing. Unreachable instances
+// do not stop the reconcile loop. Instead it reports that Redis is not ready
+// so that the instances are probed again later.
+func (r *GitLabReconciler) validateExternalRedisConfiguration(ctx context.Context, adapter gitlab.Adapter) (bool, error) {
+	connections := gitlabctl.ExternalRedisConnections(adapter)
+	if len(connections) == 0 {
+		return true, nil
 	}
 
-	// If external Redis global password is enabled, ensure it was created.
-	if adapter.WantsComponent(component.Redis) {
-		redisSecretName := adapter.Values().GetString("global.redis.password.secret", defaultRedisSecretName)
-		if err := r.ensureSecret(ctx, adapter, redisSecretName); err != nil {
-			return err
+	minimumVersion := gitlabctl.RedisMinimumVersion(adapter)
+	problems := []string{}
+	events := []string{}
+
+	var configErr error
+
+	for _, connection := range connections {
+		health := gitlab.RedisHealth{
+			Instance: connection.Instance,
+			ProbedAt: time.Now(),
+		}
+
+		endpoint, err := r.externalRedisEndpoint(ctx, adapter, connection)
+		if err != nil {
+			configErr = err
+		} else {
+			var result *internal.RedisProbeResult
+
+			if result, err = r.probeExternalRedis(ctx, endpoint); err == nil {
+				health.Address = result.Address
+				health.Version = result.ServerVersion
+				err = result.Validate(minimumVersion)
+			}
+		}
+
+		if err != nil {
+			health.Message = err.Error()
+			problems = append(problems, fmt.Sprintf("%s: %v", connection.Instance, err))
+
+			events = append(events,
+				fmt.Sprintf("External Redis instance %s is not ready: %v", connection.Instance, err))
+		} else {
+			health.Ready = true
 		}
+
+		adapter.RecordRedisHealth(health)
 	}
 
-	// If any of the sub-queues and configured, ensure relevant Secrets are created if enabled.
-	for _, subqueue := range gitlabctl.RedisSubqueues() {
-		if host := adapter.Values().GetString(fmt.Sprintf("global.redis.%s.host", subqueue)); host != "" {
-			// Subqueue is configured. Ensure its password was created.
-			if passwordEnabled := adapter.Values().GetBool(fmt.Sprintf("global.redis.%s.password.enabled", subqueue), true); passwordEnabled {
-				subqueueSecretName := adapter.Values().GetString(fmt.Sprintf("global.redis.%s.password.secret", subqueue), defaultRedisSecretName)
-				if err := r.ensureSecret(ctx, adapter, subqueueSecretName); err != nil {
-					return err
-				}
+	if len(problems) > 0 {
+		if conditionChanges(adapter, status.ConditionRedisReady, false) {
+			for _, event := range events {
+				r.Recorder.Event(adapter.Origin(), "Warning", "RedisNotReady", event)
 			}
 		}
+
+		message := fmt.Sprintf("External Redis is not ready: %s", strings.Join(problems, "; "))
+
+		if err := r.setStatusCondition(ctx, adapter, status.ConditionRedisReady, false, message); err != nil {
+			return false, err
+		}
+
+		return false, configErr
+	}
+
+	return true, r.setStatusCondition(ctx, adapter, status.ConditionRedisReady, true, "External Redis instances are reachable and meet the requirements")
+}
+
+// externalRedisEndpoint resolves the passwords of the external Redis instance
+// from their Secrets.
+func (r *GitLabReconciler) externalRedisEndpoint(ctx context.Context, adapter gitlab.Adapter, connection gitlabctl.RedisConnection) (internal.RedisEndpoint, error) {
+	endpoint := internal.RedisEndpoint{
+		Host:      connection.Host,
+		Port:      connection.Port,
+		TLS:       connection.TLS,
+		Username:  connection.Username,
+		Sentinels: connection.Sentinels,
+		Timeout:   databaseProbeTimeout,
+	}
+
+	if connection.TLS {
+		rootCAs, err := r.customCertificatePool(ctx, adapter)
+		if err != nil {
+			return endpoint, err
+		}
+
+		endpoint.RootCAs = rootCAs
 	}
 
-	return nil
+	// Ensure that the Redis password Secret was created.
+	if connection.PasswordSecret != "" {
+		password, err := r.secretValue(ctx, adapter, connection.PasswordSecret, connection.PasswordKey)
+		if err != nil {
+			return endpoint, err
+		}
+
+		endpoint.Password = string(password)
+	}
+
+	if connection.SentinelPasswordSecret != "" {
+		password, err := r.secretValue(ctx, adapter, connection.SentinelPasswordSecret, connection.SentinelPasswordKey)
+		if err != nil {
+			return endpoint, err
+		}
+
+		endpoint.SentinelPassword = string(password)
+	}
+
+	return endpoint, nil
+}
+
+// probeExternalRedis connects to the external Redis instance, discovering the
+// master through the Sentinels if needed, and reads the server version.
+func (r *GitLabReconciler) probeExternalRedis(ctx context.Context, endpoint internal.RedisEndpoint) (*internal.RedisProbeResult, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, databaseProbeTimeout)
+	defer cancel()
+
+	return internal.ProbeRedis(probeCtx, endpoint)
 }
 
 func (r *GitLabReconciler) reconcileRedisServices(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
@@ -61,7 +61,7 @@ func (r *GitLabReconciler) reconcileRegistryService(ctx context.Context, adapter
 }
 
 func (r *GitLabReconciler) reconcileRegistryIngress(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
-	if err := r.reconcileIngress(ctx, gitlabctl.RegistryIngress(template), adapter); err != nil {
+	if err := r.reconcileIngress(ctx, gitlabctl.RegistryIngress(template), adapter, template); err != nil {
 		return err
 	}
 
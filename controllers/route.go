@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+// reconcileRoutes applies the OpenShift Routes that replace the Ingress. The
+// Ingress itself is not applied, so that the OpenShift Router does not
+// translate it into Routes of its own.
+//
+// The certificates of the TLS Secrets are embedded in the Routes. A missing
+// Secret is embedded once it exists, on the next reconcile.
+func (r *GitLabReconciler) reconcileRoutes(ctx context.Context, ingress *networkingv1.Ingress, adapter gitlab.Adapter, template helm.Template) error {
+	certificates := map[string]gitlabctl.RouteCertificate{}
+
+	for _, name := range gitlabctl.IngressRouteSecrets(ingress) {
+		secret := &corev1.Secret{}
+
+		exists, err := r.lookup(ctx, types.NamespacedName{Name: name, Namespace: adapter.Name().Namespace}, secret)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			certificates[name] = gitlabctl.NewRouteCertificate(secret)
+		}
+	}
+
+	for _, route := range gitlabctl.IngressRoutes(ingress, template, certificates) {
+		if err := r.createOrPatch(ctx, route, adapter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newRoute returns an empty OpenShift Route. The Operator does not depend on
+// the OpenShift API, so Routes are unstructured objects.
+func newRoute() *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetAPIVersion(gitlabctl.RouteAPIVersion)
+	route.SetKind(gitlabctl.RouteKind)
+
+	return route
+}
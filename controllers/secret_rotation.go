@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+const (
+	// rotatedAtAnnotation records the time of the last rotation on the Secret.
+	rotatedAtAnnotation = "apps.gitlab.com/rotated-at"
+
+	// rotationPhaseAnnotation records the phase of the rollout of a rotated
+	// Secret. It is removed when the rollout is completed.
+	rotationPhaseAnnotation = "apps.gitlab.com/rotation-phase"
+
+	// rotationPhaseTimeAnnotation records when the current phase started.
+	rotationPhaseTimeAnnotation = "apps.gitlab.com/rotation-phase-time"
+
+	// rotationServersAnnotation lists the app labels of the components that
+	// are rolled before the other components that use the rotated Secret.
+	rotationServersAnnotation = "apps.gitlab.com/rotation-servers"
+
+	// secretRotationCheckInterval is the delay between checking the rollout
+	// of a rotated Secret.
+	secretRotationCheckInterval = 30 * time.Second
+)
+
+// rotateSecrets regenerates the rotated Secrets that are due and drives the
+// rollout of the new values. It returns the delay until the next rotation or
+// rollout check is due, or zero when nothing is scheduled.
+//
+// The rollout is staged. First the components that verify the Secret, for
+// example Gitaly and Praefect for the Gitaly token, are restarted with the new
+// value while the other components keep their Pods. When they are rolled out,
+// the remaining components are restarted. Gitaly and Praefect accept any token
+// until all their clients are rolled out and the overlap period has elapsed.
+// The adapter turns on their transitioning mode in the chart values while the
+// rotation is in progress.
+//
+// Only the Secrets that the shared secrets Job or the Operator generated are
+// rotated. Secrets that the user provides are left alone, and so are the
+// Secrets whose servers can not accept the previous value.
+func (r *GitLabReconciler) rotateSecrets(ctx context.Context, adapter gitlab.Adapter) (time.Duration, error) {
+	var next time.Duration
+
+	now := time.Now()
+	overlap := adapter.SecretRotationOverlap()
+
+	for _, rotation := range adapter.SecretRotations() {
+		servers := gitlabctl.RotationServers(rotation.Secret)
+		if len(servers) == 0 {
+			r.Log.Info("skipping rotation of secret that can not be rotated without an outage",
+				"gitlab", adapter.Name(), "secret", rotation.Secret)
+
+			continue
+		}
+
+		target, err := gitlabctl.RotatableSecret(adapter, rotation.Secret)
+		if err != nil {
+			return 0, err
+		}
+
+		secret := &corev1.Secret{}
+		lookupKey := types.NamespacedName{Name: target.Name, Namespace: adapter.Name().Namespace}
+
+		if err := r.Get(ctx, lookupKey, secret); err != nil {
+			if errors.IsNotFound(err) {
+				// The Secret is not generated yet. There is nothing to rotate.
+				continue
+			}
+
+			return 0, err
+		}
+
+		if !gitlabctl.IsGeneratedSecret(adapter, secret) {
+			r.Log.V(1).Info("skipping rotation of secret that is not generated",
+				"gitlab", adapter.Name(), "secret", secret.Name)
+
+			continue
+		}
+
+		rotatedAt := lastSecretRotation(secret)
+		phase := secret.Annotations[rotationPhaseAnnotation]
+
+		switch phase {
+		case status.SecretRotationRollingServers:
+			rolledOut, err := r.secretRolledOut(ctx, adapter, secret, rotationServers(secret))
+			if err != nil {
+				return 0, err
+			}
+
+			if rolledOut {
+				phase = status.SecretRotationRollingClients
+
+				if err := r.setSecretRotationPhase(ctx, secret, phase, now); err != nil {
+					return 0, err
+				}
+			}
+		case status.SecretRotationRollingClients:
+			rolledOut, err := r.secretRolledOut(ctx, adapter, secret, nil)
+			if err != nil {
+				return 0, err
+			}
+
+			if rolledOut && !now.Before(secretRotationPhaseTime(secret).Add(overlap)) {
+				phase = ""
+
+				if err := r.setSecretRotationPhase(ctx, secret, phase, now); err != nil {
+					return 0, err
+				}
+
+				r.Log.Info("completed rollout of rotated secret", "gitlab", adapter.Name(), "secret", secret.Name)
+			}
+		default:
+			if !now.Before(rotatedAt.Add(rotation.Interval)) {
+				if err := r.rotateSecret(ctx, adapter, secret, target, servers, now); err != nil {
+					return 0, err
+				}
+
+				phase = status.SecretRotationRollingServers
+				rotatedAt = now
+			}
+		}
+
+		adapter.RecordSecretRotation(rotation.Secret, secret.Name, phase, rotatedAt)
+
+		if phase != "" {
+			next = earliestDelay(next, secretRotationCheckInterval)
+		} else {
+			next = earliestDelay(next, time.Until(rotatedAt.Add(rotation.Interval)))
+		}
+	}
+
+	return next, nil
+}
+
+func (r *GitLabReconciler) rotateSecret(ctx context.Context, adapter gitlab.Adapter, secret *corev1.Secret, target gitlabctl.GeneratedSecret, servers []string, now time.Time) error {
+	value, err := target.Generate()
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	secret.Data[target.Key] = []byte(value)
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+
+	secret.Annotations[rotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	secret.Annotations[rotationServersAnnotation] = strings.Join(servers, ",")
+
+	if err := r.setSecretRotationPhase(ctx, secret, status.SecretRotationRollingServers, now); err != nil {
+		return err
+	}
+
+	r.Log.Info("rotated secret", "gitlab", adapter.Name(), "secret", secret.Name)
+	r.Recorder.Event(adapter.Origin(), "Normal", "SecretRotated",
+		fmt.Sprintf("Secret %s has been rotated, rolling out %s first", secret.Name, strings.Join(servers, ", ")))
+
+	return nil
+}
+
+// setSecretRotationPhase records the phase of the rollout on the Secret. An
+// empty phase removes the rollout annotations.
+func (r *GitLabReconciler) setSecretRotationPhase(ctx context.Context, secret *corev1.Secret, phase string, now time.Time) error {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+
+	if phase == "" {
+		delete(secret.Annotations, rotationPhaseAnnotation)
+		delete(secret.Annotations, rotationPhaseTimeAnnotation)
+		delete(secret.Annotations, rotationServersAnnotation)
+	} else {
+		secret.Annotations[rotationPhaseAnnotation] = phase
+		secret.Annotations[rotationPhaseTimeAnnotation] = now.UTC().Format(time.RFC3339)
+	}
+
+	return r.Update(ctx, secret)
+}
+
+// secretRolledOut returns true when all Deployments and StatefulSets that use
+// the Secret run with its current value. When servers is not empty only the
+// workloads of these components are considered.
+func (r *GitLabReconciler) secretRolledOut(ctx context.Context, adapter gitlab.Adapter, secret *corev1.Secret, servers []string) (bool, error) {
+	options := []client.ListOption{
+		client.InNamespace(adapter.Name().Namespace),
+		client.MatchingLabels{"release": adapter.ReleaseName()},
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, options...); err != nil {
+		return false, err
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, options...); err != nil {
+		return false, err
+	}
+
+	workloads := []client.Object{}
+
+	for i := range deployments.Items {
+		workloads = append(workloads, &deployments.Items[i])
+	}
+
+	for i := range statefulSets.Items {
+		workloads = append(workloads, &statefulSets.Items[i])
+	}
+
+	key, err := secretChecksumKey(secret.Name)
+	if err != nil {
+		return false, err
+	}
+
+	for _, workload := range workloads {
+		if len(servers) > 0 && !slices.Contains(servers, workload.GetLabels()["app"]) {
+			continue
+		}
+
+		template, err := internal.GetPodTemplateSpec(workload)
+		if err != nil {
+			return false, err
+		}
+
+		current, ok := template.Annotations[key]
+		if !ok {
+			// The workload does not use the Secret.
+			continue
+		}
+
+		keys := internal.PopulateAttachedSecrets(*template)[secret.Name]
+
+		if current != internal.SecretChecksum(*secret, keys) || !internal.WorkloadRolledOut(workload) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// stagedSecretChecksum returns the checksum of the Secret that is annotated on
+// the Pod template of the workload. While the servers of a rotated Secret are
+// rolled out, the other workloads keep the checksum of their live object so
+// that they are not restarted yet.
+func (r *GitLabReconciler) stagedSecretChecksum(ctx context.Context, secret *corev1.Secret, obj client.Object, key, checksum string) (string, error) {
+	if secret.Annotations[rotationPhaseAnnotation] != status.SecretRotationRollingServers ||
+		slices.Contains(rotationServers(secret), obj.GetLabels()["app"]) {
+		return checksum, nil
+	}
+
+	live := obj.DeepCopyObject().(client.Object)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		if errors.IsNotFound(err) {
+			return checksum, nil
+		}
+
+		return "", err
+	}
+
+	template, err := internal.GetPodTemplateSpec(live)
+	if err != nil {
+		return "", err
+	}
+
+	if current, ok := template.Annotations[key]; ok {
+		return current, nil
+	}
+
+	return checksum, nil
+}
+
+func rotationServers(secret *corev1.Secret) []string {
+	if value := secret.Annotations[rotationServersAnnotation]; value != "" {
+		return strings.Split(value, ",")
+	}
+
+	return nil
+}
+
+// lastSecretRotation returns the time of the last rotation of the Secret. When
+// the Secret was never rotated its creation time is used.
+func lastSecretRotation(secret *corev1.Secret) time.Time {
+	if value, ok := secret.Annotations[rotatedAtAnnotation]; ok {
+		if rotatedAt, err := time.Parse(time.RFC3339, value); err == nil {
+			return rotatedAt
+		}
+	}
+
+	return secret.CreationTimestamp.Time
+}
+
+// secretRotationPhaseTime returns the time when the current phase of the
+// rollout of the Secret started.
+func secretRotationPhaseTime(secret *corev1.Secret) time.Time {
+	if value, ok := secret.Annotations[rotationPhaseTimeAnnotation]; ok {
+		if phaseTime, err := time.Parse(time.RFC3339, value); err == nil {
+			return phaseTime
+		}
+	}
+
+	return lastSecretRotation(secret)
+}
+
+// earliestDelay returns the earliest of the two delays, ignoring zero.
+func earliestDelay(current, candidate time.Duration) time.Duration {
+	if candidate < time.Second {
+		candidate = time.Second
+	}
+
+	if current == 0 || candidate < current {
+		return candidate
+	}
+
+	return current
+}
@@ -41,6 +41,12 @@ var (
 	// variable to change it.
 	PrometheusServiceAccount = "gitlab-prometheus-server"
 
+	// OperatorNamespace is the namespace of the Operator. The NetworkPolicies
+	// of the instances allow the Operator to connect from it.
+	// The default value is "gitlab-system". Use GITLAB_OPERATOR_NAMESPACE environment
+	// variable to change it.
+	OperatorNamespace = "gitlab-system"
+
 	// HealthProbeBindAddress returns the address for hosting health probes.
 	HealthProbeBindAddress = ":6060"
 
@@ -71,6 +77,7 @@ const (
 	envAppNonRootServiceAccount = "GITLAB_APP_NONROOT_SERVICE_ACCOUNT"
 	envNGINXServiceAccount      = "NGINX_SERVICE_ACCOUNT"
 	envPrometheusServiceAccount = "PROMETHEUS_SERVICE_ACCOUNT"
+	envOperatorNamespace        = "GITLAB_OPERATOR_NAMESPACE"
 	envKubeVersion              = "GITLAB_OPERATOR_KUBERNETES_VERSION"
 	envKubeAPIVersions          = "GITLAB_OPERATOR_KUBERNETES_API_VERSIONS"
 )
@@ -107,6 +114,11 @@ func Load() {
 		PrometheusServiceAccount = prometheusServiceAccount
 	}
 
+	operatorNamespace := os.Getenv(envOperatorNamespace)
+	if operatorNamespace != "" {
+		OperatorNamespace = operatorNamespace
+	}
+
 	kubeVersionStr := os.Getenv(envKubeVersion)
 	if kubeVersionStr != "" {
 		DefaultKubeVersion, _ = chartutil.ParseKubeVersion(kubeVersionStr)
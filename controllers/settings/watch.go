@@ -0,0 +1,117 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	envWatchNamespace         = "WATCH_NAMESPACE"
+	envWatchNamespaceSelector = "WATCH_NAMESPACE_SELECTOR"
+
+	// ClusterScope is the scope of an Operator that watches all namespaces.
+	ClusterScope = "cluster"
+
+	// NamespaceScope is the scope of an Operator that watches one namespace.
+	NamespaceScope = "namespace"
+
+	// MultiNamespaceScope is the scope of an Operator that watches a set of
+	// namespaces.
+	MultiNamespaceScope = "multi-namespace"
+)
+
+// WatchScope describes the namespaces that the Operator watches.
+type WatchScope struct {
+	// Namespaces is the sorted list of watched namespaces. It is empty when
+	// the Operator watches all namespaces.
+	Namespaces []string
+}
+
+// Name returns the name of the scope, for example `multi-namespace`.
+func (s WatchScope) Name() string {
+	switch len(s.Namespaces) {
+	case 0:
+		return ClusterScope
+	case 1:
+		return NamespaceScope
+	default:
+		return MultiNamespaceScope
+	}
+}
+
+// LoadWatchScope reads the watched namespaces from the environment.
+//
+// WATCH_NAMESPACE is a comma-separated list of namespaces.
+// WATCH_NAMESPACE_SELECTOR is a label selector of namespaces that is resolved
+// with the client. When both are set, the Operator watches the namespaces of
+// both. When neither is set, or WATCH_NAMESPACE is empty, the Operator watches
+// all namespaces.
+func LoadWatchScope(ctx context.Context, client kubernetes.Interface) (WatchScope, error) {
+	namespaces := ParseWatchNamespaces(os.Getenv(envWatchNamespace))
+
+	if selector := strings.TrimSpace(os.Getenv(envWatchNamespaceSelector)); selector != "" {
+		selected, err := SelectNamespaces(ctx, client, selector)
+		if err != nil {
+			return WatchScope{}, err
+		}
+
+		namespaces = ParseWatchNamespaces(strings.Join(append(namespaces, selected...), ","))
+	}
+
+	return WatchScope{Namespaces: namespaces}, nil
+}
+
+// ParseWatchNamespaces parses a comma-separated list of namespaces. It
+// removes blanks and duplicates and sorts the namespaces.
+func ParseWatchNamespaces(value string) []string {
+	unique := map[string]struct{}{}
+
+	for _, ns := range strings.Split(value, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			unique[ns] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(unique))
+	for ns := range unique {
+		result = append(result, ns)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// SelectNamespaces returns the names of the namespaces that match the label
+// selector. It fails when no namespace matches, because an empty list of
+// namespaces would widen the scope to the whole cluster.
+func SelectNamespaces(ctx context.Context, client kubernetes.Interface, selector string) ([]string, error) {
+	if _, err := labels.Parse(selector); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", envWatchNamespaceSelector, err)
+	}
+
+	list, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("can not list namespaces that match %q: %w", selector, err)
+	}
+
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("no namespaces match %q", selector)
+	}
+
+	result := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		result = append(result, ns.Name)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
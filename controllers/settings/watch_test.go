@@ -0,0 +1,75 @@
+package settings
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Watch scope", func() {
+	newNamespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+		}
+	}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("gitlab-dev", map[string]string{"gitlab.com/operator": "enabled"}),
+		newNamespace("gitlab-prod", map[string]string{"gitlab.com/operator": "enabled"}),
+		newNamespace("default", nil),
+	)
+
+	It("parses comma-separated namespaces", func() {
+		Expect(ParseWatchNamespaces(" gitlab-b,gitlab-a,, gitlab-b ")).To(Equal([]string{"gitlab-a", "gitlab-b"}))
+		Expect(ParseWatchNamespaces("")).To(BeEmpty())
+	})
+
+	It("names the scope after the number of namespaces", func() {
+		Expect(WatchScope{}.Name()).To(Equal(ClusterScope))
+		Expect(WatchScope{Namespaces: []string{"gitlab"}}.Name()).To(Equal(NamespaceScope))
+		Expect(WatchScope{Namespaces: []string{"gitlab-a", "gitlab-b"}}.Name()).To(Equal(MultiNamespaceScope))
+	})
+
+	It("watches all namespaces when nothing is set", func() {
+		Expect(LoadWatchScope(context.Background(), client)).To(Equal(WatchScope{Namespaces: []string{}}))
+	})
+
+	It("watches the listed namespaces", func() {
+		GinkgoT().Setenv(envWatchNamespace, "gitlab-prod,gitlab-dev")
+
+		Expect(LoadWatchScope(context.Background(), client)).To(Equal(WatchScope{
+			Namespaces: []string{"gitlab-dev", "gitlab-prod"},
+		}))
+	})
+
+	It("watches the namespaces that match the selector", func() {
+		GinkgoT().Setenv(envWatchNamespace, "default")
+		GinkgoT().Setenv(envWatchNamespaceSelector, "gitlab.com/operator=enabled")
+
+		Expect(LoadWatchScope(context.Background(), client)).To(Equal(WatchScope{
+			Namespaces: []string{"default", "gitlab-dev", "gitlab-prod"},
+		}))
+	})
+
+	It("fails when no namespace matches the selector", func() {
+		GinkgoT().Setenv(envWatchNamespaceSelector, "gitlab.com/operator=disabled")
+
+		_, err := LoadWatchScope(context.Background(), client)
+		Expect(err).To(MatchError(ContainSubstring("no namespaces match")))
+	})
+
+	It("fails when the selector is invalid", func() {
+		GinkgoT().Setenv(envWatchNamespaceSelector, "gitlab.com/operator in enabled")
+
+		_, err := LoadWatchScope(context.Background(), client)
+		Expect(err).To(MatchError(ContainSubstring("invalid WATCH_NAMESPACE_SELECTOR")))
+	})
+})
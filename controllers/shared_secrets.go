@@ -2,6 +2,12 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
@@ -20,11 +26,15 @@ func (r *GitLabReconciler) runSharedSecretsJob(ctx context.Context, adapter gitl
 		return true, nil
 	}
 
+	if adapter.GeneratesSecretsNatively() {
+		return true, r.generateSharedSecrets(ctx, adapter)
+	}
+
 	if err := r.createOrPatch(ctx, cfgMap, adapter); err != nil {
 		return false, err
 	}
 
-	if err := r.createOrPatch(ctx, job, adapter); err != nil {
+	if err := r.createOrRecreate(ctx, job, adapter); err != nil {
 		return false, err
 	}
 
@@ -43,9 +53,83 @@ func (r *GitLabReconciler) runSelfSignedCertsJob(ctx context.Context, adapter gi
 		return true, nil
 	}
 
-	if err := r.createOrPatch(ctx, job, adapter); err != nil {
+	if adapter.GeneratesSecretsNatively() {
+		return true, r.generateSelfSignedCertificates(ctx, adapter)
+	}
+
+	if err := r.createOrRecreate(ctx, job, adapter); err != nil {
 		return false, err
 	}
 
 	return r.jobFinished(ctx, adapter, job)
 }
+
+// generateSharedSecrets creates the Secrets of the shared secrets Job that do
+// not exist yet.
+func (r *GitLabReconciler) generateSharedSecrets(ctx context.Context, adapter gitlab.Adapter) error {
+	sharedSecrets, err := gitlabctl.SharedSecrets(adapter)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sharedSecrets {
+		sharedSecret := s
+
+		err := r.createSecretIfMissing(ctx, adapter, sharedSecret.Name, func() ([]*corev1.Secret, error) {
+			data, err := sharedSecret.Generate()
+			if err != nil {
+				return nil, err
+			}
+
+			return []*corev1.Secret{gitlabctl.NewSharedSecret(adapter, sharedSecret.Name, data)}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateSelfSignedCertificates creates the Secrets of the self-signed
+// certificates Job unless the wildcard certificate already exists.
+func (r *GitLabReconciler) generateSelfSignedCertificates(ctx context.Context, adapter gitlab.Adapter) error {
+	return r.createSecretIfMissing(ctx, adapter, gitlabctl.SelfSignedCertificateSecretName(adapter), func() ([]*corev1.Secret, error) {
+		return gitlabctl.SelfSignedCertificates(adapter)
+	})
+}
+
+// createSecretIfMissing creates the generated Secrets when the named Secret
+// does not exist. Existing Secrets are never overwritten, the same way that
+// the shared secrets Job does not overwrite them. The created Secrets are
+// owned by the GitLab resource.
+func (r *GitLabReconciler) createSecretIfMissing(ctx context.Context, adapter gitlab.Adapter, name string, generate func() ([]*corev1.Secret, error)) error {
+	lookupKey := types.NamespacedName{Name: name, Namespace: adapter.Name().Namespace}
+
+	if err := r.Get(ctx, lookupKey, &corev1.Secret{}); err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	secrets, err := generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate secret %s: %w", name, err)
+	}
+
+	for _, secret := range secrets {
+		if err := controllerutil.SetControllerReference(adapter.Origin(), secret, r.Scheme); err != nil {
+			return err
+		}
+
+		if err := r.Create(ctx, secret); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+
+			return err
+		}
+
+		r.Log.Info("generated secret", "gitlab", adapter.Name(), "secret", secret.Name)
+	}
+
+	return nil
+}
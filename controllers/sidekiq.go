@@ -20,10 +20,17 @@ func (r *GitLabReconciler) reconcileSidekiqConfigMaps(ctx context.Context, adapt
 }
 
 func (r *GitLabReconciler) reconcileSidekiqDeployments(ctx context.Context, adapter gitlab.Adapter, template helm.Template, pause bool) error {
-	sidekiqs := gitlabctl.SidekiqDeployments(template)
-
-	for _, sidekiq := range sidekiqs {
-		if err := r.setDeploymentReplica(ctx, sidekiq); err != nil {
+	for _, sidekiq := range gitlabctl.SidekiqDeployments(template) {
+		if adapter.SidekiqQueueScalingEnabled() {
+			deployment, err := internal.AsDeployment(sidekiq)
+			if err != nil {
+				return err
+			}
+
+			if err := r.keepSidekiqReplicas(ctx, adapter, deployment); err != nil {
+				return err
+			}
+		} else if err := r.setDeploymentReplica(ctx, sidekiq); err != nil {
 			return err
 		}
 
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support/redis"
+)
+
+const (
+	sidekiqScalingInterval = 30 * time.Second
+)
+
+// reconcileSidekiqScaling scales the existing Sidekiq Deployments from the
+// backlog of their queues and records the outcome in the status. It only
+// changes the replicas of the Deployments, so it runs before the steps that
+// hold back the reconciliation of the components, for example an upgrade of
+// PostgreSQL, and Sidekiq keeps scaling while they wait. The status is only
+// updated when the recorded scaling changes.
+func (r *GitLabReconciler) reconcileSidekiqScaling(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
+	sidekiqs := gitlabctl.SidekiqDeployments(template)
+
+	if !adapter.SidekiqQueueScalingEnabled() || len(sidekiqs) == 0 {
+		return nil
+	}
+
+	var (
+		queues   *redis.Client
+		queueErr error
+		changed  bool
+	)
+
+	defer func() {
+		if queues != nil {
+			queues.Close()
+		}
+	}()
+
+	for _, sidekiq := range sidekiqs {
+		deployment, err := internal.AsDeployment(sidekiq)
+		if err != nil {
+			return err
+		}
+
+		existing := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, existing); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+
+			return err
+		}
+
+		// Connect once the first Deployment exists, Redis may not be
+		// running before.
+		if queues == nil && queueErr == nil {
+			queues, queueErr = r.openSidekiqQueues(ctx, adapter, template)
+		}
+
+		previous := adapter.SidekiqScaling(deployment.Name)
+
+		if err := r.scaleSidekiqDeployment(ctx, adapter, deployment, queues, queueErr); err != nil {
+			return err
+		}
+
+		if sidekiqScalingChanged(previous, adapter.SidekiqScaling(deployment.Name)) {
+			changed = true
+		}
+
+		if existing.Spec.Replicas != nil && *existing.Spec.Replicas == *deployment.Spec.Replicas {
+			continue
+		}
+
+		patch := client.MergeFrom(existing.DeepCopy())
+		existing.Spec.Replicas = deployment.Spec.Replicas
+
+		if err := r.Patch(ctx, existing, patch); err != nil {
+			return err
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return r.Status().Update(ctx, adapter.Origin())
+}
+
+// sidekiqScalingChanged returns true when the recorded scaling of a Sidekiq
+// Deployment differs from the previous record.
+func sidekiqScalingChanged(previous, current *gitlab.SidekiqScaling) bool {
+	if previous == nil || current == nil {
+		return previous != current
+	}
+
+	return previous.Replicas != current.Replicas ||
+		previous.QueueDepth != current.QueueDepth ||
+		previous.QueueLatency != current.QueueLatency ||
+		previous.Message != current.Message ||
+		!previous.LastScaleTime.Equal(current.LastScaleTime)
+}
+
+// keepSidekiqReplicas sets the replicas of the Sidekiq Deployment to the
+// replicas of the existing Deployment, which reconcileSidekiqScaling scales,
+// within the limits of the scaling.
+func (r *GitLabReconciler) keepSidekiqReplicas(ctx context.Context, adapter gitlab.Adapter, deployment *appsv1.Deployment) error {
+	scaling := adapter.SidekiqQueueScaling(deployment.Labels[internal.SidekiqPodNameLabel])
+
+	current, err := r.currentDeploymentReplicas(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	replicas := sidekiqReplicasWithinLimits(scaling, current)
+	deployment.Spec.Replicas = &replicas
+
+	return nil
+}
+
+// openSidekiqQueues connects to the Redis instance that holds the Sidekiq
+// queues. The caller must close the client.
+func (r *GitLabReconciler) openSidekiqQueues(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (*redis.Client, error) {
+	connection := gitlabctl.SidekiqRedisConnection(adapter, template)
+
+	endpoint, err := r.externalRedisEndpoint(ctx, adapter, connection)
+	if err != nil {
+		return nil, err
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, databaseProbeTimeout)
+	defer cancel()
+
+	client, _, err := internal.DialRedis(dialCtx, endpoint)
+
+	return client, err
+}
+
+// scaleSidekiqDeployment sets the replicas of the Sidekiq Deployment from the
+// backlog of its queues and records the outcome in the status. When the
+// queues can not be read, the current replicas are kept within the limits.
+func (r *GitLabReconciler) scaleSidekiqDeployment(ctx context.Context, adapter gitlab.Adapter, deployment *appsv1.Deployment, store internal.SidekiqQueueStore, storeErr error) error {
+	scaling := adapter.SidekiqQueueScaling(deployment.Labels[internal.SidekiqPodNameLabel])
+	now := time.Now()
+
+	current, err := r.currentDeploymentReplicas(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	record := gitlab.SidekiqScaling{
+		Deployment: deployment.Name,
+	}
+
+	if previous := adapter.SidekiqScaling(deployment.Name); previous != nil {
+		record.LastScaleTime = previous.LastScaleTime
+	}
+
+	stats := internal.SidekiqQueueStats{}
+
+	queues, err := internal.SidekiqQueues(deployment)
+	if err == nil {
+		err = storeErr
+	}
+
+	if err == nil {
+		stats, err = internal.ReadSidekiqQueues(store, queues, now)
+	}
+
+	replicas := current
+
+	if err != nil {
+		record.Message = err.Error()
+		replicas = sidekiqReplicasWithinLimits(scaling, replicas)
+	} else {
+		replicas = internal.SidekiqReplicas(scaling, current, stats, record.LastScaleTime, now)
+	}
+
+	if replicas != current {
+		record.LastScaleTime = now
+
+		r.Recorder.Event(adapter.Origin(), "Normal", "SidekiqScaled",
+			fmt.Sprintf("Scaled Sidekiq Deployment %s from %d to %d replicas (queue depth %d, latency %s)",
+				deployment.Name, current, replicas, stats.Depth, stats.Latency.Round(time.Second)))
+	}
+
+	record.Replicas = replicas
+	record.QueueDepth = stats.Depth
+	record.QueueLatency = stats.Latency
+
+	adapter.RecordSidekiqScaling(record)
+
+	deployment.Spec.Replicas = &replicas
+
+	return nil
+}
+
+// currentDeploymentReplicas returns the replicas of the existing Deployment,
+// or the replicas of the template when the Deployment does not exist yet.
+func (r *GitLabReconciler) currentDeploymentReplicas(ctx context.Context, deployment *appsv1.Deployment) (int32, error) {
+	existing := &appsv1.Deployment{}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return 0, err
+		}
+
+		existing = deployment
+	}
+
+	if existing.Spec.Replicas == nil {
+		return 1, nil
+	}
+
+	return *existing.Spec.Replicas, nil
+}
+
+// sidekiqReplicasWithinLimits returns the replicas within the minimum and the
+// maximum replicas of the scaling.
+func sidekiqReplicasWithinLimits(scaling gitlab.SidekiqQueueScaling, replicas int32) int32 {
+	if replicas < scaling.MinReplicas {
+		return scaling.MinReplicas
+	}
+
+	if replicas > scaling.MaxReplicas {
+		return scaling.MaxReplicas
+	}
+
+	return replicas
+}
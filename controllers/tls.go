@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/helm"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+const (
+	// certificateCheckInterval is the delay between checking the TLS
+	// certificates of the Ingresses. The TLS Secrets are not watched, so the
+	// renewed certificates are only picked up by the checks.
+	certificateCheckInterval = 6 * time.Hour
+
+	// certificatePendingRetryDelay is the delay between checking the TLS
+	// certificates when a TLS Secret does not exist yet, for example when
+	// cert-manager has not issued the certificate.
+	certificatePendingRetryDelay = 5 * time.Minute
+)
+
+// reconcileCertificateStatus checks the TLS certificates of the Ingresses and
+// records them in the status. It reports the certificates that expire within
+// the configured warning with the CertificateExpiring condition, and the
+// certificates that do not cover the hosts of their Ingresses with events.
+// The events are only recorded when the outcome of a check changes.
+//
+// It returns the delay until the next check, or zero when the Ingresses do
+// not use TLS Secrets.
+func (r *GitLabReconciler) reconcileCertificateStatus(ctx context.Context, adapter gitlab.Adapter, template helm.Template) (time.Duration, error) {
+	endpoints := gitlabctl.IngressTLSEndpoints(template)
+	warning := adapter.CertificateExpiryWarning()
+	now := time.Now()
+
+	certificates := make([]gitlab.TLSCertificate, 0, len(endpoints))
+	expiring := []string{}
+	nextCheck := time.Duration(0)
+
+	if len(endpoints) > 0 {
+		nextCheck = certificateCheckInterval
+	}
+
+	for _, endpoint := range endpoints {
+		certificate := gitlab.TLSCertificate{
+			Ingress:   endpoint.Ingress,
+			Secret:    endpoint.Secret,
+			Hosts:     endpoint.Hosts,
+			CheckedAt: now,
+		}
+
+		previous := adapter.TLSCertificate(endpoint.Ingress, endpoint.Secret)
+
+		// changed returns true when the previous check did not report the
+		// problem.
+		changed := func(problem string) bool {
+			return previous == nil || !strings.Contains(previous.Message, problem)
+		}
+
+		info, err := r.readTLSCertificate(ctx, adapter, endpoint.Secret)
+
+		switch {
+		case err != nil && errors.IsNotFound(err):
+			certificate.Message = fmt.Sprintf("Secret %s not found, the certificate may not be issued yet", endpoint.Secret)
+			nextCheck = earliestDelay(nextCheck, certificatePendingRetryDelay)
+		case err != nil:
+			certificate.Message = err.Error()
+
+			if changed(certificate.Message) {
+				r.Recorder.Event(adapter.Origin(), "Warning", "CertificateInvalid",
+					fmt.Sprintf("TLS certificate of Ingress %s in Secret %s is invalid: %v", endpoint.Ingress, endpoint.Secret, err))
+			}
+		default:
+			certificate.DNSNames = info.DNSNames
+			certificate.Issuer = info.Issuer
+			certificate.NotAfter = info.NotAfter
+
+			problems := []string{}
+
+			if info.ExpiresWithin(now, warning) {
+				problem := fmt.Sprintf("expires on %s", info.NotAfter.UTC().Format(time.RFC3339))
+				problems = append(problems, problem)
+				expiring = append(expiring, fmt.Sprintf("%s (%s)", endpoint.Secret, info.NotAfter.UTC().Format(time.RFC3339)))
+
+				if changed(problem) {
+					r.Recorder.Event(adapter.Origin(), "Warning", "CertificateExpiring",
+						fmt.Sprintf("TLS certificate of Ingress %s in Secret %s %s", endpoint.Ingress, endpoint.Secret, problem))
+				}
+			} else {
+				nextCheck = earliestDelay(nextCheck, info.NotAfter.Add(-warning).Sub(now))
+			}
+
+			if uncovered := info.UncoveredHosts(endpoint.Hosts); len(uncovered) > 0 {
+				problem := fmt.Sprintf("does not cover %s", strings.Join(uncovered, ", "))
+				problems = append(problems, problem)
+
+				if changed(problem) {
+					r.Recorder.Event(adapter.Origin(), "Warning", "CertificateHostsNotCovered",
+						fmt.Sprintf("TLS certificate of Ingress %s in Secret %s %s", endpoint.Ingress, endpoint.Secret, problem))
+				}
+			}
+
+			certificate.Message = strings.Join(problems, "; ")
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	adapter.RecordTLSCertificates(certificates)
+
+	if len(expiring) > 0 {
+		return nextCheck, r.setStatusCondition(ctx, adapter, status.ConditionCertificateExpiring, true,
+			fmt.Sprintf("TLS certificates expire within %d days: %s", int(warning.Hours()/24), strings.Join(expiring, ", ")))
+	}
+
+	return nextCheck, r.setStatusCondition(ctx, adapter, status.ConditionCertificateExpiring, false,
+		fmt.Sprintf("No TLS certificate expires within %d days", int(warning.Hours()/24)))
+}
+
+// readTLSCertificate parses the certificate of the TLS Secret. It returns a
+// NotFound error when the Secret does not exist.
+func (r *GitLabReconciler) readTLSCertificate(ctx context.Context, adapter gitlab.Adapter, secretName string) (*internal.TLSCertificateInfo, error) {
+	secret := &corev1.Secret{}
+	lookupKey := types.NamespacedName{Name: secretName, Namespace: adapter.Name().Namespace}
+
+	if err := r.Get(ctx, lookupKey, secret); err != nil {
+		return nil, err
+	}
+
+	data, ok := secret.Data[corev1.TLSCertKey]
+	if !ok {
+		return nil, fmt.Errorf("key '%s' not found in Secret '%s'", corev1.TLSCertKey, lookupKey)
+	}
+
+	return internal.ParseTLSCertificate(data)
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gitlabctl "gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+// injectCABundle mounts the bundle of the custom certificate authorities into
+// the Pod template of the object, if it has one, and annotates it with the
+// checksum of the bundle. It fails when the bundle does not exist.
+//
+// A Job that already exists keeps the bundle that it was created with, so
+// that adding or changing the bundle does not fail on its immutable Pod
+// template.
+func (r *GitLabReconciler) injectCABundle(ctx context.Context, adapter gitlab.Adapter, obj client.Object) error {
+	if job, ok := obj.(*batchv1.Job); ok {
+		existing := &batchv1.Job{}
+
+		found, err := r.lookup(ctx, client.ObjectKeyFromObject(job), existing)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			internal.KeepJobCABundle(job, existing)
+
+			return nil
+		}
+	}
+
+	bundle := adapter.CABundle()
+	if bundle == nil || !internal.HasPodTemplate(obj) {
+		return nil
+	}
+
+	content, err := r.caBundleContent(ctx, adapter, bundle)
+	if err != nil {
+		return err
+	}
+
+	internal.InjectCABundle(obj, bundle, internal.CABundleChecksum(content))
+
+	return nil
+}
+
+// customCertificatePool returns the certificate authorities that the GitLab
+// components trust: the ones of the system and the custom ones of the chart,
+// which include the bundle of the custom certificate authorities. It returns
+// nil when there are no custom certificate authorities, so that the system
+// defaults are used.
+func (r *GitLabReconciler) customCertificatePool(ctx context.Context, adapter gitlab.Adapter) (*x509.CertPool, error) {
+	sources := gitlabctl.CustomCertificateAuthorities(adapter)
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	bundles := map[string][]byte{}
+
+	for _, source := range sources {
+		lookupKey := types.NamespacedName{Name: source.Name, Namespace: adapter.Name().Namespace}
+		data := map[string][]byte{}
+
+		if source.Kind == gitlabctl.SecretKind {
+			secret := &corev1.Secret{}
+			if err := r.Get(ctx, lookupKey, secret); err != nil {
+				return nil, fmt.Errorf("can not read the custom CAs from Secret '%s': %w", lookupKey, err)
+			}
+
+			data = secret.Data
+		} else {
+			configMap := &corev1.ConfigMap{}
+			if err := r.Get(ctx, lookupKey, configMap); err != nil {
+				return nil, fmt.Errorf("can not read the custom CAs from ConfigMap '%s': %w", lookupKey, err)
+			}
+
+			for key, value := range configMap.Data {
+				data[key] = []byte(value)
+			}
+
+			for key, value := range configMap.BinaryData {
+				data[key] = value
+			}
+		}
+
+		keys := source.Keys
+		if len(keys) == 0 {
+			for key := range data {
+				keys = append(keys, key)
+			}
+		}
+
+		for _, key := range keys {
+			value, ok := data[key]
+			if !ok {
+				return nil, fmt.Errorf("key '%s' not found in %s '%s'", key, source.Kind, lookupKey)
+			}
+
+			bundles[fmt.Sprintf("key '%s' of %s '%s'", key, source.Kind, lookupKey)] = value
+		}
+	}
+
+	return internal.CertificatePool(bundles)
+}
+
+// caBundleContent reads the bundle from its ConfigMap or Secret. The
+// ConfigMap of a trust-manager Bundle has the name of the Bundle.
+func (r *GitLabReconciler) caBundleContent(ctx context.Context, adapter gitlab.Adapter, bundle *gitlab.CABundleReference) ([]byte, error) {
+	lookupKey := types.NamespacedName{Name: bundle.Name, Namespace: adapter.Name().Namespace}
+
+	if bundle.InSecret() {
+		return r.secretValue(ctx, adapter, bundle.Name, bundle.Key)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, lookupKey, configMap); err != nil {
+		return nil, fmt.Errorf("can not read the CA bundle from ConfigMap '%s': %w", lookupKey, err)
+	}
+
+	if content, ok := configMap.Data[bundle.Key]; ok {
+		return []byte(content), nil
+	}
+
+	if content, ok := configMap.BinaryData[bundle.Key]; ok {
+		return content, nil
+	}
+
+	return nil, fmt.Errorf("key '%s' not found in ConfigMap '%s'", bundle.Key, lookupKey)
+}
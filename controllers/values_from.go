@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apiv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+// gitLabsOfValuesSource maps a ConfigMap or a Secret to the GitLab resources
+// that read their values or their bundle of custom certificate authorities
+// from it, so that the chart is rendered again when the content changes.
+func (r *GitLabReconciler) gitLabsOfValuesSource(ctx context.Context, obj client.Object) []reconcile.Request {
+	kind := ""
+
+	switch obj.(type) {
+	case *corev1.ConfigMap:
+		kind = "ConfigMap"
+	case *corev1.Secret:
+		kind = "Secret"
+	default:
+		return nil
+	}
+
+	gitlabs := &apiv1beta1.GitLabList{}
+	if err := r.List(ctx, gitlabs, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list the GitLab resources of the values",
+			"kind", kind, "name", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for i := range gitlabs.Items {
+		if readsFrom(&gitlabs.Items[i], kind, obj.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&gitlabs.Items[i]),
+			})
+		}
+	}
+
+	return requests
+}
+
+// readsFrom returns true when the GitLab resource reads its values or its
+// bundle of custom certificate authorities from the named ConfigMap or
+// Secret. trust-manager writes a Bundle to the ConfigMap with its name.
+func readsFrom(resource *apiv1beta1.GitLab, kind, name string) bool {
+	for _, reference := range resource.Spec.Chart.ValuesFrom {
+		if reference.Kind == kind && reference.Name == name {
+			return true
+		}
+	}
+
+	if resource.Spec.Trust == nil || resource.Spec.Trust.CABundleRef == nil {
+		return false
+	}
+
+	bundle := resource.Spec.Trust.CABundleRef
+	if bundle.Kind == gitlab.CABundleKindBundle {
+		return kind == gitlab.CABundleKindConfigMap && bundle.Name == name
+	}
+
+	return bundle.Kind == kind && bundle.Name == name
+}
+
+// valuesSourceChanged passes the updates of the ConfigMaps and Secrets that
+// hold the values or the bundle of custom certificate authorities of a GitLab
+// resource. Their generation does not change when their content changes, so
+// they do not pass the generation predicate.
+func (r *GitLabReconciler) valuesSourceChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil ||
+				e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion() {
+				return false
+			}
+
+			return len(r.gitLabsOfValuesSource(context.Background(), e.ObjectNew)) > 0
+		},
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/internal"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+const (
+	// volumeExpansionRetryDelay is the delay between checking the progress
+	// of volume expansions.
+	volumeExpansionRetryDelay = 30 * time.Second
+)
+
+// expandStatefulSetVolumes expands the PersistentVolumeClaims of the existing
+// StatefulSet when the desired StatefulSet requests more storage in its volume
+// claim templates.
+//
+// Volume claim templates are immutable. Once the claims are expanded the
+// StatefulSet is deleted without its Pods, the equivalent of
+// `kubectl delete --cascade=orphan`, so that it can be created again with the
+// new templates and adopt the running Pods.
+//
+// When the volumes can not be expanded, the desired StatefulSet keeps the
+// current sizes so that it can still be patched.
+func (r *GitLabReconciler) expandStatefulSetVolumes(ctx context.Context, adapter gitlab.Adapter, obj client.Object) error {
+	if obj == nil {
+		return nil
+	}
+
+	desired, err := internal.AsStatefulSet(obj)
+	if err != nil {
+		return err
+	}
+
+	current := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), current); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	replicas := 1
+	if current.Spec.Replicas != nil {
+		replicas = int(*current.Spec.Replicas)
+	}
+
+	resized := false
+
+	for _, resize := range internal.VolumeClaimResizes(current, desired) {
+		if !resize.IsExpansion() {
+			r.Recorder.Event(adapter.Origin(), "Warning", "VolumeShrinkNotSupported",
+				fmt.Sprintf("Volumes of %s can not be shrunk from %s to %s",
+					current.Name, resize.Current.String(), resize.Desired.String()))
+
+			internal.SetVolumeClaimTemplateSize(desired, resize.Template, resize.Current)
+
+			continue
+		}
+
+		expanded := true
+
+		for ordinal := 0; ordinal < replicas; ordinal++ {
+			pvc := &corev1.PersistentVolumeClaim{}
+			lookupKey := types.NamespacedName{
+				Name:      internal.VolumeClaimName(resize.Template, current.Name, ordinal),
+				Namespace: current.Namespace,
+			}
+
+			if err := r.Get(ctx, lookupKey, pvc); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+
+				return err
+			}
+
+			ok, err := r.expandVolumeClaim(ctx, adapter, current.Name, pvc, resize.Desired)
+			if err != nil {
+				return err
+			}
+
+			expanded = expanded && ok
+		}
+
+		if !expanded {
+			internal.SetVolumeClaimTemplateSize(desired, resize.Template, resize.Current)
+			continue
+		}
+
+		resized = true
+	}
+
+	if err := r.reportStatefulSetVolumeExpansions(ctx, adapter, desired, replicas); err != nil {
+		return err
+	}
+
+	if !resized {
+		return nil
+	}
+
+	return r.orphanStatefulSet(ctx, adapter, current)
+}
+
+// expandVolumeClaimOf expands the existing PersistentVolumeClaim when the
+// desired claim requests more storage. This is used for the claims that are
+// not managed by StatefulSets, such as the MinIO claim.
+//
+// When the claim can not be expanded, the desired claim keeps the current
+// size so that it can still be patched.
+func (r *GitLabReconciler) expandVolumeClaimOf(ctx context.Context, adapter gitlab.Adapter, workload string, obj client.Object) error {
+	if obj == nil {
+		return nil
+	}
+
+	desired, err := internal.AsPersistentVolumeClaim(obj)
+	if err != nil {
+		return err
+	}
+
+	current := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), current); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	currentSize := current.Spec.Resources.Requests[corev1.ResourceStorage]
+	desiredSize := desired.Spec.Resources.Requests[corev1.ResourceStorage]
+
+	if desiredSize.Cmp(currentSize) < 0 {
+		r.Recorder.Event(adapter.Origin(), "Warning", "VolumeShrinkNotSupported",
+			fmt.Sprintf("Volume %s can not be shrunk from %s to %s",
+				current.Name, currentSize.String(), desiredSize.String()))
+	}
+
+	if desiredSize.Cmp(currentSize) > 0 {
+		expanded, err := r.expandVolumeClaim(ctx, adapter, workload, current, desiredSize)
+		if err != nil {
+			return err
+		}
+
+		if expanded {
+			return nil
+		}
+	}
+
+	if desiredSize.Cmp(currentSize) != 0 {
+		if desired.Spec.Resources.Requests == nil {
+			desired.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+
+		desired.Spec.Resources.Requests[corev1.ResourceStorage] = currentSize
+	}
+
+	r.reportVolumeExpansion(adapter, workload, current, currentSize)
+
+	return nil
+}
+
+// expandVolumeClaim requests the desired size for the PersistentVolumeClaim
+// when its StorageClass allows volume expansion. It returns false when the
+// claim can not be expanded.
+func (r *GitLabReconciler) expandVolumeClaim(ctx context.Context, adapter gitlab.Adapter, workload string, pvc *corev1.PersistentVolumeClaim, size resource.Quantity) (bool, error) {
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if requested.Cmp(size) >= 0 {
+		return true, nil
+	}
+
+	expandable, reason, err := r.allowsVolumeExpansion(ctx, adapter, pvc)
+	if err != nil {
+		return false, err
+	}
+
+	if !expandable {
+		r.unsupportedVolumeExpansion(adapter, workload, pvc, size, reason)
+		return false, nil
+	}
+
+	patch := client.MergeFrom(pvc.DeepCopy())
+
+	if pvc.Spec.Resources.Requests == nil {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+
+	if err := r.Patch(ctx, pvc, patch); err != nil {
+		if errors.IsForbidden(err) || errors.IsInvalid(err) {
+			r.unsupportedVolumeExpansion(adapter, workload, pvc, size, err.Error())
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	r.Log.Info("requested volume expansion",
+		"gitlab", adapter.Name(), "claim", pvc.Name, "from", requested.String(), "to", size.String())
+
+	r.Recorder.Event(adapter.Origin(), "Normal", "VolumeExpansionStarted",
+		fmt.Sprintf("Expanding volume %s of %s from %s to %s",
+			pvc.Name, workload, requested.String(), size.String()))
+
+	r.reportVolumeExpansion(adapter, workload, pvc, size)
+
+	return true, nil
+}
+
+// allowsVolumeExpansion checks whether the StorageClass of the
+// PersistentVolumeClaim allows volume expansion. When the StorageClass can not
+// be read, for example because the Operator lacks the permission, it is not
+// known whether the volume can be expanded, so it is not expanded. This is
+// reported in the VolumeExpansionPermitted condition.
+func (r *GitLabReconciler) allowsVolumeExpansion(ctx context.Context, adapter gitlab.Adapter, pvc *corev1.PersistentVolumeClaim) (bool, string, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, "the volume does not have a StorageClass", nil
+	}
+
+	storageClass := &storagev1.StorageClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, storageClass); err != nil {
+		if errors.IsForbidden(err) {
+			reason := fmt.Sprintf("StorageClass %s can not be read: %v", *pvc.Spec.StorageClassName, err)
+
+			return false, reason, r.setStatusCondition(ctx, adapter, status.ConditionVolumeExpansionPermitted, false,
+				fmt.Sprintf("Volumes are not expanded because %s", reason))
+		}
+
+		if errors.IsNotFound(err) {
+			return false, fmt.Sprintf("StorageClass %s does not exist", *pvc.Spec.StorageClassName), nil
+		}
+
+		return false, "", err
+	}
+
+	if err := r.setStatusCondition(ctx, adapter, status.ConditionVolumeExpansionPermitted, true,
+		"The StorageClasses of the volumes can be read"); err != nil {
+		return false, "", err
+	}
+
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		return false, fmt.Sprintf("StorageClass %s does not allow volume expansion", storageClass.Name), nil
+	}
+
+	return true, "", nil
+}
+
+func (r *GitLabReconciler) unsupportedVolumeExpansion(adapter gitlab.Adapter, workload string, pvc *corev1.PersistentVolumeClaim, size resource.Quantity, reason string) {
+	message := fmt.Sprintf("Volume %s of %s can not be expanded to %s: %s",
+		pvc.Name, workload, size.String(), reason)
+
+	r.Recorder.Event(adapter.Origin(), "Warning", "VolumeExpansionNotSupported", message)
+
+	adapter.RecordVolumeExpansion(gitlab.VolumeExpansion{
+		Workload: workload,
+		Claim:    pvc.Name,
+		Size:     size.String(),
+		Phase:    status.VolumeExpansionUnsupported,
+		Message:  message,
+	})
+}
+
+// reportStatefulSetVolumeExpansions records the progress of expanding the
+// PersistentVolumeClaims of the StatefulSet.
+func (r *GitLabReconciler) reportStatefulSetVolumeExpansions(ctx context.Context, adapter gitlab.Adapter, statefulSet *appsv1.StatefulSet, replicas int) error {
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		size := template.Spec.Resources.Requests[corev1.ResourceStorage]
+
+		for ordinal := 0; ordinal < replicas; ordinal++ {
+			pvc := &corev1.PersistentVolumeClaim{}
+			lookupKey := types.NamespacedName{
+				Name:      internal.VolumeClaimName(template.Name, statefulSet.Name, ordinal),
+				Namespace: statefulSet.Namespace,
+			}
+
+			if err := r.Get(ctx, lookupKey, pvc); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+
+				return err
+			}
+
+			r.reportVolumeExpansion(adapter, statefulSet.Name, pvc, size)
+		}
+	}
+
+	return nil
+}
+
+func (r *GitLabReconciler) reportVolumeExpansion(adapter gitlab.Adapter, workload string, pvc *corev1.PersistentVolumeClaim, size resource.Quantity) {
+	phase, message := internal.VolumeExpansionPhase(pvc, size)
+
+	adapter.RecordVolumeExpansion(gitlab.VolumeExpansion{
+		Workload: workload,
+		Claim:    pvc.Name,
+		Size:     size.String(),
+		Phase:    phase,
+		Message:  message,
+	})
+}
+
+// orphanStatefulSet deletes the StatefulSet but leaves its Pods and
+// PersistentVolumeClaims in place. It does not wait until the StatefulSet is
+// removed. The StatefulSet is created again when the deletion triggers the
+// next reconcile.
+func (r *GitLabReconciler) orphanStatefulSet(ctx context.Context, adapter gitlab.Adapter, statefulSet *appsv1.StatefulSet) error {
+	propagation := metav1.DeletePropagationOrphan
+
+	if err := r.Delete(ctx, statefulSet, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	r.Log.Info("deleted StatefulSet to update its volume claim templates",
+		"gitlab", adapter.Name(), "statefulset", statefulSet.Name)
+
+	r.Recorder.Event(adapter.Origin(), "Normal", "StatefulSetRecreated",
+		fmt.Sprintf("Recreating StatefulSet %s with the expanded volume claim templates", statefulSet.Name))
+
+	return nil
+}
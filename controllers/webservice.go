@@ -77,7 +77,7 @@ func (r *GitLabReconciler) reconcileWebserviceDeployments(ctx context.Context, a
 
 func (r *GitLabReconciler) reconcileWebserviceIngresses(ctx context.Context, adapter gitlab.Adapter, template helm.Template) error {
 	for _, ingress := range gitlabctl.WebserviceIngresses(template) {
-		if err := r.reconcileIngress(ctx, ingress, adapter); err != nil {
+		if err := r.reconcileIngress(ctx, ingress, adapter, template); err != nil {
 			return err
 		}
 	}
@@ -135,3 +135,48 @@ Create the name of the app service account to use
 {{- define "webhook.service.name" -}}
 {{- printf "%s-%s" (include "name" .) "webhook-service" }}
 {{- end }}
+
+{{/*
+Returns "true" when the manager watches a set of namespaces that is
+configured with watchNamespaces or watchNamespaceSelector.
+*/}}
+{{- define "manager.watchesNamespaces" -}}
+{{- if and (not .Values.watchCluster) (or .Values.watchNamespaces .Values.watchNamespaceSelector) -}}
+true
+{{- end -}}
+{{- end }}
+
+{{/*
+Label selector of the watched namespaces in the format of WATCH_NAMESPACE_SELECTOR.
+*/}}
+{{- define "manager.watchNamespaceSelector" -}}
+{{- $selector := list }}
+{{- range $key, $value := .Values.watchNamespaceSelector }}
+{{- $selector = append $selector (printf "%s=%s" $key (toString $value)) }}
+{{- end }}
+{{- join "," $selector }}
+{{- end }}
+
+{{/*
+Comma-separated list of the watched namespaces. The namespaces that match
+watchNamespaceSelector are looked up when the chart is installed or upgraded.
+*/}}
+{{- define "manager.watchedNamespaces" -}}
+{{- $namespaces := .Values.watchNamespaces | default list }}
+{{- if .Values.watchNamespaceSelector }}
+{{-   $selector := .Values.watchNamespaceSelector }}
+{{-   range (lookup "v1" "Namespace" "" "").items | default list }}
+{{-     $labels := .metadata.labels | default dict }}
+{{-     $match := true }}
+{{-     range $key, $value := $selector }}
+{{-       if ne (get $labels $key) (toString $value) }}
+{{-         $match = false }}
+{{-       end }}
+{{-     end }}
+{{-     if $match }}
+{{-       $namespaces = append $namespaces .metadata.name }}
+{{-     end }}
+{{-   end }}
+{{- end }}
+{{- $namespaces | uniq | sortAlpha | join "," }}
+{{- end }}
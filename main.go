@@ -17,15 +17,22 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
 	"os"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	kubectlscheme "k8s.io/kubectl/pkg/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	appsv1beta1 "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
@@ -36,6 +43,10 @@ import (
 	// +kubebuilder:scaffold:imports
 )
 
+const (
+	watchScopeTimeout = 30 * time.Second
+)
+
 var (
 	scheme   = kubectlscheme.Scheme
 	setupLog = ctrl.Log.WithName("setup")
@@ -78,29 +89,35 @@ func main() {
 		os.Exit(1)
 	}
 
-	operatorScope := "namespace"
+	cfg := ctrl.GetConfigOrDie()
 
-	watchNamespace, err := getWatchNamespace()
+	watchScope, err := loadWatchScope(cfg)
 	if err != nil {
-		operatorScope = "cluster"
-
-		setupLog.Info("unable to get WATCH_NAMESPACE, " +
-			"the manager will watch and manage resources in all namespaces")
+		setupLog.Error(err, "unable to determine the watched namespaces")
+		os.Exit(1)
 	}
 
-	setupLog.Info("setting operator scope", "scope", operatorScope)
+	setupLog.Info("setting operator scope", "scope", watchScope.Name(), "namespaces", watchScope.Namespaces)
+	controllers.RecordWatchScope(watchScope)
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:                     scheme,
 		MetricsBindAddress:         metricsAddr,
 		Port:                       9443,
 		LeaderElection:             enableLeaderElection,
 		LeaderElectionResourceLock: resourcelock.LeasesResourceLock,
 		LeaderElectionID:           "852d23b0.gitlab.com",
-		Namespace:                  watchNamespace,
+		Cache:                      cache.Options{Namespaces: watchScope.Namespaces},
 		HealthProbeBindAddress:     settings.HealthProbeBindAddress,
 		ReadinessEndpointName:      settings.ReadinessEndpointName,
 		LivenessEndpointName:       settings.LivenessEndpointName,
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// StorageClasses are only read when volumes are expanded and
+				// PersistentVolumes when PostgreSQL is upgraded.
+				DisableFor: []client.Object{&storagev1.StorageClass{}, &corev1.PersistentVolume{}},
+			},
+		},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -125,6 +142,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controllers.GitLabRunnerReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("GitLabRunner"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("gitlabrunner-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitLabRunner")
+		os.Exit(1)
+	}
+
 	if err = (&appsv1beta1.GitLab{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "GitLab")
 		os.Exit(1)
@@ -145,17 +172,16 @@ func main() {
 	}
 }
 
-// getWatchNamespace returns the Namespace the operator should be watching for changes.
-func getWatchNamespace() (string, error) {
-	// WatchNamespaceEnvVar is the constant for env variable WATCH_NAMESPACE
-	// which specifies the Namespace to watch.
-	// An empty value means the operator is running with cluster scope.
-	var watchNamespaceEnvVar = "WATCH_NAMESPACE"
-
-	ns, found := os.LookupEnv(watchNamespaceEnvVar)
-	if !found {
-		return "", fmt.Errorf("%s not set", watchNamespaceEnvVar)
+// loadWatchScope returns the namespaces the operator should be watching for
+// changes. See settings.LoadWatchScope for the supported environment variables.
+func loadWatchScope(cfg *rest.Config) (settings.WatchScope, error) {
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return settings.WatchScope{}, err
 	}
 
-	return ns, nil
+	ctx, cancel := context.WithTimeout(context.Background(), watchScopeTimeout)
+	defer cancel()
+
+	return settings.LoadWatchScope(ctx, client)
 }
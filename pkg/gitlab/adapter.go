@@ -14,6 +14,18 @@ import (
 type Adapter interface {
 	Operation
 	Features
+	Secrets
+	ObjectStorage
+	Gitaly
+	ExternalMigration
+	Geo
+	Networking
+	Availability
+	Autoscaling
+	Profile
+	CertManager
+	TLS
+	Trust
 	Status
 	ManagedObjects
 	resource.CustomResourceWrapper
@@ -0,0 +1,26 @@
+package gitlab
+
+import (
+	"time"
+)
+
+// Autoscaling represents the settings of the underlying GitLab resource that
+// control how the Operator scales the components.
+type Autoscaling interface {
+	// SidekiqQueueScalingEnabled returns true when the Sidekiq Deployments are
+	// scaled on the depth and the latency of their queues.
+	SidekiqQueueScalingEnabled() bool
+
+	// SidekiqQueueScaling returns the scaling settings of the Sidekiq pod with
+	// the given name.
+	SidekiqQueueScaling(pod string) SidekiqQueueScaling
+}
+
+// SidekiqQueueScaling is the scaling settings of a Sidekiq pod.
+type SidekiqQueueScaling struct {
+	MinReplicas        int32
+	MaxReplicas        int32
+	TargetQueueDepth   int64
+	TargetQueueLatency time.Duration
+	ScaleDownDelay     time.Duration
+}
@@ -0,0 +1,28 @@
+package gitlab
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Availability represents the settings of the underlying GitLab resource
+// that control the PodDisruptionBudgets and the topology spread of the
+// components.
+type Availability interface {
+	// ManagesAvailability returns true when the Operator must manage the
+	// PodDisruptionBudgets and the topology spread of the components.
+	ManagesAvailability() bool
+
+	// ComponentAvailability returns the overrides of the component. It
+	// returns an empty value when the component is not overridden.
+	ComponentAvailability(component Component) ComponentAvailability
+}
+
+// ComponentAvailability describes the overrides of the PodDisruptionBudgets
+// and the topology spread of a component.
+type ComponentAvailability struct {
+	Disabled                  bool
+	MinAvailable              *intstr.IntOrString
+	MaxUnavailable            *intstr.IntOrString
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint
+}
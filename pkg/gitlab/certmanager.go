@@ -0,0 +1,46 @@
+package gitlab
+
+import (
+	acmev1 "github.com/jetstack/cert-manager/pkg/apis/acme/v1"
+)
+
+const (
+	// CertManagerHostGitLab is the host of Webservice.
+	CertManagerHostGitLab = "gitlab"
+
+	// CertManagerHostRegistry is the host of the Container Registry.
+	CertManagerHostRegistry = "registry"
+
+	// CertManagerHostPages is the wildcard host of GitLab Pages.
+	CertManagerHostPages = "pages"
+
+	// CertManagerHostKAS is the host of the GitLab agent server.
+	CertManagerHostKAS = "kas"
+)
+
+// CertManagerHosts are the hosts whose issuers can be overridden.
+var CertManagerHosts = []string{
+	CertManagerHostGitLab,
+	CertManagerHostRegistry,
+	CertManagerHostPages,
+	CertManagerHostKAS,
+}
+
+// CertManager represents the settings of the underlying GitLab resource that
+// control the cert-manager issuers of the Ingresses.
+type CertManager interface {
+	// CertManagerIssuer returns the existing issuer of the host, or nil when
+	// the host uses the Issuer of the Operator.
+	CertManagerIssuer(host string) *IssuerReference
+
+	// CertManagerDNS01 returns the DNS-01 solver of the Issuer of the
+	// Operator, or nil when it uses the HTTP-01 solver.
+	CertManagerDNS01() *acmev1.ACMEChallengeSolverDNS01
+}
+
+// IssuerReference describes an existing cert-manager Issuer or
+// ClusterIssuer.
+type IssuerReference struct {
+	Name string
+	Kind string
+}
@@ -8,6 +8,7 @@ const (
 	GitLab gitlab.Component = "gitlab"
 
 	Gitaly         gitlab.Component = "gitaly"
+	GeoLogcursor   gitlab.Component = "geo-logcursor"
 	GitLabExporter gitlab.Component = "gitlab-exporter"
 	GitLabPages    gitlab.Component = "gitlab-pages"
 	GitLabShell    gitlab.Component = "gitlab-shell"
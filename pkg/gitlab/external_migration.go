@@ -0,0 +1,35 @@
+package gitlab
+
+// ExternalMigration represents the settings of the underlying GitLab resource
+// that control how the data of the bundled services is moved to external
+// services.
+type ExternalMigration interface {
+	// PostgreSQLMigration returns the external PostgreSQL server that the
+	// bundled PostgreSQL is migrated to, or nil when no migration is requested.
+	PostgreSQLMigration() *PostgreSQLMigration
+
+	// RedisMigration returns the external Redis server that the bundled Redis
+	// is migrated to, or nil when no migration is requested.
+	RedisMigration() *RedisMigration
+}
+
+// PostgreSQLMigration describes the external PostgreSQL server that the
+// GitLab database is moved to.
+type PostgreSQLMigration struct {
+	Host           string
+	Port           int
+	Database       string
+	Username       string
+	PasswordSecret string
+	PasswordKey    string
+}
+
+// RedisMigration describes the external Redis server that the keys of the
+// bundled Redis are moved to. The password is empty when the server does not
+// require one.
+type RedisMigration struct {
+	Host           string
+	Port           int
+	PasswordSecret string
+	PasswordKey    string
+}
@@ -0,0 +1,32 @@
+package gitlab
+
+const (
+	// GeoPrimary is the role of the primary site of a Geo deployment.
+	GeoPrimary = "primary"
+
+	// GeoSecondary is the role of a secondary site of a Geo deployment.
+	GeoSecondary = "secondary"
+)
+
+// Geo represents the settings of the underlying GitLab resource that control
+// the role of the instance in a GitLab Geo deployment.
+type Geo interface {
+	// GeoRole returns the role of the instance, either GeoPrimary or
+	// GeoSecondary. It is empty when the instance is not a Geo site.
+	GeoRole() string
+
+	// GeoPeerDatabase returns the replica of the database of the primary site
+	// that a secondary site reads from, or nil when it is not specified.
+	GeoPeerDatabase() *GeoPeerDatabase
+}
+
+// GeoPeerDatabase describes the read-only replica of the database of the
+// primary site.
+type GeoPeerDatabase struct {
+	Host           string
+	Port           int
+	Database       string
+	Username       string
+	PasswordSecret string
+	PasswordKey    string
+}
@@ -0,0 +1,28 @@
+package gitlab
+
+import (
+	"time"
+)
+
+// Gitaly represents the settings of the underlying GitLab resource that
+// control how Gitaly is managed.
+type Gitaly interface {
+	// StagesGitalyRollout returns true when changes to the Gitaly
+	// StatefulSets must be rolled out one Pod at a time.
+	StagesGitalyRollout() bool
+
+	// GitalyRolloutSoakTime returns the time to wait after an updated Gitaly
+	// Pod becomes ready before the next Pod is updated.
+	GitalyRolloutSoakTime() time.Duration
+
+	// GitalyMigration returns the requested migration of repositories from
+	// the standalone Gitaly to Praefect, or nil when no migration is requested.
+	GitalyMigration() *GitalyMigration
+}
+
+// GitalyMigration describes the migration of repositories from the
+// standalone Gitaly to a Praefect virtual storage.
+type GitalyMigration struct {
+	SourceStorage      string
+	DestinationStorage string
+}
@@ -17,6 +17,10 @@ type Adapter struct {
 	values support.Values
 	charts charts.Catalog
 
+	// valuesFromVersions are the resource versions of the ConfigMaps and
+	// Secrets that hold values.
+	valuesFromVersions []string
+
 	// NOTE: This is a temporary solution to migrate the existing Helm facility
 	//       to the new framework. It will be removed once the migration is
 	//       completed. Do not use it for any other purpose.
@@ -8,14 +8,18 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	api "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/settings"
 
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/component"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+	rt "gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/runtime"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support/charts"
 )
@@ -138,6 +142,164 @@ var _ = Describe("GitLab Adapter [v1beta1]", func() {
 		Expect(a.values.GetValue("gitlab.webservice.serviceAccount.name")).To(Equal("great-service-account"))
 	})
 
+	It("layers the resource profile between operator defaults and user-defined values", func() {
+		values := support.Values{}
+		_ = values.SetValue("gitlab.webservice.minReplicas", 6)
+
+		g := newGitLabResource(getChartVersion(), values)
+		g.Spec.Profile = gitlab.Profile3K
+
+		a, err := NewAdapter(context.TODO(), g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a).NotTo(BeNil())
+
+		Expect(a.ResourceProfile()).To(Equal(gitlab.Profile3K))
+		Expect(a.values.GetValue("gitlab.webservice.minReplicas")).To(BeEquivalentTo(6))
+		Expect(a.values.GetValue("gitlab.webservice.workerProcesses")).To(BeEquivalentTo(4))
+		Expect(a.values.GetString("gitlab.gitaly.resources.requests.memory")).To(Equal("15Gi"))
+		Expect(a.values.GetValue("gitlab.webservice.serviceAccount.name")).To(Equal(settings.AppAnyUIDServiceAccount))
+	})
+
+	It("merges values from ConfigMaps and Secrets before user-defined values", func() {
+		values := support.Values{}
+		_ = values.SetValue("global.hosts.domain", "greatexpectations.com")
+
+		g := newGitLabResource(getChartVersion(), values)
+		g.ObjectMeta.UID = "abcdef"
+		g.ObjectMeta.Generation = 1
+		g.Spec.Chart.ValuesFrom = []api.ValuesReference{
+			{Kind: "ConfigMap", Name: "shared-values"},
+			{Kind: "Secret", Name: "email", ValuesKey: "from", TargetPath: "global.email.from"},
+			{Kind: "Secret", Name: "missing", Optional: true},
+		}
+
+		sharedValues := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared-values", Namespace: "default"},
+			Data: map[string]string{
+				"values.yaml": "global:\n  hosts:\n    domain: example.org\n  time_zone: Europe/Amsterdam\n",
+			},
+		}
+		email := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "email", Namespace: "default"},
+			Data:       map[string][]byte{"from": []byte("gitlab@greatexpectations.com")},
+		}
+
+		c := fake.NewClientBuilder().WithObjects(sharedValues, email).Build()
+		ctx := rt.NewContext(context.TODO(), rt.WithClient(c))
+
+		a, err := NewAdapter(ctx, g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.values.GetString("global.hosts.domain")).To(Equal("greatexpectations.com"))
+		Expect(a.values.GetString("global.time_zone")).To(Equal("Europe/Amsterdam"))
+		Expect(a.values.GetString("global.email.from")).To(Equal("gitlab@greatexpectations.com"))
+
+		h1 := a.Hash()
+		Expect(h1).To(HavePrefix("abcdef-1-"))
+
+		/* Pretend the values are updated without a new generation */
+		sharedValues.Data["values.yaml"] = "global:\n  time_zone: UTC\n"
+		Expect(c.Update(ctx, sharedValues)).To(Succeed())
+
+		a, err = NewAdapter(ctx, g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.values.GetString("global.time_zone")).To(Equal("UTC"))
+		Expect(a.Hash()).NotTo(Equal(h1))
+	})
+
+	It("fails when the values of a required reference do not exist", func() {
+		g := newGitLabResource(getChartVersion(), support.Values{})
+		g.Spec.Chart.ValuesFrom = []api.ValuesReference{
+			{Kind: "Secret", Name: "ldap"},
+		}
+
+		ctx := rt.NewContext(context.TODO(), rt.WithClient(fake.NewClientBuilder().Build()))
+
+		_, err := NewAdapter(ctx, g)
+
+		Expect(err).To(MatchError(ContainSubstring("can not read values from Secret ldap")))
+	})
+
+	It("switches to the external services once their migration completes", func() {
+		g := newGitLabResource(getChartVersion(), support.Values{})
+		g.ObjectMeta.UID = "abcdef"
+		g.ObjectMeta.Generation = 1
+		g.Spec.ExternalMigration = &api.ExternalMigrationSpec{
+			PostgreSQL: &api.ExternalPostgreSQLSpec{
+				Host:     "postgres.example.com",
+				Password: api.SecretKeySpec{Secret: "postgres", Key: "password"},
+			},
+			Redis: &api.ExternalRedisSpec{
+				Host: "redis.example.com",
+			},
+		}
+		g.Status.ExternalMigrations = []api.ExternalMigrationStatus{
+			{Service: status.ExternalMigrationPostgreSQL, Phase: status.ExternalMigrationMigrating},
+		}
+
+		a, err := NewAdapter(context.TODO(), g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.WantsComponent(component.PostgreSQL)).To(BeTrue())
+		Expect(a.values.GetString("global.psql.host")).NotTo(Equal("postgres.example.com"))
+
+		h1 := a.Hash()
+		Expect(h1).To(Equal("abcdef-1"))
+
+		/* Pretend the migration completes without a new generation */
+		g.Status.ExternalMigrations[0].Phase = status.ExternalMigrationCompleted
+
+		a, err = NewAdapter(context.TODO(), g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.WantsComponent(component.PostgreSQL)).To(BeFalse())
+		Expect(a.WantsComponent(component.Redis)).To(BeTrue())
+		Expect(a.values.GetString("global.psql.host")).To(Equal("postgres.example.com"))
+		Expect(a.values.GetString("global.psql.password.secret")).To(Equal("postgres"))
+		Expect(a.values.GetString("global.redis.host")).NotTo(Equal("redis.example.com"))
+
+		h2 := a.Hash()
+		Expect(h2).To(HavePrefix("abcdef-1-"))
+
+		g.Status.ExternalMigrations = append(g.Status.ExternalMigrations,
+			api.ExternalMigrationStatus{Service: status.ExternalMigrationRedis, Phase: status.ExternalMigrationCompleted})
+
+		a, err = NewAdapter(context.TODO(), g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.WantsComponent(component.Redis)).To(BeFalse())
+		Expect(a.values.GetString("global.redis.host")).To(Equal("redis.example.com"))
+		Expect(a.Hash()).NotTo(BeElementOf(h1, h2))
+	})
+
+	It("turns on the transitioning mode of Gitaly while its token is rotated", func() {
+		g := newGitLabResource(getChartVersion(), support.Values{})
+		g.ObjectMeta.UID = "abcdef"
+		g.ObjectMeta.Generation = 1
+		g.Spec.Secrets = &api.GitLabSecretsSpec{
+			Rotation: []api.SecretRotationSpec{{Secret: gitlab.GitalyTokenSecret}},
+		}
+
+		a, err := NewAdapter(context.TODO(), g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.values.GetBool("gitlab.gitaly.auth.transitioning")).To(BeFalse())
+		Expect(a.Hash()).To(Equal("abcdef-1"))
+
+		g.Status.Secrets = []api.SecretRotationStatus{
+			{Secret: gitlab.GitalyTokenSecret, Name: "gitaly-secret", Phase: status.SecretRotationRollingServers},
+		}
+
+		a, err = NewAdapter(context.TODO(), g)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.values.GetBool("gitlab.gitaly.auth.transitioning")).To(BeTrue())
+		Expect(a.values.GetBool("gitlab.praefect.auth.transitioning")).To(BeTrue())
+		Expect(a.Hash()).To(HavePrefix("abcdef-1-"))
+	})
+
 	It("wants default components and features when not specified otherwise", func() {
 		a, err := NewAdapter(context.TODO(),
 			newGitLabResource(getChartVersion(), support.Values{}))
@@ -0,0 +1,79 @@
+package v1beta1
+
+import (
+	"time"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	defaultSidekiqMinReplicas        = 1
+	defaultSidekiqMaxReplicas        = 10
+	defaultSidekiqTargetQueueDepth   = 100
+	defaultSidekiqTargetQueueLatency = 30 * time.Second
+	defaultSidekiqScaleDownDelay     = 5 * time.Minute
+)
+
+/* GitLabAutoscaling */
+
+func (w *Adapter) SidekiqQueueScalingEnabled() bool {
+	return w.source.Spec.Autoscaling != nil &&
+		w.source.Spec.Autoscaling.Sidekiq != nil &&
+		w.source.Spec.Autoscaling.Sidekiq.Enabled
+}
+
+func (w *Adapter) SidekiqQueueScaling(pod string) gitlab.SidekiqQueueScaling {
+	scaling := gitlab.SidekiqQueueScaling{
+		MinReplicas:        defaultSidekiqMinReplicas,
+		MaxReplicas:        defaultSidekiqMaxReplicas,
+		TargetQueueDepth:   defaultSidekiqTargetQueueDepth,
+		TargetQueueLatency: defaultSidekiqTargetQueueLatency,
+		ScaleDownDelay:     defaultSidekiqScaleDownDelay,
+	}
+
+	if w.source.Spec.Autoscaling == nil || w.source.Spec.Autoscaling.Sidekiq == nil {
+		return scaling
+	}
+
+	spec := w.source.Spec.Autoscaling.Sidekiq
+
+	if spec.MinReplicas != nil {
+		scaling.MinReplicas = *spec.MinReplicas
+	}
+
+	if spec.MaxReplicas > 0 {
+		scaling.MaxReplicas = spec.MaxReplicas
+	}
+
+	if spec.TargetQueueDepth > 0 {
+		scaling.TargetQueueDepth = int64(spec.TargetQueueDepth)
+	}
+
+	if spec.TargetQueueLatency != nil && spec.TargetQueueLatency.Duration > 0 {
+		scaling.TargetQueueLatency = spec.TargetQueueLatency.Duration
+	}
+
+	if spec.ScaleDownDelay != nil {
+		scaling.ScaleDownDelay = spec.ScaleDownDelay.Duration
+	}
+
+	for _, override := range spec.Pods {
+		if override.Name != pod {
+			continue
+		}
+
+		if override.MinReplicas != nil {
+			scaling.MinReplicas = *override.MinReplicas
+		}
+
+		if override.MaxReplicas != nil {
+			scaling.MaxReplicas = *override.MaxReplicas
+		}
+	}
+
+	if scaling.MaxReplicas < scaling.MinReplicas {
+		scaling.MaxReplicas = scaling.MinReplicas
+	}
+
+	return scaling
+}
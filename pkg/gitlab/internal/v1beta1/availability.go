@@ -0,0 +1,32 @@
+package v1beta1
+
+import (
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+/* GitLabAvailability */
+
+func (w *Adapter) ManagesAvailability() bool {
+	return w.source.Spec.Availability != nil && w.source.Spec.Availability.Enabled
+}
+
+func (w *Adapter) ComponentAvailability(component gitlab.Component) gitlab.ComponentAvailability {
+	if w.source.Spec.Availability == nil {
+		return gitlab.ComponentAvailability{}
+	}
+
+	for _, spec := range w.source.Spec.Availability.Components {
+		if spec.Name != component.Name() {
+			continue
+		}
+
+		return gitlab.ComponentAvailability{
+			Disabled:                  spec.Disabled,
+			MinAvailable:              spec.MinAvailable,
+			MaxUnavailable:            spec.MaxUnavailable,
+			TopologySpreadConstraints: spec.TopologySpreadConstraints,
+		}
+	}
+
+	return gitlab.ComponentAvailability{}
+}
@@ -0,0 +1,135 @@
+package v1beta1
+
+import (
+	"context"
+
+	acmev1 "github.com/jetstack/cert-manager/pkg/apis/acme/v1"
+
+	api "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	certManagerIssuerAnnotation      = "cert-manager.io/issuer"
+	certManagerIssuerKindAnnotation  = "cert-manager.io/issuer-kind"
+	certManagerIssuerGroupAnnotation = "cert-manager.io/issuer-group"
+	certManagerGroup                 = "cert-manager.io"
+)
+
+// certManagerIngressKeys are the keys of the Ingress settings of the hosts in
+// the chart values.
+var certManagerIngressKeys = map[string]string{
+	gitlab.CertManagerHostGitLab:   "gitlab.webservice.ingress",
+	gitlab.CertManagerHostRegistry: "registry.ingress",
+	gitlab.CertManagerHostPages:    "gitlab.gitlab-pages.ingress",
+	gitlab.CertManagerHostKAS:      "gitlab.kas.ingress",
+}
+
+/* GitLabCertManager */
+
+func (w *Adapter) CertManagerIssuer(host string) *gitlab.IssuerReference {
+	spec := w.source.Spec.CertManager
+	if spec == nil {
+		return nil
+	}
+
+	if issuer := hostIssuer(spec.Hosts, host); issuer != nil {
+		return issuerReference(issuer)
+	}
+
+	if spec.Issuer != nil {
+		return issuerReference(spec.Issuer)
+	}
+
+	return nil
+}
+
+func (w *Adapter) CertManagerDNS01() *acmev1.ACMEChallengeSolverDNS01 {
+	if w.source.Spec.CertManager == nil {
+		return nil
+	}
+
+	return w.source.Spec.CertManager.DNS01
+}
+
+/* Helpers */
+
+func hostIssuer(hosts *api.CertManagerHostsSpec, host string) *api.IssuerReferenceSpec {
+	if hosts == nil {
+		return nil
+	}
+
+	switch host {
+	case gitlab.CertManagerHostGitLab:
+		return hosts.GitLab
+	case gitlab.CertManagerHostRegistry:
+		return hosts.Registry
+	case gitlab.CertManagerHostPages:
+		return hosts.Pages
+	case gitlab.CertManagerHostKAS:
+		return hosts.KAS
+	}
+
+	return nil
+}
+
+func issuerReference(spec *api.IssuerReferenceSpec) *gitlab.IssuerReference {
+	kind := spec.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+
+	return &gitlab.IssuerReference{
+		Name: spec.Name,
+		Kind: kind,
+	}
+}
+
+// applyCertManagerValues points the Ingresses of the hosts to their existing
+// issuers. The annotations replace the Issuer of the Operator that the
+// override values set, so they are applied after them.
+//
+// The annotations of the default issuer are set globally and the annotations
+// of the hosts are set on their Ingresses, which take precedence in the
+// chart. They use the same keys, so that an Issuer and a ClusterIssuer never
+// conflict.
+func (w *Adapter) applyCertManagerValues(_ context.Context) error {
+	if !w.WantsFeature(ConfigureCertManager) || w.source.Spec.CertManager == nil {
+		return nil
+	}
+
+	if issuer := w.source.Spec.CertManager.Issuer; issuer != nil {
+		if err := w.setIssuerAnnotations("global.ingress", issuerReference(issuer)); err != nil {
+			return err
+		}
+	}
+
+	for _, host := range gitlab.CertManagerHosts {
+		if issuer := hostIssuer(w.source.Spec.CertManager.Hosts, host); issuer != nil {
+			if err := w.setIssuerAnnotations(certManagerIngressKeys[host], issuerReference(issuer)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setIssuerAnnotations sets the issuer annotations of the Ingress settings
+// with the key. The annotation keys contain dots, so they are set on the
+// annotations map instead of with their full keys.
+func (w *Adapter) setIssuerAnnotations(key string, issuer *gitlab.IssuerReference) error {
+	annotations := map[string]interface{}{}
+
+	if current, err := w.values.GetValue(key + ".annotations"); err == nil {
+		if current, ok := current.(map[string]interface{}); ok {
+			annotations = current
+		}
+	}
+
+	annotations[certManagerIssuerAnnotation] = issuer.Name
+	annotations[certManagerIssuerKindAnnotation] = issuer.Kind
+	annotations[certManagerIssuerGroupAnnotation] = certManagerGroup
+
+	return w.values.SetValue(key+".annotations", annotations)
+}
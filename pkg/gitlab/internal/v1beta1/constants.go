@@ -1,5 +1,28 @@
 package v1beta1
 
+import (
+	"time"
+)
+
 const (
 	defaultCertManagerIssuerEmail = "admin@example.com"
+
+	defaultSecretRotationInterval = 90 * 24 * time.Hour
+	defaultSecretRotationOverlap  = time.Hour
+
+	secretsGeneratorOperator = "Operator"
+
+	defaultGitalyRolloutSoakTime = 5 * time.Minute
+
+	gitalyRolloutStrategyStaged = "Staged"
+
+	defaultGitalyMigrationSourceStorage = "default"
+
+	defaultExternalPostgreSQLPort     = 5432
+	defaultExternalPostgreSQLDatabase = "gitlabhq_production"
+	defaultExternalPostgreSQLUsername = "gitlab"
+	defaultExternalRedisPort          = 6379
+
+	defaultGeoTrackingDatabase = "gitlabhq_geo_production"
+	defaultGeoTrackingUsername = "gitlab_geo"
 )
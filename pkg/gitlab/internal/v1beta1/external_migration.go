@@ -0,0 +1,134 @@
+package v1beta1
+
+import (
+	"context"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
+)
+
+/* GitLabExternalMigration */
+
+func (w *Adapter) PostgreSQLMigration() *gitlab.PostgreSQLMigration {
+	if w.source.Spec.ExternalMigration == nil || w.source.Spec.ExternalMigration.PostgreSQL == nil {
+		return nil
+	}
+
+	spec := w.source.Spec.ExternalMigration.PostgreSQL
+
+	migration := &gitlab.PostgreSQLMigration{
+		Host:           spec.Host,
+		Port:           int(spec.Port),
+		Database:       spec.Database,
+		Username:       spec.Username,
+		PasswordSecret: spec.Password.Secret,
+		PasswordKey:    spec.Password.Key,
+	}
+
+	if migration.Port == 0 {
+		migration.Port = defaultExternalPostgreSQLPort
+	}
+
+	if migration.Database == "" {
+		migration.Database = defaultExternalPostgreSQLDatabase
+	}
+
+	if migration.Username == "" {
+		migration.Username = defaultExternalPostgreSQLUsername
+	}
+
+	return migration
+}
+
+func (w *Adapter) RedisMigration() *gitlab.RedisMigration {
+	if w.source.Spec.ExternalMigration == nil || w.source.Spec.ExternalMigration.Redis == nil {
+		return nil
+	}
+
+	spec := w.source.Spec.ExternalMigration.Redis
+
+	migration := &gitlab.RedisMigration{
+		Host: spec.Host,
+		Port: int(spec.Port),
+	}
+
+	if migration.Port == 0 {
+		migration.Port = defaultExternalRedisPort
+	}
+
+	if spec.Password != nil {
+		migration.PasswordSecret = spec.Password.Secret
+		migration.PasswordKey = spec.Password.Key
+	}
+
+	return migration
+}
+
+/* Helpers */
+
+// applyExternalMigrationValues points the instance to the external services
+// once the data of the bundled services is migrated. It takes precedence over
+// the user-defined values so that the instance does not switch back to the
+// bundled services before the user updates the values.
+func (w *Adapter) applyExternalMigrationValues(_ context.Context) error {
+	if postgres := w.PostgreSQLMigration(); postgres != nil && w.externalMigrationCompleted(status.ExternalMigrationPostgreSQL) {
+		for key, value := range map[string]interface{}{
+			"postgresql.install":          false,
+			"global.psql.host":            postgres.Host,
+			"global.psql.port":            postgres.Port,
+			"global.psql.database":        postgres.Database,
+			"global.psql.username":        postgres.Username,
+			"global.psql.password.secret": postgres.PasswordSecret,
+			"global.psql.password.key":    postgres.PasswordKey,
+		} {
+			if err := w.values.SetValue(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if redis := w.RedisMigration(); redis != nil && w.externalMigrationCompleted(status.ExternalMigrationRedis) {
+		values := map[string]interface{}{
+			"redis.install":             false,
+			"global.redis.host":         redis.Host,
+			"global.redis.port":         redis.Port,
+			"global.redis.auth.enabled": redis.PasswordSecret != "",
+		}
+
+		if redis.PasswordSecret != "" {
+			values["global.redis.auth.secret"] = redis.PasswordSecret
+			values["global.redis.auth.key"] = redis.PasswordKey
+		}
+
+		for key, value := range values {
+			if err := w.values.SetValue(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// completedExternalMigrations lists the services whose values switched to
+// the external service, so that the template is rendered again when their
+// migration completes.
+func (w *Adapter) completedExternalMigrations() []string {
+	completed := []string{}
+
+	if w.PostgreSQLMigration() != nil && w.externalMigrationCompleted(status.ExternalMigrationPostgreSQL) {
+		completed = append(completed, "migrated:"+status.ExternalMigrationPostgreSQL)
+	}
+
+	if w.RedisMigration() != nil && w.externalMigrationCompleted(status.ExternalMigrationRedis) {
+		completed = append(completed, "migrated:"+status.ExternalMigrationRedis)
+	}
+
+	return completed
+}
+
+func (w *Adapter) externalMigrationCompleted(service string) bool {
+	progress := w.ExternalMigrationProgress(service)
+
+	return progress != nil && progress.Phase == status.ExternalMigrationCompleted
+}
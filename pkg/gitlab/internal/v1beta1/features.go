@@ -45,8 +45,17 @@ func newCheckEnabled(keys ...string) gitlab.FeatureCheck {
 	return newCheckEnabledWithDefault(false, keys...)
 }
 
+// checkGeoLogcursorEnabled checks that the instance is a Geo secondary site,
+// which is the only role that runs the Geo Log Cursor.
+func checkGeoLogcursorEnabled(values support.Values) bool {
+	return values.GetBool("global.geo.enabled") &&
+		values.GetString("global.geo.role") == gitlab.GeoSecondary &&
+		values.GetBool("gitlab.geo-logcursor.enabled", true)
+}
+
 var mapComponentEnabled = map[gitlab.Component]gitlab.FeatureCheck{
 	component.Gitaly:         newCheckEnabled("global.gitaly.enabled"),
+	component.GeoLogcursor:   checkGeoLogcursorEnabled,
 	component.GitLabExporter: newCheckEnabled("gitlab.gitlab-exporter.enabled"),
 	component.GitLabPages:    newCheckEnabled("global.pages.enabled"),
 	component.GitLabShell:    newCheckEnabled("gitlab.gitlab-shell.enabled"),
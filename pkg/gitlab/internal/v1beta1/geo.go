@@ -0,0 +1,104 @@
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+/* GitLabGeo */
+
+func (w *Adapter) GeoRole() string {
+	if w.source.Spec.Geo == nil {
+		return ""
+	}
+
+	return w.source.Spec.Geo.Role
+}
+
+func (w *Adapter) GeoPeerDatabase() *gitlab.GeoPeerDatabase {
+	if w.source.Spec.Geo == nil || w.source.Spec.Geo.Peer == nil {
+		return nil
+	}
+
+	spec := w.source.Spec.Geo.Peer.Database
+
+	database := &gitlab.GeoPeerDatabase{
+		Host:           spec.Host,
+		Port:           int(spec.Port),
+		Database:       spec.Database,
+		Username:       spec.Username,
+		PasswordSecret: spec.Password.Secret,
+		PasswordKey:    spec.Password.Key,
+	}
+
+	if database.Port == 0 {
+		database.Port = defaultExternalPostgreSQLPort
+	}
+
+	if database.Database == "" {
+		database.Database = defaultExternalPostgreSQLDatabase
+	}
+
+	if database.Username == "" {
+		database.Username = defaultExternalPostgreSQLUsername
+	}
+
+	return database
+}
+
+/* Helpers */
+
+// applyGeoValues renders the Geo values of the role of the instance. They are
+// applied before the user-defined values, so that the user can still change
+// them, for example to use an external tracking database.
+//
+// A secondary site reads from the replica of the database of the primary
+// site. The tracking database is created in the bundled PostgreSQL, which
+// keeps its own password Secret.
+func (w *Adapter) applyGeoValues(_ context.Context) error {
+	role := w.GeoRole()
+	if role == "" {
+		return nil
+	}
+
+	values := map[string]interface{}{
+		"global.geo.enabled": true,
+		"global.geo.role":    role,
+	}
+
+	if nodeName := w.source.Spec.Geo.NodeName; nodeName != "" {
+		values["global.geo.nodeName"] = nodeName
+	}
+
+	if role == gitlab.GeoSecondary {
+		bundledSecret := fmt.Sprintf("%s-postgresql-password", w.ReleaseName())
+
+		values["global.geo.psql.host"] = fmt.Sprintf("%s-postgresql.%s.svc", w.ReleaseName(), w.source.Namespace)
+		values["global.geo.psql.port"] = defaultExternalPostgreSQLPort
+		values["global.geo.psql.database"] = defaultGeoTrackingDatabase
+		values["global.geo.psql.username"] = defaultGeoTrackingUsername
+		values["global.geo.psql.password.secret"] = fmt.Sprintf("%s-geo-postgresql-password", w.ReleaseName())
+		values["global.geo.psql.password.key"] = "postgresql-password"
+		values["postgresql.auth.existingSecret"] = bundledSecret
+		values["postgresql.auth.secretKeys.userPasswordKey"] = "postgresql-password"
+
+		if peer := w.GeoPeerDatabase(); peer != nil {
+			values["global.psql.host"] = peer.Host
+			values["global.psql.port"] = peer.Port
+			values["global.psql.database"] = peer.Database
+			values["global.psql.username"] = peer.Username
+			values["global.psql.password.secret"] = peer.PasswordSecret
+			values["global.psql.password.key"] = peer.PasswordKey
+		}
+	}
+
+	for key, value := range values {
+		if err := w.values.SetValue(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package v1beta1
+
+import (
+	"time"
+
+	api "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+/* GitLabGitaly */
+
+func (w *Adapter) StagesGitalyRollout() bool {
+	return w.gitalyRollout() != nil && w.gitalyRollout().Strategy == gitalyRolloutStrategyStaged
+}
+
+func (w *Adapter) GitalyRolloutSoakTime() time.Duration {
+	if w.gitalyRollout() == nil || w.gitalyRollout().SoakTime == nil {
+		return defaultGitalyRolloutSoakTime
+	}
+
+	return w.gitalyRollout().SoakTime.Duration
+}
+
+func (w *Adapter) GitalyMigration() *gitlab.GitalyMigration {
+	if w.source.Spec.Gitaly == nil || w.source.Spec.Gitaly.Migration == nil {
+		return nil
+	}
+
+	migration := w.source.Spec.Gitaly.Migration
+
+	source := migration.SourceStorage
+	if source == "" {
+		source = defaultGitalyMigrationSourceStorage
+	}
+
+	return &gitlab.GitalyMigration{
+		SourceStorage:      source,
+		DestinationStorage: migration.DestinationStorage,
+	}
+}
+
+func (w *Adapter) gitalyRollout() *api.GitalyRolloutSpec {
+	if w.source.Spec.Gitaly == nil {
+		return nil
+	}
+
+	return w.source.Spec.Gitaly.Rollout
+}
@@ -89,6 +89,22 @@ var potentialSupportedGVRs []schema.GroupVersionResource = []schema.GroupVersion
 		Group:    "extensions",
 		Version:  "v1beta1",
 		Resource: "ingresses",
+	}, {
+		Group:    "networking.k8s.io",
+		Version:  "v1",
+		Resource: "networkpolicies",
+	}, {
+		Group:    "route.openshift.io",
+		Version:  "v1",
+		Resource: "routes",
+	}, {
+		Group:    "gateway.networking.k8s.io",
+		Version:  "v1",
+		Resource: "httproutes",
+	}, {
+		Group:    "gateway.networking.k8s.io",
+		Version:  "v1alpha2",
+		Resource: "tcproutes",
 	}, {
 		Group:    "batch",
 		Version:  "v1",
@@ -117,6 +133,10 @@ var potentialSupportedGVRs []schema.GroupVersionResource = []schema.GroupVersion
 		Group:    "autoscaling",
 		Version:  "v1",
 		Resource: "horizontalpodautoscalers",
+	}, {
+		Group:    "policy",
+		Version:  "v1",
+		Resource: "poddisruptionbudgets",
 	}, {
 		Group:    "monitoring.coreos.com",
 		Version:  "v1",
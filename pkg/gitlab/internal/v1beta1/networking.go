@@ -0,0 +1,80 @@
+package v1beta1
+
+import (
+	"context"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+/* GitLabNetworking */
+
+func (w *Adapter) NetworkingMode() string {
+	if w.source.Spec.Networking == nil || w.source.Spec.Networking.Mode == "" {
+		return gitlab.NetworkingModeIngress
+	}
+
+	return w.source.Spec.Networking.Mode
+}
+
+func (w *Adapter) NetworkingGateway() *gitlab.GatewayReference {
+	if w.source.Spec.Networking == nil || w.source.Spec.Networking.Gateway == nil {
+		return nil
+	}
+
+	spec := w.source.Spec.Networking.Gateway
+
+	gateway := &gitlab.GatewayReference{
+		Name:         spec.Name,
+		Namespace:    spec.Namespace,
+		HTTPListener: spec.HTTPListener,
+		SSHListener:  spec.SSHListener,
+	}
+
+	if gateway.Namespace == "" {
+		gateway.Namespace = w.source.Namespace
+	}
+
+	return gateway
+}
+
+func (w *Adapter) WantsNetworkPolicies() bool {
+	return w.source.Spec.NetworkPolicies != nil && w.source.Spec.NetworkPolicies.Enabled
+}
+
+/* Helpers */
+
+// applyNetworkingValues renders the values of the networking mode of the
+// instance. They are applied before the user-defined values, so that the user
+// can still change them.
+//
+// Routes are served by the OpenShift Router or the Gateway, so the NGINX
+// Ingress Controller is not deployed. The HTTP-01 challenges of cert-manager
+// need the Ingresses, so the chart does not configure cert-manager either.
+func (w *Adapter) applyNetworkingValues(_ context.Context) error {
+	if w.NetworkingMode() == gitlab.NetworkingModeIngress {
+		return nil
+	}
+
+	values := map[string]interface{}{
+		"nginx-ingress.enabled":               false,
+		"global.ingress.configureCertmanager": false,
+	}
+
+	for key, value := range values {
+		if err := w.values.SetValue(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyNetworkingOverrideValues disables the NGINX Ingress Controller in the
+// GatewayAPI mode, even when the user enables it. The Gateway replaces it.
+func (w *Adapter) applyNetworkingOverrideValues(_ context.Context) error {
+	if w.NetworkingMode() != gitlab.NetworkingModeGatewayAPI {
+		return nil
+	}
+
+	return w.values.SetValue("nginx-ingress.enabled", false)
+}
@@ -0,0 +1,7 @@
+package v1beta1
+
+/* GitLabObjectStorage */
+
+func (w *Adapter) ProvisionsBuckets() bool {
+	return w.source.Spec.ObjectStorage != nil && w.source.Spec.ObjectStorage.ProvisionBuckets
+}
@@ -5,6 +5,12 @@ gitlab-runner:
   install: false
 
 gitlab:
+  geo-logcursor:
+    common:
+      labels:
+        app.kubernetes.io/component: geo-logcursor
+        app.kubernetes.io/instance: {{ .ReleaseName }}-geo-logcursor
+
   gitaly:
     common:
       labels:
@@ -86,7 +92,9 @@ global:
     {{ if .UseCertManager }}
     annotations:
       cert-manager.io/issuer: {{ .ReleaseName }}-issuer
+      {{- if not .UseDNS01 }}
       acme.cert-manager.io/http01-edit-in-place: true
+      {{- end }}
     {{ end }}
 
   serviceAccount:
@@ -0,0 +1,49 @@
+# Up to 10,000 users, 200 requests per second.
+
+gitlab:
+  webservice:
+    minReplicas: 12
+    maxReplicas: 20
+    workerProcesses: 4
+    resources:
+      requests:
+        cpu: 4
+        memory: 5G
+      limits:
+        memory: 7G
+
+  sidekiq:
+    minReplicas: 14
+    maxReplicas: 20
+    concurrency: 20
+    resources:
+      requests:
+        cpu: 900m
+        memory: 2G
+      limits:
+        memory: 4G
+
+  gitlab-shell:
+    minReplicas: 4
+    maxReplicas: 10
+
+  kas:
+    minReplicas: 4
+    maxReplicas: 10
+
+  gitaly:
+    resources:
+      requests:
+        cpu: 16
+        memory: 60Gi
+      limits:
+        memory: 60Gi
+
+registry:
+  hpa:
+    minReplicas: 4
+    maxReplicas: 10
+
+nginx-ingress:
+  controller:
+    replicaCount: 3
@@ -0,0 +1,49 @@
+# Up to 1,000 users, 20 requests per second.
+
+gitlab:
+  webservice:
+    minReplicas: 2
+    maxReplicas: 3
+    workerProcesses: 2
+    resources:
+      requests:
+        cpu: 2
+        memory: 2.5G
+      limits:
+        memory: 3.5G
+
+  sidekiq:
+    minReplicas: 1
+    maxReplicas: 2
+    concurrency: 20
+    resources:
+      requests:
+        cpu: 900m
+        memory: 2G
+      limits:
+        memory: 4G
+
+  gitlab-shell:
+    minReplicas: 2
+    maxReplicas: 4
+
+  kas:
+    minReplicas: 2
+    maxReplicas: 4
+
+  gitaly:
+    resources:
+      requests:
+        cpu: 2
+        memory: 7.5Gi
+      limits:
+        memory: 7.5Gi
+
+registry:
+  hpa:
+    minReplicas: 2
+    maxReplicas: 4
+
+nginx-ingress:
+  controller:
+    replicaCount: 2
@@ -0,0 +1,49 @@
+# Up to 3,000 users, 60 requests per second.
+
+gitlab:
+  webservice:
+    minReplicas: 4
+    maxReplicas: 6
+    workerProcesses: 4
+    resources:
+      requests:
+        cpu: 4
+        memory: 5G
+      limits:
+        memory: 7G
+
+  sidekiq:
+    minReplicas: 4
+    maxReplicas: 8
+    concurrency: 20
+    resources:
+      requests:
+        cpu: 900m
+        memory: 2G
+      limits:
+        memory: 4G
+
+  gitlab-shell:
+    minReplicas: 2
+    maxReplicas: 6
+
+  kas:
+    minReplicas: 2
+    maxReplicas: 6
+
+  gitaly:
+    resources:
+      requests:
+        cpu: 4
+        memory: 15Gi
+      limits:
+        memory: 15Gi
+
+registry:
+  hpa:
+    minReplicas: 2
+    maxReplicas: 6
+
+nginx-ingress:
+  controller:
+    replicaCount: 2
@@ -0,0 +1,43 @@
+# Evaluation and development: a single replica of each component.
+
+gitlab:
+  webservice:
+    minReplicas: 1
+    maxReplicas: 1
+    workerProcesses: 1
+    resources:
+      requests:
+        cpu: 300m
+        memory: 1.5G
+
+  sidekiq:
+    minReplicas: 1
+    maxReplicas: 1
+    concurrency: 10
+    resources:
+      requests:
+        cpu: 200m
+        memory: 1G
+
+  gitlab-shell:
+    minReplicas: 1
+    maxReplicas: 1
+
+  kas:
+    minReplicas: 1
+    maxReplicas: 1
+
+  gitaly:
+    resources:
+      requests:
+        cpu: 200m
+        memory: 300Mi
+
+registry:
+  hpa:
+    minReplicas: 1
+    maxReplicas: 1
+
+nginx-ingress:
+  controller:
+    replicaCount: 1
@@ -0,0 +1,27 @@
+package v1beta1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+/* GitLabProfile */
+
+func (w *Adapter) ResourceProfile() string {
+	return w.source.Spec.Profile
+}
+
+func (w *Adapter) applyProfileValues(_ context.Context) error {
+	profile := w.ResourceProfile()
+	if profile == "" {
+		return nil
+	}
+
+	template, ok := profileValuesTemplates[profile]
+	if !ok {
+		return errors.Errorf("unknown resource profile: %s", profile)
+	}
+
+	return w.loadValuesFromTemplate(template)
+}
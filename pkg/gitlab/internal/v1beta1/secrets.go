@@ -0,0 +1,77 @@
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+/* GitLabSecrets */
+
+func (w *Adapter) GeneratesSecretsNatively() bool {
+	return w.source.Spec.Secrets != nil && w.source.Spec.Secrets.Generator == secretsGeneratorOperator
+}
+
+func (w *Adapter) SecretRotations() []gitlab.SecretRotation {
+	if w.source.Spec.Secrets == nil {
+		return nil
+	}
+
+	result := make([]gitlab.SecretRotation, 0, len(w.source.Spec.Secrets.Rotation))
+
+	for _, r := range w.source.Spec.Secrets.Rotation {
+		interval := defaultSecretRotationInterval
+		if r.Interval != nil && r.Interval.Duration > 0 {
+			interval = r.Interval.Duration
+		}
+
+		result = append(result, gitlab.SecretRotation{
+			Secret:   r.Secret,
+			Interval: interval,
+		})
+	}
+
+	return result
+}
+
+func (w *Adapter) SecretRotationOverlap() time.Duration {
+	if w.source.Spec.Secrets == nil || w.source.Spec.Secrets.RotationOverlap == nil {
+		return defaultSecretRotationOverlap
+	}
+
+	return w.source.Spec.Secrets.RotationOverlap.Duration
+}
+
+/* Helpers */
+
+// applySecretRotationValues turns on the transitioning mode of the Gitaly and
+// Praefect authentication while the Gitaly token is rotated, so that they
+// accept the previous token of their clients until the rotation completes.
+func (w *Adapter) applySecretRotationValues(_ context.Context) error {
+	if !w.rotatesGitalyToken() {
+		return nil
+	}
+
+	for _, key := range []string{"gitlab.gitaly.auth.transitioning", "gitlab.praefect.auth.transitioning"} {
+		if err := w.values.SetValue(key, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rotatingSecrets lists the Secrets whose rotation changes the values, so
+// that the template is rendered again when the rotation starts and completes.
+func (w *Adapter) rotatingSecrets() []string {
+	if !w.rotatesGitalyToken() {
+		return nil
+	}
+
+	return []string{"rotating:" + gitlab.GitalyTokenSecret}
+}
+
+func (w *Adapter) rotatesGitalyToken() bool {
+	return w.SecretRotationPhase(gitlab.GitalyTokenSecret) != ""
+}
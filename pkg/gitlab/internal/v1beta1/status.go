@@ -1,9 +1,14 @@
 package v1beta1
 
 import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	api "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab/status"
 )
 
@@ -24,6 +29,441 @@ func (w *Adapter) SetCondition(condition metav1.Condition) {
 	meta.SetStatusCondition(&w.source.Status.Conditions, condition)
 }
 
+func (w *Adapter) Condition(conditionType gitlab.ConditionType) *metav1.Condition {
+	return meta.FindStatusCondition(w.source.Status.Conditions, conditionType.Name())
+}
+
 func (w *Adapter) RecordVersion() {
 	w.source.Status.Version = w.DesiredVersion()
 }
+
+func (w *Adapter) RecordSecretRotation(secret, name, phase string, rotatedAt time.Time) {
+	record := api.SecretRotationStatus{
+		Secret:           secret,
+		Name:             name,
+		LastRotationTime: metav1.NewTime(rotatedAt),
+		Phase:            phase,
+	}
+
+	for i := range w.source.Status.Secrets {
+		if w.source.Status.Secrets[i].Secret == secret {
+			w.source.Status.Secrets[i] = record
+			return
+		}
+	}
+
+	w.source.Status.Secrets = append(w.source.Status.Secrets, record)
+}
+
+func (w *Adapter) SecretRotationPhase(secret string) string {
+	for _, current := range w.source.Status.Secrets {
+		if current.Secret == secret {
+			return current.Phase
+		}
+	}
+
+	return ""
+}
+
+func (w *Adapter) RecordRedisHealth(health gitlab.RedisHealth) {
+	record := api.RedisInstanceStatus{
+		Instance:      health.Instance,
+		Address:       health.Address,
+		Version:       health.Version,
+		Ready:         health.Ready,
+		Message:       health.Message,
+		LastProbeTime: metav1.NewTime(health.ProbedAt),
+	}
+
+	for i := range w.source.Status.Redis {
+		current := &w.source.Status.Redis[i]
+
+		if current.Instance != health.Instance {
+			continue
+		}
+
+		// Keep the probe time while the result does not change, so that
+		// probing does not update the status on every reconcile.
+		if current.Address == record.Address && current.Version == record.Version &&
+			current.Ready == record.Ready && current.Message == record.Message {
+			return
+		}
+
+		*current = record
+
+		return
+	}
+
+	w.source.Status.Redis = append(w.source.Status.Redis, record)
+}
+
+func (w *Adapter) RecordVolumeExpansion(expansion gitlab.VolumeExpansion) {
+	record := api.VolumeExpansionStatus{
+		Workload:           expansion.Workload,
+		Claim:              expansion.Claim,
+		Size:               expansion.Size,
+		Phase:              expansion.Phase,
+		Message:            expansion.Message,
+		LastTransitionTime: metav1.Now(),
+	}
+
+	for i := range w.source.Status.VolumeExpansions {
+		current := &w.source.Status.VolumeExpansions[i]
+
+		if current.Claim != expansion.Claim {
+			continue
+		}
+
+		if current.Phase == record.Phase && current.Size == record.Size {
+			record.LastTransitionTime = current.LastTransitionTime
+		}
+
+		*current = record
+
+		return
+	}
+
+	if expansion.Phase == status.VolumeExpansionCompleted {
+		return
+	}
+
+	w.source.Status.VolumeExpansions = append(w.source.Status.VolumeExpansions, record)
+}
+
+func (w *Adapter) VolumeExpansionInProgress() bool {
+	for _, expansion := range w.source.Status.VolumeExpansions {
+		if expansion.Phase != status.VolumeExpansionCompleted &&
+			expansion.Phase != status.VolumeExpansionUnsupported {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *Adapter) RecordGitalyRollout(rollout gitlab.GitalyRollout) {
+	record := api.GitalyRolloutStatus{
+		StatefulSet:        rollout.StatefulSet,
+		Partition:          rollout.Partition,
+		UpdatedReplicas:    rollout.UpdatedReplicas,
+		Replicas:           rollout.Replicas,
+		Phase:              rollout.Phase,
+		Message:            rollout.Message,
+		LastTransitionTime: metav1.Now(),
+	}
+
+	for i := range w.source.Status.GitalyRollouts {
+		current := &w.source.Status.GitalyRollouts[i]
+
+		if current.StatefulSet != rollout.StatefulSet {
+			continue
+		}
+
+		if current.Phase == record.Phase && current.Partition == record.Partition {
+			record.LastTransitionTime = current.LastTransitionTime
+		}
+
+		*current = record
+
+		return
+	}
+
+	w.source.Status.GitalyRollouts = append(w.source.Status.GitalyRollouts, record)
+}
+
+func (w *Adapter) GitalyRolloutInProgress() bool {
+	for _, rollout := range w.source.Status.GitalyRollouts {
+		if rollout.Phase != status.GitalyRolloutCompleted {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *Adapter) RecordGitalyMigration(migration *gitlab.GitalyMigrationProgress) {
+	if migration == nil {
+		w.source.Status.GitalyMigration = nil
+		return
+	}
+
+	record := &api.GitalyMigrationStatus{
+		SourceStorage:         migration.SourceStorage,
+		DestinationStorage:    migration.DestinationStorage,
+		Phase:                 migration.Phase,
+		Attempt:               migration.Attempt,
+		RemainingRepositories: migration.Remaining,
+		InProgressMoves:       migration.InProgress,
+		FailedMoves:           migration.Failed,
+		Message:               migration.Message,
+	}
+
+	if !migration.LastCheckTime.IsZero() {
+		lastCheckTime := metav1.NewTime(migration.LastCheckTime)
+		record.LastCheckTime = &lastCheckTime
+	}
+
+	if current := w.source.Status.GitalyMigration; current != nil {
+		record.StartTime = current.StartTime
+		record.CompletionTime = current.CompletionTime
+	}
+
+	now := metav1.Now()
+
+	if record.StartTime == nil && migration.Attempt > 0 {
+		record.StartTime = &now
+	}
+
+	if record.CompletionTime == nil && migration.Phase == status.GitalyMigrationCompleted {
+		record.CompletionTime = &now
+	}
+
+	w.source.Status.GitalyMigration = record
+}
+
+func (w *Adapter) GitalyMigrationProgress() *gitlab.GitalyMigrationProgress {
+	current := w.source.Status.GitalyMigration
+	if current == nil {
+		return nil
+	}
+
+	progress := &gitlab.GitalyMigrationProgress{
+		SourceStorage:      current.SourceStorage,
+		DestinationStorage: current.DestinationStorage,
+		Phase:              current.Phase,
+		Attempt:            current.Attempt,
+		Remaining:          current.RemainingRepositories,
+		InProgress:         current.InProgressMoves,
+		Failed:             current.FailedMoves,
+		Message:            current.Message,
+	}
+
+	if current.LastCheckTime != nil {
+		progress.LastCheckTime = current.LastCheckTime.Time
+	}
+
+	return progress
+}
+
+func (w *Adapter) RecordPostgreSQLUpgrade(upgrade *gitlab.PostgreSQLUpgrade) {
+	if upgrade == nil {
+		w.source.Status.PostgreSQLUpgrade = nil
+		return
+	}
+
+	record := &api.PostgreSQLUpgradeStatus{
+		FromVersion:    int32(upgrade.FromVersion),
+		ToVersion:      int32(upgrade.ToVersion),
+		FromImage:      upgrade.FromImage,
+		Phase:          upgrade.Phase,
+		Message:        upgrade.Message,
+		RetainedVolume: upgrade.RetainedVolume,
+	}
+
+	if current := w.source.Status.PostgreSQLUpgrade; current != nil &&
+		current.FromVersion == record.FromVersion && current.ToVersion == record.ToVersion {
+		record.StartTime = current.StartTime
+		record.CompletionTime = current.CompletionTime
+	}
+
+	now := metav1.Now()
+
+	if record.StartTime == nil {
+		record.StartTime = &now
+	}
+
+	if record.CompletionTime == nil && upgrade.Phase == status.PostgreSQLUpgradeCompleted {
+		record.CompletionTime = &now
+	}
+
+	w.source.Status.PostgreSQLUpgrade = record
+}
+
+func (w *Adapter) PostgreSQLUpgrade() *gitlab.PostgreSQLUpgrade {
+	current := w.source.Status.PostgreSQLUpgrade
+	if current == nil {
+		return nil
+	}
+
+	return &gitlab.PostgreSQLUpgrade{
+		FromVersion:    int(current.FromVersion),
+		ToVersion:      int(current.ToVersion),
+		FromImage:      current.FromImage,
+		Phase:          current.Phase,
+		Message:        current.Message,
+		RetainedVolume: current.RetainedVolume,
+	}
+}
+
+func (w *Adapter) RecordExternalMigration(migration gitlab.ExternalMigrationProgress) {
+	record := api.ExternalMigrationStatus{
+		Service:          migration.Service,
+		Phase:            migration.Phase,
+		Message:          migration.Message,
+		SourceCount:      migration.SourceCount,
+		DestinationCount: migration.DestinationCount,
+	}
+
+	now := metav1.Now()
+
+	for i := range w.source.Status.ExternalMigrations {
+		current := &w.source.Status.ExternalMigrations[i]
+		if current.Service != migration.Service {
+			continue
+		}
+
+		record.StartTime = current.StartTime
+		record.CompletionTime = current.CompletionTime
+
+		if record.CompletionTime == nil && migration.Phase == status.ExternalMigrationCompleted {
+			record.CompletionTime = &now
+		}
+
+		*current = record
+
+		return
+	}
+
+	record.StartTime = &now
+
+	if migration.Phase == status.ExternalMigrationCompleted {
+		record.CompletionTime = &now
+	}
+
+	w.source.Status.ExternalMigrations = append(w.source.Status.ExternalMigrations, record)
+}
+
+func (w *Adapter) ExternalMigrationProgress(service string) *gitlab.ExternalMigrationProgress {
+	for _, current := range w.source.Status.ExternalMigrations {
+		if current.Service != service {
+			continue
+		}
+
+		return &gitlab.ExternalMigrationProgress{
+			Service:          current.Service,
+			Phase:            current.Phase,
+			Message:          current.Message,
+			SourceCount:      current.SourceCount,
+			DestinationCount: current.DestinationCount,
+		}
+	}
+
+	return nil
+}
+
+func (w *Adapter) RecordTLSCertificates(certificates []gitlab.TLSCertificate) {
+	records := make([]api.TLSCertificateStatus, 0, len(certificates))
+
+	for _, certificate := range certificates {
+		record := api.TLSCertificateStatus{
+			Ingress:       certificate.Ingress,
+			Secret:        certificate.Secret,
+			Hosts:         certificate.Hosts,
+			DNSNames:      certificate.DNSNames,
+			Issuer:        certificate.Issuer,
+			Message:       certificate.Message,
+			LastCheckTime: metav1.NewTime(certificate.CheckedAt),
+		}
+
+		if !certificate.NotAfter.IsZero() {
+			notAfter := metav1.NewTime(certificate.NotAfter)
+			record.NotAfter = &notAfter
+		}
+
+		// Keep the check time while the outcome does not change, so that
+		// checking does not update the status on every reconcile.
+		if previous := w.tlsCertificateStatus(certificate.Ingress, certificate.Secret); previous != nil {
+			current := previous.DeepCopy()
+			current.LastCheckTime = record.LastCheckTime
+
+			if equality.Semantic.DeepEqual(*current, record) {
+				record.LastCheckTime = previous.LastCheckTime
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	w.source.Status.TLS = records
+}
+
+func (w *Adapter) TLSCertificate(ingress, secret string) *gitlab.TLSCertificate {
+	current := w.tlsCertificateStatus(ingress, secret)
+	if current == nil {
+		return nil
+	}
+
+	certificate := &gitlab.TLSCertificate{
+		Ingress:   current.Ingress,
+		Secret:    current.Secret,
+		Hosts:     current.Hosts,
+		DNSNames:  current.DNSNames,
+		Issuer:    current.Issuer,
+		Message:   current.Message,
+		CheckedAt: current.LastCheckTime.Time,
+	}
+
+	if current.NotAfter != nil {
+		certificate.NotAfter = current.NotAfter.Time
+	}
+
+	return certificate
+}
+
+func (w *Adapter) tlsCertificateStatus(ingress, secret string) *api.TLSCertificateStatus {
+	for i := range w.source.Status.TLS {
+		if w.source.Status.TLS[i].Ingress == ingress && w.source.Status.TLS[i].Secret == secret {
+			return &w.source.Status.TLS[i]
+		}
+	}
+
+	return nil
+}
+
+func (w *Adapter) RecordSidekiqScaling(scaling gitlab.SidekiqScaling) {
+	record := api.SidekiqScalingStatus{
+		Deployment:   scaling.Deployment,
+		Replicas:     scaling.Replicas,
+		QueueDepth:   scaling.QueueDepth,
+		QueueLatency: metav1.Duration{Duration: scaling.QueueLatency},
+		Message:      scaling.Message,
+	}
+
+	if !scaling.LastScaleTime.IsZero() {
+		lastScaleTime := metav1.NewTime(scaling.LastScaleTime)
+		record.LastScaleTime = &lastScaleTime
+	}
+
+	for i, current := range w.source.Status.SidekiqScaling {
+		if current.Deployment == scaling.Deployment {
+			w.source.Status.SidekiqScaling[i] = record
+			return
+		}
+	}
+
+	w.source.Status.SidekiqScaling = append(w.source.Status.SidekiqScaling, record)
+}
+
+func (w *Adapter) SidekiqScaling(deployment string) *gitlab.SidekiqScaling {
+	for _, current := range w.source.Status.SidekiqScaling {
+		if current.Deployment != deployment {
+			continue
+		}
+
+		scaling := &gitlab.SidekiqScaling{
+			Deployment:   current.Deployment,
+			Replicas:     current.Replicas,
+			QueueDepth:   current.QueueDepth,
+			QueueLatency: current.QueueLatency.Duration,
+			Message:      current.Message,
+		}
+
+		if current.LastScaleTime != nil {
+			scaling.LastScaleTime = current.LastScaleTime.Time
+		}
+
+		return scaling
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package v1beta1
+
+import (
+	"time"
+)
+
+const (
+	defaultCertificateExpiryWarningDays = 30
+)
+
+/* GitLabTLS */
+
+func (w *Adapter) CertificateExpiryWarning() time.Duration {
+	days := int32(defaultCertificateExpiryWarningDays)
+
+	if w.source.Spec.TLS != nil && w.source.Spec.TLS.ExpiryWarningDays > 0 {
+		days = w.source.Spec.TLS.ExpiryWarningDays
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,66 @@
+package v1beta1
+
+import (
+	"context"
+
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
+)
+
+const (
+	defaultCABundleKey = "ca.crt"
+)
+
+/* GitLabTrust */
+
+func (w *Adapter) CABundle() *gitlab.CABundleReference {
+	if w.source.Spec.Trust == nil || w.source.Spec.Trust.CABundleRef == nil {
+		return nil
+	}
+
+	spec := w.source.Spec.Trust.CABundleRef
+
+	bundle := &gitlab.CABundleReference{
+		Kind: spec.Kind,
+		Name: spec.Name,
+		Key:  spec.Key,
+	}
+
+	if bundle.Key == "" {
+		bundle.Key = defaultCABundleKey
+	}
+
+	return bundle
+}
+
+/* Helpers */
+
+// applyTrustValues adds the bundle of the custom certificate authorities to
+// the custom CAs of the chart, so that the certificates init containers of
+// the GitLab components add it to their system bundle. The custom CAs of the
+// user are kept.
+func (w *Adapter) applyTrustValues(_ context.Context) error {
+	bundle := w.CABundle()
+	if bundle == nil {
+		return nil
+	}
+
+	sourceKey := "configMap"
+	if bundle.InSecret() {
+		sourceKey = "secret"
+	}
+
+	customCAs := []interface{}{}
+
+	if current, err := w.values.GetValue("global.certificates.customCAs"); err == nil {
+		if current, ok := current.([]interface{}); ok {
+			customCAs = append(customCAs, current...)
+		}
+	}
+
+	customCAs = append(customCAs, map[string]interface{}{
+		sourceKey: bundle.Name,
+		"keys":    []interface{}{bundle.Key},
+	})
+
+	return w.values.SetValue("global.certificates.customCAs", customCAs)
+}
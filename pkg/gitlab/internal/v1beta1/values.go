@@ -1,9 +1,10 @@
 package v1beta1
 
 import (
-	_ "embed"
+	"embed"
 
 	"context"
+	"fmt"
 	"html/template"
 	"strings"
 
@@ -12,6 +13,7 @@ import (
 	"helm.sh/helm/v3/pkg/chartutil"
 
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/controllers/settings"
+	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/gitlab"
 	"gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/support"
 )
 
@@ -22,7 +24,21 @@ func (w *Adapter) Values() support.Values {
 }
 
 func (w *Adapter) Hash() string {
-	return support.SimpleObjectHash(w.source)
+	hash := support.SimpleObjectHash(w.source)
+
+	/*
+	 * Values from ConfigMaps and Secrets change without a new generation, and
+	 * so do the values of the external services once their migration completes
+	 * and the values of Gitaly and Praefect while the Gitaly token is rotated.
+	 */
+	versions := append(append([]string{}, w.valuesFromVersions...), w.completedExternalMigrations()...)
+	versions = append(versions, w.rotatingSecrets()...)
+
+	if hash == "" || len(versions) == 0 {
+		return hash
+	}
+
+	return fmt.Sprintf("%s-%s", hash, valuesFromHash(hash, versions))
 }
 
 /* Helpers */
@@ -30,8 +46,17 @@ func (w *Adapter) Hash() string {
 func (w *Adapter) populate(ctx context.Context) error {
 	return support.ChainedOperation{
 		w.applyOperatorDefaultValues,
+		w.applyProfileValues,
+		w.applyGeoValues,
+		w.applyNetworkingValues,
+		w.applyValuesFromReferences,
 		w.applyUserDefinedValues,
 		w.applyOperatorOverrideValues,
+		w.applyCertManagerValues,
+		w.applyTrustValues,
+		w.applyNetworkingOverrideValues,
+		w.applyExternalMigrationValues,
+		w.applySecretRotationValues,
 		w.applyChartDefaultValues, // it uses coalesce (set value if not present)
 	}.Run(ctx)
 }
@@ -77,6 +102,7 @@ func (w *Adapter) templateParameters() map[string]interface{} {
 	return map[string]interface{}{
 		"ReleaseName":    w.ReleaseName(),
 		"UseCertManager": w.WantsFeature(ConfigureCertManager),
+		"UseDNS01":       w.CertManagerDNS01() != nil,
 		"Settings":       appSettings,
 	}
 }
@@ -109,6 +135,10 @@ var defaultValuesTemplate *template.Template
 var overrideValuesSource string
 var overrideValuesTemplate *template.Template
 
+//go:embed profile-*.tpl
+var profileValuesSources embed.FS
+var profileValuesTemplates map[string]*template.Template
+
 func init() {
 	settings.Load()
 
@@ -123,4 +153,10 @@ func init() {
 
 	defaultValuesTemplate = template.Must(template.New("defaultValues").Parse(defaultValuesSource))
 	overrideValuesTemplate = template.Must(template.New("overrideValues").Parse(overrideValuesSource))
+
+	profileValuesTemplates = map[string]*template.Template{}
+
+	for _, profile := range []string{gitlab.ProfileMinimal, gitlab.Profile1K, gitlab.Profile3K, gitlab.Profile10K} {
+		profileValuesTemplates[profile] = template.Must(template.ParseFS(profileValuesSources, "profile-"+profile+".tpl"))
+	}
 }
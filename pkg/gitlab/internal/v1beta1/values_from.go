@@ -0,0 +1,132 @@
+package v1beta1
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "gitlab.com/gitlab-org/cloud-native/gitlab-operator/api/v1beta1"
+	rt "gitlab.com/gitlab-org/cloud-native/gitlab-operator/pkg/runtime"
+)
+
+const (
+	valuesKindConfigMap = "ConfigMap"
+	valuesKindSecret    = "Secret"
+
+	defaultValuesKey = "values.yaml"
+)
+
+/* Helpers */
+
+// applyValuesFromReferences merges the values of the referenced ConfigMaps
+// and Secrets in order. It records their resource versions so that the hash
+// of the values changes when their content changes.
+func (w *Adapter) applyValuesFromReferences(ctx context.Context) error {
+	references := w.source.Spec.Chart.ValuesFrom
+	if len(references) == 0 {
+		return nil
+	}
+
+	c := rt.ClientFromContext(ctx)
+	if c == nil {
+		return errors.New("can not read values from ConfigMaps and Secrets without a client")
+	}
+
+	for _, reference := range references {
+		content, version, err := w.readValuesReference(ctx, c, reference)
+		if err != nil {
+			return err
+		}
+
+		w.valuesFromVersions = append(w.valuesFromVersions,
+			fmt.Sprintf("%s/%s@%s", reference.Kind, reference.Name, version))
+
+		if content == nil {
+			continue
+		}
+
+		if reference.TargetPath != "" {
+			err = w.values.SetValue(reference.TargetPath, string(content))
+		} else {
+			err = w.values.AddFromYAMLBuffer(content)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "can not merge values from %s %s", reference.Kind, reference.Name)
+		}
+	}
+
+	return nil
+}
+
+// readValuesReference returns the content of the key and the resource version
+// of the referenced object. The content is nil when the object or the key of
+// an optional reference does not exist.
+func (w *Adapter) readValuesReference(ctx context.Context, c client.Client, reference api.ValuesReference) ([]byte, string, error) {
+	key := reference.ValuesKey
+	if key == "" {
+		key = defaultValuesKey
+	}
+
+	name := types.NamespacedName{
+		Namespace: w.source.Namespace,
+		Name:      reference.Name,
+	}
+
+	var (
+		object client.Object
+		data   map[string][]byte
+	)
+
+	switch reference.Kind {
+	case valuesKindConfigMap:
+		cm := &corev1.ConfigMap{}
+		object = cm
+		data = map[string][]byte{}
+
+		if err := c.Get(ctx, name, cm); err == nil {
+			for k, v := range cm.BinaryData {
+				data[k] = v
+			}
+
+			for k, v := range cm.Data {
+				data[k] = []byte(v)
+			}
+		} else if !apierrors.IsNotFound(err) || !reference.Optional {
+			return nil, "", errors.Wrapf(err, "can not read values from ConfigMap %s", reference.Name)
+		}
+	case valuesKindSecret:
+		secret := &corev1.Secret{}
+		object = secret
+
+		if err := c.Get(ctx, name, secret); err == nil {
+			data = secret.Data
+		} else if !apierrors.IsNotFound(err) || !reference.Optional {
+			return nil, "", errors.Wrapf(err, "can not read values from Secret %s", reference.Name)
+		}
+	default:
+		return nil, "", errors.Errorf("unsupported kind of values reference: %s", reference.Kind)
+	}
+
+	content, ok := data[key]
+	if !ok && !reference.Optional {
+		return nil, "", errors.Errorf("%s %s does not have the key %s", reference.Kind, reference.Name, key)
+	}
+
+	return content, object.GetResourceVersion(), nil
+}
+
+// valuesFromHash summarizes the hash of the resource and the resource
+// versions of the referenced ConfigMaps and Secrets.
+func valuesFromHash(objectHash string, versions []string) string {
+	sum := sha256.Sum256([]byte(objectHash + ";" + strings.Join(versions, ";")))
+
+	return fmt.Sprintf("%x", sum)[:10]
+}
@@ -0,0 +1,41 @@
+package gitlab
+
+const (
+	// NetworkingModeIngress exposes the instance with the Ingresses of the
+	// GitLab Chart.
+	NetworkingModeIngress = "Ingress"
+
+	// NetworkingModeRoute exposes the instance with OpenShift Routes that are
+	// converted from the Ingresses of the GitLab Chart.
+	NetworkingModeRoute = "Route"
+
+	// NetworkingModeGatewayAPI exposes the instance with Gateway API routes
+	// that are converted from the Ingresses of the GitLab Chart and attach to
+	// a Gateway.
+	NetworkingModeGatewayAPI = "GatewayAPI"
+)
+
+// Networking represents the settings of the underlying GitLab resource that
+// control how the instance is exposed.
+type Networking interface {
+	// NetworkingMode returns the kind of objects that expose the instance. It
+	// defaults to NetworkingModeIngress.
+	NetworkingMode() string
+
+	// NetworkingGateway returns the Gateway that the routes attach to in the
+	// NetworkingModeGatewayAPI mode, or nil when it is not specified.
+	NetworkingGateway() *GatewayReference
+
+	// WantsNetworkPolicies returns true when the Operator must generate the
+	// NetworkPolicies of the components.
+	WantsNetworkPolicies() bool
+}
+
+// GatewayReference describes the Gateway and its listeners that the routes
+// of the instance attach to.
+type GatewayReference struct {
+	Name         string
+	Namespace    string
+	HTTPListener string
+	SSHListener  string
+}
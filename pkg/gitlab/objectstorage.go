@@ -0,0 +1,9 @@
+package gitlab
+
+// ObjectStorage represents the settings of the underlying GitLab resource
+// that control how the external object storage is managed.
+type ObjectStorage interface {
+	// ProvisionsBuckets returns true when the Operator must create the
+	// configured buckets that do not exist.
+	ProvisionsBuckets() bool
+}
@@ -0,0 +1,24 @@
+package gitlab
+
+const (
+	// ProfileMinimal sizes the components for evaluation and development.
+	ProfileMinimal = "minimal"
+
+	// Profile1K sizes the components for up to 1,000 users.
+	Profile1K = "1k"
+
+	// Profile3K sizes the components for up to 3,000 users.
+	Profile3K = "3k"
+
+	// Profile10K sizes the components for up to 10,000 users.
+	Profile10K = "10k"
+)
+
+// Profile represents the settings of the underlying GitLab resource that
+// control the sizing of the components.
+type Profile interface {
+	// ResourceProfile returns the name of the resource profile of the
+	// instance, or an empty string when the components use the sizing of the
+	// chart.
+	ResourceProfile() string
+}
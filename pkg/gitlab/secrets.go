@@ -0,0 +1,37 @@
+package gitlab
+
+import (
+	"time"
+)
+
+// GitalyTokenSecret is the kind of the generated Gitaly token. It is the only
+// generated Secret that the Operator rotates, because Gitaly and Praefect can
+// accept the previous token while the new one is rolled out.
+const GitalyTokenSecret = "Gitaly"
+
+// Secrets represents the settings of the underlying GitLab resource that
+// control how the generated Secrets of the instance are managed.
+type Secrets interface {
+	// GeneratesSecretsNatively returns true when the Operator generates the
+	// shared Secrets and self-signed certificates itself instead of running
+	// the Jobs of the GitLab Chart.
+	GeneratesSecretsNatively() bool
+
+	// SecretRotations returns the list of generated Secrets that must be
+	// rotated periodically. It is empty when rotation is not configured.
+	SecretRotations() []SecretRotation
+
+	// SecretRotationOverlap returns the minimum period during which the
+	// servers of a rotated Secret accept the previous value after its clients
+	// start to roll.
+	SecretRotationOverlap() time.Duration
+}
+
+// SecretRotation describes the rotation schedule of a generated Secret.
+type SecretRotation struct {
+	// Secret is the kind of the generated Secret, for example `Gitaly`.
+	Secret string
+
+	// Interval is the maximum age of the Secret before it is regenerated.
+	Interval time.Duration
+}
@@ -1,6 +1,8 @@
 package gitlab
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -12,6 +14,169 @@ type Status interface {
 	// and adds it to the resource conditions.
 	SetCondition(condition metav1.Condition)
 
+	// Condition returns the recorded condition of the specified type, or nil
+	// when it is not recorded.
+	Condition(conditionType ConditionType) *metav1.Condition
+
 	// RecordVersion sets the status version to the specified (desired) version.
 	RecordVersion()
+
+	// RecordSecretRotation records the time when the specified generated
+	// Secret was last rotated and the phase of its rollout.
+	RecordSecretRotation(secret, name, phase string, rotatedAt time.Time)
+
+	// SecretRotationPhase returns the recorded phase of the rollout of the
+	// specified generated Secret. It is empty when no rotation is in progress.
+	SecretRotationPhase(secret string) string
+
+	// RecordRedisHealth records the health of an external Redis instance. The
+	// probe time is only updated when the health changes.
+	RecordRedisHealth(health RedisHealth)
+
+	// RecordVolumeExpansion records the progress of expanding a
+	// PersistentVolumeClaim. A completed expansion is only recorded when the
+	// expansion was recorded before.
+	RecordVolumeExpansion(expansion VolumeExpansion)
+
+	// VolumeExpansionInProgress returns true when at least one of the
+	// recorded volume expansions is neither completed nor unsupported.
+	VolumeExpansionInProgress() bool
+
+	// RecordGitalyRollout records the progress of the staged rollout of a
+	// Gitaly StatefulSet.
+	RecordGitalyRollout(rollout GitalyRollout)
+
+	// GitalyRolloutInProgress returns true when at least one of the recorded
+	// Gitaly rollouts is not completed.
+	GitalyRolloutInProgress() bool
+
+	// RecordGitalyMigration records the progress of the migration from the
+	// standalone Gitaly to Praefect. It clears the recorded progress when the
+	// migration is nil.
+	RecordGitalyMigration(migration *GitalyMigrationProgress)
+
+	// GitalyMigrationProgress returns the recorded progress of the migration
+	// from the standalone Gitaly to Praefect, or nil when it is not recorded.
+	GitalyMigrationProgress() *GitalyMigrationProgress
+
+	// RecordPostgreSQLUpgrade records the progress of the major version
+	// upgrade of the bundled PostgreSQL.
+	RecordPostgreSQLUpgrade(upgrade *PostgreSQLUpgrade)
+
+	// PostgreSQLUpgrade returns the recorded progress of the major version
+	// upgrade of the bundled PostgreSQL, or nil when it is not recorded.
+	PostgreSQLUpgrade() *PostgreSQLUpgrade
+
+	// RecordExternalMigration records the progress of moving the data of a
+	// bundled service to an external service.
+	RecordExternalMigration(migration ExternalMigrationProgress)
+
+	// ExternalMigrationProgress returns the recorded progress of moving the
+	// data of the bundled service to an external service, or nil when it is
+	// not recorded.
+	ExternalMigrationProgress(service string) *ExternalMigrationProgress
+
+	// RecordTLSCertificates replaces the recorded TLS certificates of the
+	// Ingresses. The check time of a certificate is kept while the outcome of
+	// its check does not change.
+	RecordTLSCertificates(certificates []TLSCertificate)
+
+	// TLSCertificate returns the recorded TLS certificate of the Ingress in
+	// the Secret, or nil when it is not recorded.
+	TLSCertificate(ingress, secret string) *TLSCertificate
+
+	// RecordSidekiqScaling records the queue-based scaling of a Sidekiq
+	// Deployment.
+	RecordSidekiqScaling(scaling SidekiqScaling)
+
+	// SidekiqScaling returns the recorded queue-based scaling of the Sidekiq
+	// Deployment, or nil when it is not recorded.
+	SidekiqScaling(deployment string) *SidekiqScaling
+}
+
+// RedisHealth is the outcome of probing an external Redis instance.
+type RedisHealth struct {
+	Instance string
+	Address  string
+	Version  string
+	Ready    bool
+	Message  string
+	ProbedAt time.Time
+}
+
+// VolumeExpansion is the progress of expanding a PersistentVolumeClaim.
+type VolumeExpansion struct {
+	Workload string
+	Claim    string
+	Size     string
+	Phase    string
+	Message  string
+}
+
+// GitalyRollout is the progress of the staged rollout of a Gitaly StatefulSet.
+type GitalyRollout struct {
+	StatefulSet     string
+	Partition       int32
+	UpdatedReplicas int32
+	Replicas        int32
+	Phase           string
+	Message         string
+}
+
+// GitalyMigrationProgress is the progress of the migration from the
+// standalone Gitaly to Praefect.
+type GitalyMigrationProgress struct {
+	SourceStorage      string
+	DestinationStorage string
+	Phase              string
+	Attempt            int32
+	Remaining          int64
+	InProgress         int64
+	Failed             int64
+	Message            string
+	LastCheckTime      time.Time
+}
+
+// PostgreSQLUpgrade is the progress of the major version upgrade of the
+// bundled PostgreSQL.
+type PostgreSQLUpgrade struct {
+	FromVersion    int
+	ToVersion      int
+	FromImage      string
+	Phase          string
+	Message        string
+	RetainedVolume string
+}
+
+// ExternalMigrationProgress is the progress of moving the data of a bundled
+// service to an external service.
+type ExternalMigrationProgress struct {
+	Service          string
+	Phase            string
+	Message          string
+	SourceCount      int64
+	DestinationCount int64
+}
+
+// TLSCertificate is the outcome of checking the TLS certificate of an
+// Ingress.
+type TLSCertificate struct {
+	Ingress   string
+	Secret    string
+	Hosts     []string
+	DNSNames  []string
+	Issuer    string
+	NotAfter  time.Time
+	Message   string
+	CheckedAt time.Time
+}
+
+// SidekiqScaling is the queue-based scaling of a Sidekiq Deployment.
+type SidekiqScaling struct {
+	Deployment    string
+	Replicas      int32
+	QueueDepth    int64
+	QueueLatency  time.Duration
+	Message       string
+	LastScaleTime time.Time
 }
@@ -8,9 +8,79 @@ const (
 	ConditionInitialized gitlab.ConditionType = "Initialized"
 	ConditionUpgrading   gitlab.ConditionType = "Upgrading"
 	ConditionAvailable   gitlab.ConditionType = "Available"
+
+	ConditionDatabaseReady         gitlab.ConditionType = "DatabaseReady"
+	ConditionRedisReady            gitlab.ConditionType = "RedisReady"
+	ConditionObjectStorageReady    gitlab.ConditionType = "ObjectStorageReady"
+	ConditionPraefectDatabaseReady gitlab.ConditionType = "PraefectDatabaseReady"
+	ConditionCertificateExpiring   gitlab.ConditionType = "CertificateExpiring"
+
+	ConditionVolumeExpansionPermitted gitlab.ConditionType = "VolumeExpansionPermitted"
 )
 
 const (
 	PhasePreparing = "Preparing"
 	PhaseRunning   = "Running"
 )
+
+const (
+	SecretRotationRollingServers = "RollingServers"
+	SecretRotationRollingClients = "RollingClients"
+)
+
+const (
+	VolumeExpansionPending                 = "Pending"
+	VolumeExpansionResizing                = "Resizing"
+	VolumeExpansionFileSystemResizePending = "FileSystemResizePending"
+	VolumeExpansionCompleted               = "Completed"
+	VolumeExpansionUnsupported             = "Unsupported"
+)
+
+const (
+	GitalyRolloutPending    = "Pending"
+	GitalyRolloutRollingOut = "RollingOut"
+	GitalyRolloutSoaking    = "Soaking"
+	GitalyRolloutCompleted  = "Completed"
+)
+
+const (
+	GitalyMigrationPending   = "Pending"
+	GitalyMigrationMoving    = "Moving"
+	GitalyMigrationCompleted = "Completed"
+	GitalyMigrationFailed    = "Failed"
+)
+
+const (
+	PostgreSQLUpgradeBackingUp = "BackingUp"
+	PostgreSQLUpgradeReplacing = "Replacing"
+	PostgreSQLUpgradeRestoring = "Restoring"
+	PostgreSQLUpgradeCompleted = "Completed"
+	PostgreSQLUpgradeFailed    = "Failed"
+)
+
+const (
+	ExternalMigrationMigrating = "Migrating"
+	ExternalMigrationCompleted = "Completed"
+	ExternalMigrationFailed    = "Failed"
+)
+
+const (
+	ExternalMigrationPostgreSQL = "PostgreSQL"
+	ExternalMigrationRedis      = "Redis"
+)
+
+const (
+	RunnerPending     = "Pending"
+	RunnerRegistering = "Registering"
+	RunnerRunning     = "Running"
+	RunnerFailed      = "Failed"
+)
+
+const (
+	ConditionRunnerRegistered gitlab.ConditionType = "Registered"
+)
+
+const (
+	ConditionGeoTrackingDatabaseReady gitlab.ConditionType = "GeoTrackingDatabaseReady"
+	ConditionGeoReplicationReady      gitlab.ConditionType = "GeoReplicationReady"
+)
@@ -0,0 +1,13 @@
+package gitlab
+
+import (
+	"time"
+)
+
+// TLS represents the settings of the underlying GitLab resource that control
+// the monitoring of the TLS certificates of the Ingresses.
+type TLS interface {
+	// CertificateExpiryWarning returns how long before the expiry of a
+	// certificate it is reported as expiring.
+	CertificateExpiryWarning() time.Duration
+}
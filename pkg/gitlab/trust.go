@@ -0,0 +1,35 @@
+package gitlab
+
+const (
+	// CABundleKindConfigMap is a bundle in a ConfigMap.
+	CABundleKindConfigMap = "ConfigMap"
+
+	// CABundleKindSecret is a bundle in a Secret.
+	CABundleKindSecret = "Secret"
+
+	// CABundleKindBundle is a trust-manager Bundle.
+	CABundleKindBundle = "Bundle"
+)
+
+// Trust represents the settings of the underlying GitLab resource that
+// control the custom certificate authorities of the components.
+type Trust interface {
+	// CABundle returns the bundle of the custom certificate authorities, or
+	// nil when it is not specified.
+	CABundle() *CABundleReference
+}
+
+// CABundleReference describes the object that contains a bundle of
+// certificate authorities.
+type CABundleReference struct {
+	Kind string
+	Name string
+	Key  string
+}
+
+// InSecret returns true when the bundle is in a Secret. Otherwise it is in a
+// ConfigMap, including the ConfigMap that trust-manager writes a Bundle to,
+// which has the name of the Bundle.
+func (r CABundleReference) InSecret() bool {
+	return r.Kind == CABundleKindSecret
+}
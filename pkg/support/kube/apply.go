@@ -3,21 +3,26 @@ package kube
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
 	"k8s.io/apimachinery/pkg/util/mergepatch"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubectl/pkg/util"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 // ApplyOutcome is the action result of ApplyObject call.
@@ -32,8 +37,31 @@ const (
 
 	// ObjectUpdated indicates that changes are applied to the existing resource.
 	ObjectUpdated ApplyOutcome = "updated"
+
+	// ObjectDeleted indicates that the existing resource is deleted because
+	// the changes modify its immutable fields. It is created again when it is
+	// applied after it is removed.
+	ObjectDeleted ApplyOutcome = "deleted"
 )
 
+// RecreatePolicy lists the kinds of objects that can be deleted and created
+// again when a patch is rejected because it changes immutable fields. It maps
+// each kind to the propagation policy that is used for deleting the object.
+type RecreatePolicy map[schema.GroupKind]metav1.DeletionPropagation
+
+// DefaultRecreatePolicy recreates StatefulSets and Services. StatefulSets
+// are deleted without their Pods, which are adopted by the new StatefulSet.
+var DefaultRecreatePolicy = RecreatePolicy{
+	{Group: "apps", Kind: "StatefulSet"}: metav1.DeletePropagationOrphan,
+	{Group: "", Kind: "Service"}:         metav1.DeletePropagationBackground,
+}
+
+// JobRecreatePolicy recreates Jobs together with their Pods. Jobs that are
+// still running are not deleted.
+var JobRecreatePolicy = RecreatePolicy{
+	{Group: "batch", Kind: "Job"}: metav1.DeletePropagationBackground,
+}
+
 // ApplyConfig is the configuration that is used for applying object changes.
 //
 // The configuration requires a Kubernetes API Client to work properly. You can
@@ -45,6 +73,7 @@ type ApplyConfig struct {
 	Context   context.Context
 	Logger    logr.Logger
 	Overwrite bool
+	Recreate  RecreatePolicy
 	Scheme    *runtime.Scheme
 
 	object client.Object
@@ -58,6 +87,7 @@ type ApplyConfig struct {
 //   - WithContext
 //   - WithLogger
 //   - WithManager
+//   - WithRecreatePolicy
 //   - WithScheme
 //
 // See each option for further details.
@@ -70,6 +100,11 @@ type ApplyOption = func(*ApplyConfig)
 // It annotates objects with the last configuration that was used to create or
 // update them with the same annotation that `kubectl apply` uses.
 //
+// When the patch is rejected because it changes immutable fields and the
+// RecreatePolicy includes the kind of the object, the object is deleted. It
+// does not wait until the object is removed: the object is created again when
+// it is applied after it is removed. A Job is not deleted while it has active Pods.
+//
 // It returns the executed operation and an error.
 func ApplyObject(object client.Object, options ...ApplyOption) (ApplyOutcome, error) {
 	cfg := defaultApplyConfig(object)
@@ -145,11 +180,31 @@ func (c *ApplyConfig) apply() (ApplyOutcome, error) {
 		}
 	case err != nil:
 		err = c.wrapObjectError(err, "failed to obtain current configuration")
+	case c.isRecreating():
+		/* Wait until the deleted object is removed before creating it again. */
+		c.Logger.Info("object is being deleted, it is created again once it is removed")
 	case err == nil:
 		/* Patch the existing object. */
-		patched, err := c.patch(modified)
-		if err == nil && patched {
+		var patched bool
+
+		patched, err = c.patch(modified)
+
+		switch {
+		case err == nil && patched:
 			outcome = ObjectUpdated
+		case err != nil && isImmutableFieldError(err):
+			propagation, ok := c.recreatePropagation()
+			if !ok {
+				break
+			}
+
+			c.Logger.Info("object can not be patched because of immutable fields, deleting it",
+				"error", err.Error())
+
+			err = c.delete(propagation)
+			if err == nil {
+				outcome = ObjectDeleted
+			}
 		}
 	}
 
@@ -172,6 +227,71 @@ func (c *ApplyConfig) create() error {
 	return nil
 }
 
+func (c *ApplyConfig) recreatePropagation() (metav1.DeletionPropagation, bool) {
+	gvk, err := apiutil.GVKForObject(c.object, c.Scheme)
+	if err != nil {
+		return "", false
+	}
+
+	propagation, ok := c.Recreate[gvk.GroupKind()]
+
+	return propagation, ok
+}
+
+// isRecreating returns true when the current object is being deleted and the
+// RecreatePolicy includes its kind.
+func (c *ApplyConfig) isRecreating() bool {
+	if c.object.GetDeletionTimestamp() == nil {
+		return false
+	}
+
+	_, ok := c.recreatePropagation()
+
+	return ok
+}
+
+// delete removes the current object so that it can be created again. A Job
+// that is still running is not deleted.
+func (c *ApplyConfig) delete(propagation metav1.DeletionPropagation) error {
+	active, err := c.isActiveJob()
+	if err != nil {
+		return c.wrapObjectError(err, "failed to read job status")
+	}
+
+	if active {
+		return c.wrapObjectError(errors.New("job is still running"), "failed to recreate object")
+	}
+
+	if err := c.Client.Delete(c.Context, c.object, &client.DeleteOptions{
+		PropagationPolicy: &propagation,
+		Preconditions:     metav1.NewUIDPreconditions(string(c.object.GetUID())),
+	}); err != nil && !kerrors.IsNotFound(err) {
+		return c.wrapObjectError(err, "failed to delete object")
+	}
+
+	return nil
+}
+
+// isActiveJob returns true when the current object is a Job with active Pods.
+func (c *ApplyConfig) isActiveJob() (bool, error) {
+	gvk, err := apiutil.GVKForObject(c.object, c.Scheme)
+	if err != nil || gvk.GroupKind() != (schema.GroupKind{Group: "batch", Kind: "Job"}) {
+		return false, nil
+	}
+
+	current, err := runtime.DefaultUnstructuredConverter.ToUnstructured(c.object)
+	if err != nil {
+		return false, err
+	}
+
+	active, _, err := unstructured.NestedInt64(current, "status", "active")
+	if err != nil {
+		return false, err
+	}
+
+	return active > 0, nil
+}
+
 func (c *ApplyConfig) patch(modified []byte) (bool, error) {
 	c.Logger.V(2).Info("object exists, patching it")
 
@@ -294,6 +414,36 @@ func (c *ApplyConfig) wrapObjectError(err error, msg string) error {
 
 /* Private */
 
+// isImmutableFieldError returns true when the API server rejects a patch
+// because it modifies fields that can not be changed once they are set, for
+// example the selector of a Job, the volume claim templates of a StatefulSet
+// (Forbidden) or the cluster IP of a Service (may not change once set).
+func isImmutableFieldError(err error) bool {
+	if !kerrors.IsInvalid(err) {
+		return false
+	}
+
+	var statusErr kerrors.APIStatus
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+
+	details := statusErr.Status().Details
+	if details == nil {
+		return false
+	}
+
+	for _, cause := range details.Causes {
+		if cause.Type == metav1.CauseType(field.ErrorTypeForbidden) ||
+			strings.Contains(cause.Message, "field is immutable") ||
+			strings.Contains(cause.Message, "may not change once set") {
+			return true
+		}
+	}
+
+	return false
+}
+
 func ignoreMetadataKey(keys ...string) mergepatch.PreconditionFunc {
 	return func(patch interface{}) bool {
 		patchMap, ok := patch.(map[string]interface{})
@@ -64,6 +64,17 @@ func WithLogger(logger logr.Logger) kube.ApplyOption {
 	}
 }
 
+// WithRecreatePolicy configures apply to delete and create the object again
+// when the patch is rejected because it changes immutable fields, but only for
+// the kinds that are listed in the policy.
+//
+// By default objects are not recreated.
+func WithRecreatePolicy(policy kube.RecreatePolicy) kube.ApplyOption {
+	return func(cfg *kube.ApplyConfig) {
+		cfg.Recreate = policy
+	}
+}
+
 // WithScheme configures apply with the specified scheme for looking up Go types
 // from resource kind and API version.
 //
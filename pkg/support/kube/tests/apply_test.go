@@ -1,6 +1,8 @@
 package kubetests
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -183,6 +185,138 @@ var _ = Describe("ApplyObject", func() {
 		Eventually(DeleteObject(obj)).Should(Succeed())
 	})
 
+	It("recreates the object when its immutable fields are changed", func() {
+		obj := ReadObject("apply/job-recreate-1")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager)),
+		).To(Equal(kube.ObjectCreated))
+
+		/* wait for the change to be populated */
+		j := &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		Eventually(GetObject(j)).Should(Succeed())
+		uid := j.UID
+
+		obj = ReadObject("apply/job-recreate-2")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager),
+				apply.WithRecreatePolicy(kube.JobRecreatePolicy)),
+		).To(Equal(kube.ObjectDeleted))
+
+		/* the object is created again once it is removed */
+		Eventually(GetObject(j)).ShouldNot(Succeed())
+
+		obj = ReadObject("apply/job-recreate-2")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager),
+				apply.WithRecreatePolicy(kube.JobRecreatePolicy)),
+		).To(Equal(kube.ObjectCreated))
+
+		j = &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		Eventually(GetObject(j)).Should(Succeed())
+
+		Expect(j.UID).NotTo(Equal(uid))
+		Expect(j.Spec.Template.Spec.Containers[0].Image).To(Equal("perl:5.36"))
+
+		Eventually(DeleteObject(j)).Should(Succeed())
+	})
+
+	It("recreates a Service when its cluster IP is changed", func() {
+		obj := ReadObject("apply/service-recreate-1")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager)),
+		).To(Equal(kube.ObjectCreated))
+
+		/* wait for the change to be populated */
+		svc := &corev1.Service{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		Eventually(GetObject(svc)).Should(Succeed())
+
+		obj = ReadObject("apply/service-recreate-2")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager),
+				apply.WithRecreatePolicy(kube.DefaultRecreatePolicy)),
+		).To(Equal(kube.ObjectDeleted))
+
+		Eventually(GetObject(svc)).ShouldNot(Succeed())
+
+		obj = ReadObject("apply/service-recreate-2")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager),
+				apply.WithRecreatePolicy(kube.DefaultRecreatePolicy)),
+		).To(Equal(kube.ObjectCreated))
+
+		Eventually(GetObject(svc)).Should(Succeed())
+		Expect(svc.Spec.ClusterIP).To(Equal("10.0.0.120"))
+
+		Eventually(DeleteObject(svc)).Should(Succeed())
+	})
+
+	It("does not recreate the object without a recreate policy", func() {
+		obj := ReadObject("apply/job-keep-1")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager)),
+		).To(Equal(kube.ObjectCreated))
+
+		/* wait for the change to be populated */
+		j := &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		Eventually(GetObject(j)).Should(Succeed())
+
+		obj = ReadObject("apply/job-keep-2")
+		_, err := kube.ApplyObject(obj, apply.WithManager(Manager))
+		Expect(err).To(HaveOccurred())
+
+		Eventually(DeleteObject(j)).Should(Succeed())
+	})
+
+	It("does not recreate a Job that is still running", func() {
+		obj := ReadObject("apply/job-running-1")
+		Expect(
+			kube.ApplyObject(obj, apply.WithManager(Manager)),
+		).To(Equal(kube.ObjectCreated))
+
+		/* wait for the change to be populated */
+		j := &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		Eventually(GetObject(j)).Should(Succeed())
+		uid := j.UID
+
+		j.Status.Active = 1
+		Expect(Manager.GetClient().Status().Update(context.TODO(), j)).To(Succeed())
+
+		obj = ReadObject("apply/job-running-2")
+		_, err := kube.ApplyObject(obj, apply.WithManager(Manager),
+			apply.WithRecreatePolicy(kube.JobRecreatePolicy))
+		Expect(err).To(MatchError(ContainSubstring("job is still running")))
+
+		Eventually(GetObject(j)).Should(Succeed())
+		Expect(j.UID).To(Equal(uid))
+
+		Eventually(DeleteObject(j)).Should(Succeed())
+	})
+
 	/*
 	 * Testing unregistered types has proven to be difficult here. These types
 	 * must be recognized by the mock Kubernetes API Server but not registered
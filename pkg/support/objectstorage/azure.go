@@ -0,0 +1,171 @@
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAzureStorageDomain = "core.windows.net"
+	azureStorageVersion       = "2021-08-06"
+)
+
+// azureClient talks to Azure Blob Storage and signs the requests with the
+// Shared Key of the storage account.
+type azureClient struct {
+	baseURL string
+	account string
+	key     []byte
+	client  *http.Client
+	now     func() time.Time
+}
+
+func newAzureClient(connection *Connection) (*azureClient, error) {
+	key, err := base64.StdEncoding.DecodeString(connection.AzureStorageAccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("can not decode azure_storage_access_key: %w", err)
+	}
+
+	domain := connection.AzureStorageDomain
+	if domain == "" {
+		domain = defaultAzureStorageDomain
+	}
+
+	return &azureClient{
+		baseURL: fmt.Sprintf("https://%s.blob.%s", connection.AzureStorageAccountName, domain),
+		account: connection.AzureStorageAccountName,
+		key:     key,
+		client:  newHTTPClient(),
+		now:     time.Now,
+	}, nil
+}
+
+func (c *azureClient) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	response, err := c.do(ctx, http.MethodHead, "/"+bucket, url.Values{"restype": {"container"}}, nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return false, checkResponse(response, http.StatusNotFound)
+	}
+
+	return true, checkResponse(response, http.StatusOK)
+}
+
+func (c *azureClient) CreateBucket(ctx context.Context, bucket string) error {
+	response, err := c.do(ctx, http.MethodPut, "/"+bucket, url.Values{"restype": {"container"}}, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(response, http.StatusCreated)
+}
+
+func (c *azureClient) CheckWritable(ctx context.Context, bucket string) error {
+	path := "/" + bucket + "/" + writeCheckObject
+
+	response, err := c.do(ctx, http.MethodPut, path, nil, map[string]string{"x-ms-blob-type": "BlockBlob"}, []byte{})
+	if err != nil {
+		return err
+	}
+
+	if err := checkResponse(response, http.StatusCreated); err != nil {
+		return err
+	}
+
+	response, err = c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(response, http.StatusAccepted)
+}
+
+func (c *azureClient) do(ctx context.Context, method, path string, query url.Values, headers map[string]string, body []byte) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("x-ms-date", c.now().UTC().Format(http.TimeFormat))
+	request.Header.Set("x-ms-version", azureStorageVersion)
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	request.Header.Set("Authorization", "SharedKey "+c.account+":"+c.signature(request, path, query, len(body)))
+
+	return c.client.Do(request)
+}
+
+// signature computes the Shared Key signature of the request.
+func (c *azureClient) signature(request *http.Request, path string, query url.Values, contentLength int) string {
+	length := ""
+	if contentLength > 0 {
+		length = strconv.Itoa(contentLength)
+	}
+
+	msHeaders := []string{}
+
+	for name := range request.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower+":"+strings.TrimSpace(request.Header.Get(name)))
+		}
+	}
+
+	sort.Strings(msHeaders)
+
+	resource := "/" + c.account + path
+
+	queryKeys := make([]string, 0, len(query))
+	for k := range query {
+		queryKeys = append(queryKeys, k)
+	}
+
+	sort.Strings(queryKeys)
+
+	for _, k := range queryKeys {
+		values := query[k]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(k) + ":" + strings.Join(values, ",")
+	}
+
+	stringToSign := strings.Join([]string{
+		request.Method,
+		"", // Content-Encoding
+		"", // Content-Language
+		length,
+		"", // Content-MD5
+		request.Header.Get("Content-Type"),
+		"", // Date
+		"", // If-Modified-Since
+		"", // If-Match
+		"", // If-None-Match
+		"", // If-Unmodified-Since
+		"", // Range
+		strings.Join(msHeaders, "\n"),
+		resource,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(stringToSign))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
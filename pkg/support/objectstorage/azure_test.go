@@ -0,0 +1,91 @@
+package objectstorage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Azure client", func() {
+	var (
+		mutex    sync.Mutex
+		requests []*http.Request
+		client   *azureClient
+		ctx      = context.Background()
+	)
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			requests = append(requests, r)
+
+			switch {
+			case r.Method == http.MethodHead && r.URL.Path == "/gitlab-uploads":
+				w.WriteHeader(http.StatusNotFound)
+			case r.Method == http.MethodPut:
+				w.WriteHeader(http.StatusCreated)
+			case r.Method == http.MethodDelete:
+				w.WriteHeader(http.StatusAccepted)
+			}
+		}))
+		DeferCleanup(server.Close)
+
+		var err error
+
+		client, err = newAzureClient(&Connection{
+			Provider:                ProviderAzure,
+			AzureStorageAccountName: "gitlab",
+			AzureStorageAccessKey:   "c2VjcmV0",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(client.baseURL).To(Equal("https://gitlab.blob.core.windows.net"))
+
+		client.baseURL = server.URL
+		client.now = func() time.Time {
+			return time.Date(2023, time.September, 1, 10, 0, 0, 0, time.UTC)
+		}
+	})
+
+	It("checks, creates, and writes containers", func() {
+		Expect(client.BucketExists(ctx, "gitlab-artifacts")).To(BeTrue())
+		Expect(client.BucketExists(ctx, "gitlab-uploads")).To(BeFalse())
+		Expect(client.CreateBucket(ctx, "gitlab-uploads")).To(Succeed())
+		Expect(client.CheckWritable(ctx, "gitlab-artifacts")).To(Succeed())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		Expect(requests).To(HaveLen(5))
+		Expect(requests[3].URL.Path).To(Equal("/gitlab-artifacts/" + writeCheckObject))
+		Expect(requests[3].Header.Get("x-ms-blob-type")).To(Equal("BlockBlob"))
+
+		for _, r := range requests {
+			Expect(r.Header.Get("x-ms-date")).To(Equal("Fri, 01 Sep 2023 10:00:00 GMT"))
+			Expect(strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey gitlab:")).To(BeTrue())
+		}
+	})
+
+	It("signs the canonicalized resource with the query parameters", func() {
+		request, err := http.NewRequest(http.MethodHead, "https://gitlab.blob.core.windows.net/gitlab-lfs?restype=container", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		request.Header.Set("x-ms-date", "Fri, 01 Sep 2023 10:00:00 GMT")
+		request.Header.Set("x-ms-version", azureStorageVersion)
+
+		signature := client.signature(request, "/gitlab-lfs", request.URL.Query(), 0)
+
+		request.URL.RawQuery = ""
+		Expect(client.signature(request, "/gitlab-lfs", nil, 0)).NotTo(Equal(signature))
+	})
+})
@@ -0,0 +1,125 @@
+// Package objectstorage provides minimal clients of the object storage
+// services that GitLab supports. The clients can check and create buckets
+// using the fog connection settings that GitLab consumes.
+package objectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Supported fog providers.
+	ProviderAWS    = "AWS"
+	ProviderGoogle = "Google"
+	ProviderAzure  = "AzureRM"
+
+	// writeCheckObject is the name of the object that is written and deleted
+	// to check that a bucket is writable.
+	writeCheckObject = ".gitlab-operator-write-check"
+)
+
+// ErrUnsupportedCredentials is returned when the connection uses credentials
+// that are only available inside the GitLab Pods, for example IAM instance
+// profiles or the Google application default credentials.
+var ErrUnsupportedCredentials = errors.New("credentials are not supported for validation")
+
+// Connection is the fog connection of an object storage provider as it is
+// stored in the connection Secrets of GitLab.
+type Connection struct {
+	Provider string `json:"provider"`
+
+	// AWS and S3-compatible settings.
+	Region             string `json:"region,omitempty"`
+	AWSAccessKeyID     string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty"`
+	UseIAMProfile      bool   `json:"use_iam_profile,omitempty"`
+	Endpoint           string `json:"endpoint,omitempty"`
+	PathStyle          bool   `json:"path_style,omitempty"`
+
+	// Google Cloud Storage settings.
+	GoogleProject            string `json:"google_project,omitempty"`
+	GoogleClientEmail        string `json:"google_client_email,omitempty"`
+	GoogleJSONKeyString      string `json:"google_json_key_string,omitempty"`
+	GoogleJSONKeyLocation    string `json:"google_json_key_location,omitempty"`
+	GoogleApplicationDefault bool   `json:"google_application_default,omitempty"`
+
+	// Azure Blob Storage settings.
+	AzureStorageAccountName string `json:"azure_storage_account_name,omitempty"`
+	AzureStorageAccessKey   string `json:"azure_storage_access_key,omitempty"`
+	AzureStorageDomain      string `json:"azure_storage_domain,omitempty"`
+}
+
+// ParseConnection parses the YAML connection settings and checks that the
+// settings that the provider requires are present.
+func ParseConnection(data []byte) (*Connection, error) {
+	connection := &Connection{}
+
+	if err := yaml.Unmarshal(data, connection); err != nil {
+		return nil, fmt.Errorf("can not parse connection: %w", err)
+	}
+
+	if err := connection.validate(); err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (c *Connection) validate() error {
+	switch c.Provider {
+	case ProviderAWS:
+		if !c.UseIAMProfile && (c.AWSAccessKeyID == "" || c.AWSSecretAccessKey == "") {
+			return fmt.Errorf("aws_access_key_id and aws_secret_access_key are required unless use_iam_profile is set")
+		}
+	case ProviderGoogle:
+		if c.GoogleProject == "" {
+			return fmt.Errorf("google_project is required")
+		}
+
+		if c.GoogleJSONKeyString == "" && c.GoogleJSONKeyLocation == "" && !c.GoogleApplicationDefault {
+			return fmt.Errorf("one of google_json_key_string, google_json_key_location, or google_application_default is required")
+		}
+	case ProviderAzure:
+		if c.AzureStorageAccountName == "" || c.AzureStorageAccessKey == "" {
+			return fmt.Errorf("azure_storage_account_name and azure_storage_access_key are required")
+		}
+	case "":
+		return fmt.Errorf("provider is required")
+	default:
+		return fmt.Errorf("unsupported provider %q", c.Provider)
+	}
+
+	return nil
+}
+
+// Client checks and creates buckets of an object storage provider.
+type Client interface {
+	// BucketExists checks if the bucket exists and is accessible.
+	BucketExists(ctx context.Context, bucket string) (bool, error)
+
+	// CreateBucket creates the bucket.
+	CreateBucket(ctx context.Context, bucket string) error
+
+	// CheckWritable writes an empty object to the bucket and deletes it.
+	CheckWritable(ctx context.Context, bucket string) error
+}
+
+// NewClient returns a client for the provider of the connection. It returns
+// ErrUnsupportedCredentials when the credentials of the connection can not
+// be used outside of the GitLab Pods.
+func NewClient(ctx context.Context, connection *Connection) (Client, error) {
+	switch connection.Provider {
+	case ProviderAWS:
+		return newS3Client(connection)
+	case ProviderGoogle:
+		return newGCSClient(ctx, connection)
+	case ProviderAzure:
+		return newAzureClient(connection)
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", connection.Provider)
+	}
+}
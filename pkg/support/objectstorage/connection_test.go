@@ -0,0 +1,78 @@
+package objectstorage
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection", func() {
+	It("parses S3 connections", func() {
+		connection, err := ParseConnection([]byte(`
+provider: AWS
+region: eu-central-1
+aws_access_key_id: access-key
+aws_secret_access_key: secret-key
+endpoint: http://minio.example.com:9000
+path_style: true
+`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(connection.Provider).To(Equal(ProviderAWS))
+		Expect(connection.Region).To(Equal("eu-central-1"))
+		Expect(connection.Endpoint).To(Equal("http://minio.example.com:9000"))
+		Expect(connection.PathStyle).To(BeTrue())
+	})
+
+	It("parses GCS connections", func() {
+		connection, err := ParseConnection([]byte(`
+provider: Google
+google_project: gitlab
+google_client_email: gitlab@gitlab.iam.gserviceaccount.com
+google_json_key_string: '{"type": "service_account"}'
+`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(connection.GoogleProject).To(Equal("gitlab"))
+		Expect(connection.GoogleJSONKeyString).To(Equal(`{"type": "service_account"}`))
+	})
+
+	It("parses Azure connections", func() {
+		connection, err := ParseConnection([]byte(`
+provider: AzureRM
+azure_storage_account_name: gitlab
+azure_storage_access_key: c2VjcmV0
+azure_storage_domain: blob.core.windows.net
+`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(connection.AzureStorageAccountName).To(Equal("gitlab"))
+		Expect(connection.AzureStorageAccessKey).To(Equal("c2VjcmV0"))
+	})
+
+	It("rejects incomplete connections", func() {
+		_, err := ParseConnection([]byte("provider: AWS\nregion: us-east-1\n"))
+		Expect(err).To(MatchError(ContainSubstring("aws_access_key_id")))
+
+		_, err = ParseConnection([]byte("provider: Google\ngoogle_project: gitlab\n"))
+		Expect(err).To(MatchError(ContainSubstring("google_json_key_string")))
+
+		_, err = ParseConnection([]byte("provider: AzureRM\n"))
+		Expect(err).To(MatchError(ContainSubstring("azure_storage_account_name")))
+
+		_, err = ParseConnection([]byte("provider: Rackspace\n"))
+		Expect(err).To(MatchError(ContainSubstring("unsupported provider")))
+
+		_, err = ParseConnection([]byte("region: us-east-1\n"))
+		Expect(err).To(MatchError(ContainSubstring("provider is required")))
+	})
+
+	It("does not support credentials that are only available to GitLab", func() {
+		_, err := NewClient(context.Background(), &Connection{Provider: ProviderAWS, UseIAMProfile: true})
+		Expect(err).To(MatchError(ErrUnsupportedCredentials))
+
+		_, err = NewClient(context.Background(), &Connection{Provider: ProviderGoogle, GoogleApplicationDefault: true})
+		Expect(err).To(MatchError(ErrUnsupportedCredentials))
+	})
+})
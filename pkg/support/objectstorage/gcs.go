@@ -0,0 +1,140 @@
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	gcsBaseURL    = "https://storage.googleapis.com"
+	gcsTokenURL   = "https://oauth2.googleapis.com/token"
+	gcsScope      = "https://www.googleapis.com/auth/devstorage.read_write"
+	gcsBucketPath = "/storage/v1/b"
+)
+
+// gcsServiceAccountKey is the subset of a Google service account JSON key
+// that is needed to request access tokens.
+type gcsServiceAccountKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// gcsClient talks to the JSON API of Google Cloud Storage with a service
+// account key.
+type gcsClient struct {
+	baseURL string
+	project string
+	client  *http.Client
+}
+
+func newGCSClient(ctx context.Context, connection *Connection) (*gcsClient, error) {
+	if connection.GoogleJSONKeyString == "" {
+		// The key file or the application default credentials are only
+		// available inside the GitLab Pods.
+		return nil, ErrUnsupportedCredentials
+	}
+
+	key := gcsServiceAccountKey{}
+	if err := json.Unmarshal([]byte(connection.GoogleJSONKeyString), &key); err != nil {
+		return nil, fmt.Errorf("can not parse google_json_key_string: %w", err)
+	}
+
+	if key.ClientEmail == "" {
+		key.ClientEmail = connection.GoogleClientEmail
+	}
+
+	if key.TokenURI == "" {
+		key.TokenURI = gcsTokenURL
+	}
+
+	config := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		TokenURL:     key.TokenURI,
+		Scopes:       []string{gcsScope},
+	}
+
+	client := config.Client(ctx)
+	client.Timeout = requestTimeout
+
+	return &gcsClient{
+		baseURL: gcsBaseURL,
+		project: connection.GoogleProject,
+		client:  client,
+	}, nil
+}
+
+func (c *gcsClient) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	response, err := c.do(ctx, http.MethodGet, gcsBucketPath+"/"+url.PathEscape(bucket), nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return false, checkResponse(response, http.StatusNotFound)
+	}
+
+	return true, checkResponse(response, http.StatusOK)
+}
+
+func (c *gcsClient) CreateBucket(ctx context.Context, bucket string) error {
+	body, err := json.Marshal(map[string]string{"name": bucket})
+	if err != nil {
+		return err
+	}
+
+	response, err := c.do(ctx, http.MethodPost, gcsBucketPath,
+		url.Values{"project": {c.project}}, body)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(response, http.StatusOK)
+}
+
+func (c *gcsClient) CheckWritable(ctx context.Context, bucket string) error {
+	response, err := c.do(ctx, http.MethodPost, "/upload"+gcsBucketPath+"/"+url.PathEscape(bucket)+"/o",
+		url.Values{"uploadType": {"media"}, "name": {writeCheckObject}}, []byte{})
+	if err != nil {
+		return err
+	}
+
+	if err := checkResponse(response, http.StatusOK); err != nil {
+		return err
+	}
+
+	response, err = c.do(ctx, http.MethodDelete,
+		gcsBucketPath+"/"+url.PathEscape(bucket)+"/o/"+url.PathEscape(writeCheckObject), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(response, http.StatusOK, http.StatusNoContent)
+}
+
+func (c *gcsClient) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > 0 {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.client.Do(request)
+}
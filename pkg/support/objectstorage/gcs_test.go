@@ -0,0 +1,93 @@
+package objectstorage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GCS client", func() {
+	var (
+		mutex    sync.Mutex
+		requests []string
+		client   *gcsClient
+		ctx      = context.Background()
+	)
+
+	BeforeEach(func() {
+		requests = []string{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if r.URL.Path == "/token" {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`))
+
+				return
+			}
+
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			requests = append(requests, r.Method+" "+r.URL.RequestURI())
+
+			if r.URL.Path == "/storage/v1/b/gitlab-uploads" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+
+		key, err := json.Marshal(map[string]string{
+			"client_email": "gitlab@gitlab.iam.gserviceaccount.com",
+			"private_key": string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+			})),
+			"token_uri": server.URL + "/token",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		client, err = newGCSClient(ctx, &Connection{
+			Provider:            ProviderGoogle,
+			GoogleProject:       "gitlab",
+			GoogleJSONKeyString: string(key),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		client.baseURL = server.URL
+	})
+
+	It("checks, creates, and writes buckets with an access token", func() {
+		Expect(client.BucketExists(ctx, "gitlab-artifacts")).To(BeTrue())
+		Expect(client.BucketExists(ctx, "gitlab-uploads")).To(BeFalse())
+		Expect(client.CreateBucket(ctx, "gitlab-uploads")).To(Succeed())
+		Expect(client.CheckWritable(ctx, "gitlab-artifacts")).To(Succeed())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		Expect(requests).To(Equal([]string{
+			"GET /storage/v1/b/gitlab-artifacts",
+			"GET /storage/v1/b/gitlab-uploads",
+			"POST /storage/v1/b?project=gitlab",
+			"POST /upload/storage/v1/b/gitlab-artifacts/o?name=.gitlab-operator-write-check&uploadType=media",
+			"DELETE /storage/v1/b/gitlab-artifacts/o/.gitlab-operator-write-check",
+		}))
+	})
+})
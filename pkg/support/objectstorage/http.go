@@ -0,0 +1,56 @@
+package objectstorage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+
+	// maxErrorBodySize limits the size of the response body that is included
+	// in errors.
+	maxErrorBodySize = 512
+)
+
+// ResponseError is returned when the provider answers with an unexpected
+// status code.
+type ResponseError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ResponseError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected response status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("unexpected response status %d: %s", e.StatusCode, e.Body)
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: requestTimeout}
+}
+
+// checkResponse drains and closes the response body and returns a
+// ResponseError when the status code is not one of the expected ones.
+func checkResponse(response *http.Response, expected ...int) error {
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	for _, code := range expected {
+		if response.StatusCode == code {
+			return nil
+		}
+	}
+
+	return &ResponseError{
+		StatusCode: response.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+	}
+}
@@ -0,0 +1,288 @@
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+// s3Client talks to AWS S3 or an S3-compatible endpoint and signs the
+// requests with AWS Signature Version 4.
+type s3Client struct {
+	endpoint  *url.URL
+	region    string
+	pathStyle bool
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func newS3Client(connection *Connection) (*s3Client, error) {
+	if connection.UseIAMProfile {
+		return nil, ErrUnsupportedCredentials
+	}
+
+	region := connection.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	endpoint := connection.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	if endpointURL.Scheme == "" || endpointURL.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme and host are required", endpoint)
+	}
+
+	client := newHTTPClient()
+
+	// Redirects point to the endpoint of another region. The signature of the
+	// request is only valid for the configured region.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &s3Client{
+		endpoint:  endpointURL,
+		region:    region,
+		pathStyle: connection.PathStyle,
+		accessKey: connection.AWSAccessKeyID,
+		secretKey: connection.AWSSecretAccessKey,
+		client:    client,
+		now:       time.Now,
+	}, nil
+}
+
+// WrongRegionError is returned when a bucket exists in another region than
+// the region of the connection.
+type WrongRegionError struct {
+	Bucket           string
+	Region           string
+	ConfiguredRegion string
+}
+
+func (e *WrongRegionError) Error() string {
+	if e.Region == "" {
+		return fmt.Sprintf("bucket %s exists in another region than %s", e.Bucket, e.ConfiguredRegion)
+	}
+
+	return fmt.Sprintf("bucket %s exists in region %s, not in region %s", e.Bucket, e.Region, e.ConfiguredRegion)
+}
+
+func (c *s3Client) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	response, err := c.do(ctx, http.MethodHead, bucket, "", nil)
+	if err != nil {
+		return false, err
+	}
+
+	switch response.StatusCode {
+	case http.StatusNotFound:
+		return false, checkResponse(response, http.StatusNotFound)
+	case http.StatusMovedPermanently:
+		// S3 answers with PermanentRedirect when the bucket exists in another
+		// region than the one of the endpoint.
+		_ = checkResponse(response, http.StatusMovedPermanently)
+
+		return true, &WrongRegionError{
+			Bucket:           bucket,
+			Region:           response.Header.Get("X-Amz-Bucket-Region"),
+			ConfiguredRegion: c.region,
+		}
+	}
+
+	return true, checkResponse(response, http.StatusOK)
+}
+
+func (c *s3Client) CreateBucket(ctx context.Context, bucket string) error {
+	var body []byte
+
+	if c.region != defaultS3Region {
+		body = []byte(fmt.Sprintf(
+			`<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`+
+				`<LocationConstraint>%s</LocationConstraint></CreateBucketConfiguration>`, c.region))
+	}
+
+	response, err := c.do(ctx, http.MethodPut, bucket, "", body)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(response, http.StatusOK)
+}
+
+func (c *s3Client) CheckWritable(ctx context.Context, bucket string) error {
+	response, err := c.do(ctx, http.MethodPut, bucket, writeCheckObject, []byte{})
+	if err != nil {
+		return err
+	}
+
+	if err := checkResponse(response, http.StatusOK); err != nil {
+		return err
+	}
+
+	response, err = c.do(ctx, http.MethodDelete, bucket, writeCheckObject, nil)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(response, http.StatusOK, http.StatusNoContent)
+}
+
+func (c *s3Client) do(ctx context.Context, method, bucket, key string, body []byte) (*http.Response, error) {
+	target := *c.endpoint
+
+	if c.pathStyle {
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/" + bucket
+	} else {
+		target.Host = bucket + "." + target.Host
+	}
+
+	if key != "" {
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/" + key
+	}
+
+	if target.Path == "" {
+		target.Path = "/"
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if body == nil {
+		request.Body = http.NoBody
+		request.ContentLength = 0
+	}
+
+	c.sign(request, body)
+
+	return c.client.Do(request)
+}
+
+// sign adds the AWS Signature Version 4 headers to the request.
+func (c *s3Client) sign(request *http.Request, body []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format(amzDateFormat)
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	headerValues := map[string]string{
+		"host":                 request.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+
+	canonicalHeaders := ""
+	for _, name := range signedHeaders {
+		canonicalHeaders += name + ":" + headerValues[name] + "\n"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		s3CanonicalURI(request.URL.Path),
+		s3CanonicalQuery(request.URL.Query()),
+		canonicalHeaders,
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, c.region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, c.accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+func s3CanonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	parts := []string{}
+
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+
+		for _, v := range values {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3Escape escapes all characters except the unreserved characters of
+// RFC 3986, as Signature Version 4 requires.
+func s3Escape(value string) string {
+	return strings.NewReplacer("+", "%20", "%7E", "~").Replace(url.QueryEscape(value))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,102 @@
+package objectstorage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeStorage is an in-memory object storage that answers S3-compatible
+// path-style requests.
+type fakeStorage struct {
+	mutex    sync.Mutex
+	buckets  map[string]map[string]bool
+	requests []*http.Request
+	readOnly bool
+}
+
+func newFakeStorage(buckets ...string) (*fakeStorage, *httptest.Server) {
+	storage := &fakeStorage{buckets: map[string]map[string]bool{}}
+
+	for _, bucket := range buckets {
+		storage.buckets[bucket] = map[string]bool{}
+	}
+
+	server := httptest.NewServer(storage)
+	DeferCleanup(server.Close)
+
+	return storage, server
+}
+
+func (s *fakeStorage) Requests() []*http.Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests
+}
+
+func (s *fakeStorage) SetReadOnly(readOnly bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.readOnly = readOnly
+}
+
+func (s *fakeStorage) HasBucket(bucket string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.buckets[bucket]
+
+	return ok
+}
+
+func (s *fakeStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, r)
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access-key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	objects, exists := s.buckets[bucket]
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case key == "" && r.Method == http.MethodPut:
+		if exists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		s.buckets[bucket] = map[string]bool{}
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+	case s.readOnly:
+		w.WriteHeader(http.StatusForbidden)
+	case r.Method == http.MethodPut:
+		objects[key] = true
+	case r.Method == http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestObjectStorage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GitLab Operator Framework: Object Storage Support")
+}
@@ -0,0 +1,348 @@
+// Package redis provides a minimal Redis client that implements the subset of
+// the Redis serialization protocol (RESP) that the Operator needs to inspect
+// Redis servers and Sentinels.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+)
+
+// Error is an error reply of the Redis server.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Options describes how to connect to a Redis server or Sentinel.
+type Options struct {
+	// Address is the `host:port` of the server.
+	Address string
+
+	// Username and Password are used for AUTH. AUTH is skipped when Password
+	// is empty. Username is optional and requires Redis 6 ACLs.
+	Username string
+	Password string
+
+	// TLS enables TLS when it is not nil.
+	TLS *tls.Config
+
+	// Timeout of dialing and of each command. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Client is a connection to a Redis server. It is not safe for concurrent use.
+type Client struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+// Dial connects to the Redis server and authenticates when a password is
+// provided.
+func Dial(ctx context.Context, options Options) (*Client, error) {
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if options.TLS != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: options.TLS}).DialContext(ctx, "tcp", options.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", options.Address)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		timeout: timeout,
+	}
+
+	if options.Password != "" {
+		args := []interface{}{"AUTH", options.Password}
+		if options.Username != "" {
+			args = []interface{}{"AUTH", options.Username, options.Password}
+		}
+
+		if _, err := client.Do(args...); err != nil {
+			client.Close()
+
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Do sends a command and returns its reply. Arguments can be strings, byte
+// slices, or integers. Replies are returned as string (simple and bulk
+// strings), int64, []interface{}, or nil. Error replies are returned as Error.
+func (c *Client) Do(args ...interface{}) (interface{}, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	var cmd strings.Builder
+
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+
+	for _, arg := range args {
+		var value string
+
+		switch v := arg.(type) {
+		case string:
+			value = v
+		case []byte:
+			value = string(v)
+		case int:
+			value = strconv.Itoa(v)
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		default:
+			return nil, fmt.Errorf("unsupported argument type %T", arg)
+		}
+
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(value), value)
+	}
+
+	if _, err := io.WriteString(c.conn, cmd.String()); err != nil {
+		return nil, err
+	}
+
+	return c.readReply()
+}
+
+// Info returns the fields of the specified section of the INFO command.
+func (c *Client) Info(section string) (map[string]string, error) {
+	reply, err := c.Do("INFO", section)
+	if err != nil {
+		return nil, err
+	}
+
+	text, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply to INFO: %v", reply)
+	}
+
+	result := map[string]string{}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if key, value, found := strings.Cut(line, ":"); found {
+			result[key] = value
+		}
+	}
+
+	return result, nil
+}
+
+// ServerVersion returns the version of the Redis server.
+func (c *Client) ServerVersion() (string, error) {
+	info, err := c.Info("server")
+	if err != nil {
+		return "", err
+	}
+
+	version, ok := info["redis_version"]
+	if !ok {
+		return "", fmt.Errorf("server did not report its version")
+	}
+
+	return version, nil
+}
+
+// ListLength returns the length of the list. It returns zero when the list
+// does not exist.
+func (c *Client) ListLength(key string) (int64, error) {
+	reply, err := c.Do("LLEN", key)
+	if err != nil {
+		return 0, err
+	}
+
+	length, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected reply to LLEN: %v", reply)
+	}
+
+	return length, nil
+}
+
+// ListIndex returns the element of the list at the index. Negative indexes
+// count from the tail of the list. It returns false when the element does not
+// exist.
+func (c *Client) ListIndex(key string, index int64) (string, bool, error) {
+	reply, err := c.Do("LINDEX", key, index)
+	if err != nil {
+		return "", false, err
+	}
+
+	if reply == nil {
+		return "", false, nil
+	}
+
+	element, ok := reply.(string)
+	if !ok {
+		return "", false, fmt.Errorf("unexpected reply to LINDEX: %v", reply)
+	}
+
+	return element, true, nil
+}
+
+// MasterAddress asks a Sentinel for the address of the master of the
+// specified group.
+func (c *Client) MasterAddress(masterName string) (string, error) {
+	reply, err := c.Do("SENTINEL", "get-master-addr-by-name", masterName)
+	if err != nil {
+		return "", err
+	}
+
+	parts, ok := reply.([]interface{})
+	if !ok || len(parts) != 2 {
+		return "", fmt.Errorf("sentinel does not know master %q", masterName)
+	}
+
+	host, _ := parts[0].(string)
+	port, _ := parts[1].(string)
+
+	return net.JoinHostPort(host, port), nil
+}
+
+func (c *Client) readReply() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, buf); err != nil {
+			return nil, err
+		}
+
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if count < 0 {
+			return nil, nil
+		}
+
+		result := make([]interface{}, count)
+
+		for i := range result {
+			item, err := c.readReply()
+			if err != nil {
+				if _, isError := err.(Error); !isError {
+					return nil, err
+				}
+
+				item = err
+			}
+
+			result[i] = item
+		}
+
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unexpected reply: %q", line)
+	}
+}
+
+func (c *Client) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// DiscoverMaster asks the Sentinels in turn for the address of the master of
+// the specified group and returns the first answer. The address of each
+// Sentinel in sentinels overrides the address in options.
+func DiscoverMaster(ctx context.Context, sentinels []string, masterName string, options Options) (string, error) {
+	if len(sentinels) == 0 {
+		return "", fmt.Errorf("no sentinels are configured")
+	}
+
+	errs := []string{}
+
+	for _, sentinel := range sentinels {
+		options.Address = sentinel
+
+		address, err := askSentinel(ctx, masterName, options)
+		if err == nil {
+			return address, nil
+		}
+
+		errs = append(errs, fmt.Sprintf("%s: %v", sentinel, err))
+	}
+
+	return "", fmt.Errorf("can not discover master %q: %s", masterName, strings.Join(errs, "; "))
+}
+
+func askSentinel(ctx context.Context, masterName string, options Options) (string, error) {
+	client, err := Dial(ctx, options)
+	if err != nil {
+		return "", err
+	}
+
+	defer client.Close()
+
+	return client.MasterAddress(masterName)
+}
@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var server *fakeServer
+
+	BeforeEach(func() {
+		server = newFakeServer(func(args []string) string {
+			switch strings.ToUpper(args[0]) {
+			case "AUTH":
+				if args[len(args)-1] == "secret" {
+					return "+OK\r\n"
+				}
+
+				return "-WRONGPASS invalid username-password pair\r\n"
+			case "INFO":
+				return bulkString("# Server\r\nredis_version:7.0.12\r\nredis_mode:standalone\r\n")
+			case "SENTINEL":
+				if args[2] == "gitlab-redis" {
+					return "*2\r\n" + bulkString("10.0.0.1") + bulkString("6379")
+				}
+
+				return "*-1\r\n"
+			case "DBSIZE":
+				return ":42\r\n"
+			case "LLEN":
+				if args[1] == "queue:default" {
+					return ":3\r\n"
+				}
+
+				return ":0\r\n"
+			case "LINDEX":
+				if args[1] == "queue:default" && args[2] == "-1" {
+					return bulkString(`{"enqueued_at":1700000000.5}`)
+				}
+
+				return "$-1\r\n"
+			default:
+				return "-ERR unknown command\r\n"
+			}
+		})
+	})
+
+	It("authenticates with the password", func() {
+		client, err := Dial(context.Background(), Options{
+			Address:  server.Address(),
+			Password: "secret",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Close()).To(Succeed())
+		Expect(server.Commands()).To(ContainElement([]string{"AUTH", "secret"}))
+	})
+
+	It("authenticates with the username and password", func() {
+		client, err := Dial(context.Background(), Options{
+			Address:  server.Address(),
+			Username: "gitlab",
+			Password: "secret",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Close()).To(Succeed())
+		Expect(server.Commands()).To(ContainElement([]string{"AUTH", "gitlab", "secret"}))
+	})
+
+	It("fails when the password is wrong", func() {
+		_, err := Dial(context.Background(), Options{
+			Address:  server.Address(),
+			Password: "wrong",
+		})
+
+		Expect(err).To(MatchError(ContainSubstring("WRONGPASS")))
+	})
+
+	It("reads the server version", func() {
+		client, err := Dial(context.Background(), Options{Address: server.Address()})
+		Expect(err).NotTo(HaveOccurred())
+
+		defer client.Close()
+
+		Expect(client.ServerVersion()).To(Equal("7.0.12"))
+	})
+
+	It("asks Sentinel for the master address", func() {
+		client, err := Dial(context.Background(), Options{Address: server.Address()})
+		Expect(err).NotTo(HaveOccurred())
+
+		defer client.Close()
+
+		Expect(client.MasterAddress("gitlab-redis")).To(Equal("10.0.0.1:6379"))
+
+		_, err = client.MasterAddress("unknown")
+		Expect(err).To(MatchError(ContainSubstring("does not know master")))
+	})
+
+	It("returns integer and error replies", func() {
+		client, err := Dial(context.Background(), Options{Address: server.Address()})
+		Expect(err).NotTo(HaveOccurred())
+
+		defer client.Close()
+
+		Expect(client.Do("DBSIZE")).To(Equal(int64(42)))
+
+		_, err = client.Do("FOO")
+		Expect(err).To(Equal(Error("ERR unknown command")))
+	})
+
+	It("reads the length of a list", func() {
+		client, err := Dial(context.Background(), Options{Address: server.Address()})
+		Expect(err).NotTo(HaveOccurred())
+
+		defer client.Close()
+
+		Expect(client.ListLength("queue:default")).To(Equal(int64(3)))
+		Expect(client.ListLength("queue:mailers")).To(BeZero())
+	})
+
+	It("reads an element of a list", func() {
+		client, err := Dial(context.Background(), Options{Address: server.Address()})
+		Expect(err).NotTo(HaveOccurred())
+
+		defer client.Close()
+
+		element, found, err := client.ListIndex("queue:default", -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(element).To(Equal(`{"enqueued_at":1700000000.5}`))
+
+		_, found, err = client.ListIndex("queue:mailers", -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+})
+
+var _ = Describe("DiscoverMaster", func() {
+	It("uses the first Sentinel that knows the master", func() {
+		unknown := newFakeServer(func(args []string) string {
+			return "*-1\r\n"
+		})
+		known := newFakeServer(func(args []string) string {
+			return "*2\r\n" + bulkString("10.0.0.2") + bulkString("6380")
+		})
+
+		address, err := DiscoverMaster(context.Background(),
+			[]string{unknown.Address(), known.Address()}, "gitlab-redis", Options{})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("10.0.0.2:6380"))
+		Expect(known.Commands()).To(Equal([][]string{{"SENTINEL", "get-master-addr-by-name", "gitlab-redis"}}))
+	})
+
+	It("fails when no Sentinel knows the master", func() {
+		unknown := newFakeServer(func(args []string) string {
+			return "*-1\r\n"
+		})
+
+		_, err := DiscoverMaster(context.Background(), []string{unknown.Address()}, "gitlab-redis", Options{})
+
+		Expect(err).To(MatchError(ContainSubstring("can not discover master")))
+	})
+})
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeServer accepts RESP commands and answers them with the raw replies
+// returned by handler.
+type fakeServer struct {
+	listener net.Listener
+	handler  func(args []string) string
+
+	mutex    sync.Mutex
+	commands [][]string
+}
+
+func newFakeServer(handler func(args []string) string) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	server := &fakeServer{
+		listener: listener,
+		handler:  handler,
+	}
+
+	go server.serve()
+
+	DeferCleanup(listener.Close)
+
+	return server
+}
+
+func (s *fakeServer) Address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) Commands() [][]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.commands
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.commands = append(s.commands, args)
+		s.mutex.Unlock()
+
+		if _, err := io.WriteString(conn, s.handler(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		value, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = strings.TrimRight(value, "\r\n")
+	}
+
+	return args, nil
+}
+
+func bulkString(value string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func TestRedis(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GitLab Operator Framework: Redis Support")
+}